@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"strconv"
 	"syscall"
+	"time"
 
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/store/sqlstore"
@@ -79,6 +80,25 @@ func main() {
 	
 	// Setup XRay converter service
 	xrayConverterService := services.NewXRayConverterService(learningRepo, logger)
+	if resolver, err := services.NewHostResolver(cfg.DNSUpstream, cfg.DNSCacheTTL); err != nil {
+		logger.Errorf("Invalid DNS upstream, using system resolver: %v", err)
+	} else {
+		xrayConverterService.SetResolver(resolver)
+	}
+	xrayConverterService.SetFingerprintSecret(cfg.XRayFingerprintSecret)
+	xrayConverterService.SetBundleSecret(cfg.XRayBundleSecret)
+	
+	// Retensi log konversi XRay: prune saat start lalu setiap 6 jam
+	if cfg.XRayLogRetentionDays > 0 {
+		retention := time.Duration(cfg.XRayLogRetentionDays) * 24 * time.Hour
+		pruneLogs := func() {
+			if _, err := xrayConverterService.PruneConversionLogs(retention); err != nil {
+				logger.Errorf("Failed to prune XRay conversion logs: %v", err)
+			}
+		}
+		pruneLogs()
+		services.NewSchedulerService(pruneLogs, logger).Start(6 * time.Hour)
+	}
 	
 	// Insert default XRay converters
 	logger.Info("Setting up default XRay converters...")
@@ -87,6 +107,9 @@ func main() {
 	} else {
 		logger.Success("Default XRay converters setup complete!")
 	}
+	if err := database.InsertDefaultRuleTemplates(learningRepo); err != nil {
+		logger.Errorf("Failed to insert default rule templates: %v", err)
+	}
 	
 	// Setup learning message handler
 	learningMessageHandler := handlers.NewLearningMessageHandler(client, learningService, xrayConverterService, logger, promoteCfg.AdminNumbers)
@@ -94,6 +117,7 @@ func main() {
 	// Setup dashboard server
 	dashboardServer := web.NewDashboardServer(learningRepo, logger, promoteCfg.AdminNumbers)
 	dashboardServer.SetWhatsAppClient(client)
+	dashboardServer.SetXRayConverterService(xrayConverterService)
 	
 	logger.Success("Learning System initialized!")
 	
@@ -112,12 +136,16 @@ func main() {
 		defer promoteDB.Close()
 		
 		// Setup services (template service jika diperlukan)
-		// templateService := services.NewTemplateService(promoteRepo, logger)
+		templateService := services.NewTemplateService(promoteRepo, logger)
 		autoPromoteService = services.NewAutoPromoteService(client, promoteRepo, logger)
 		// Set interval dari konfigurasi
 		autoPromoteService.SetInterval(promoteCfg.AutoPromoteInterval)
+		dashboardServer.SetAutoPromoteService(autoPromoteService)
+		dashboardServer.SetPromoteTemplateService(templateService)
+		// Data produk untuk loop {{#each PRODUCTS}} di template promosi
+		apiProductService := services.NewAPIProductService(templateService, logger)
+		autoPromoteService.SetProductSource(apiProductService)
 		// Services untuk auto promote (jika diperlukan nanti)
-		// apiProductService := services.NewAPIProductService(templateService, logger)
 		// groupManagerService := services.NewGroupManagerService(client, promoteRepo, logger)
 		
 		// Setup command handlers (if needed for specific use cases)
@@ -142,6 +170,13 @@ func main() {
 	if err != nil || port == 0 {
 		port = 1462 // Default port
 	}
+	
+	// Base URL subscription: PUBLIC_URL atau localhost dashboard
+	subscriptionBaseURL := cfg.PublicURL
+	if subscriptionBaseURL == "" {
+		subscriptionBaseURL = fmt.Sprintf("http://localhost:%d", port)
+	}
+	learningMessageHandler.SetSubscriptionBaseURL(subscriptionBaseURL)
 
 	go func() {
 		if err := dashboardServer.StartServer(port); err != nil {
@@ -4,6 +4,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 // Config adalah struktur yang menyimpan semua konfigurasi bot
@@ -23,6 +25,28 @@ type Config struct {
 	// AutoReplyGroup menentukan apakah bot otomatis membalas chat grup
 	// PENTING: Set false jika Anda ada di banyak grup untuk menghindari spam
 	AutoReplyGroup bool
+	
+	// PublicURL adalah base URL publik dashboard (dipakai untuk link subscription)
+	// Kosongkan untuk memakai http://localhost:<PORT>
+	PublicURL string
+	
+	// DNSUpstream resolver untuk placeholder {bug_ip} di template converter:
+	// "system", "https://cloudflare-dns.com/dns-query" (DoH) atau "tls://1.1.1.1:853" (DoT)
+	DNSUpstream string
+	
+	// DNSCacheTTL lama cache hasil resolve bug host (0 = tanpa cache)
+	DNSCacheTTL time.Duration
+	
+	// XRayLogRetentionDays lama penyimpanan log konversi XRay (0 = simpan selamanya)
+	XRayLogRetentionDays int
+	
+	// XRayFingerprintSecret secret HMAC untuk fingerprint UUID/password di log konversi
+	// Kosongkan untuk menonaktifkan fingerprint
+	XRayFingerprintSecret string
+	
+	// XRayBundleSecret secret HMAC untuk menandatangani bundle export converter.
+	// Jika diisi, import hanya menerima bundle dengan signature yang cocok.
+	XRayBundleSecret string
 }
 
 // NewConfig membuat konfigurasi default untuk bot
@@ -44,6 +68,18 @@ func NewConfig() *Config {
 		// Auto reply untuk grup DIMATIKAN untuk menghindari spam
 		// Anda bisa mengubah ini ke true jika ingin bot membalas di grup
 		AutoReplyGroup: getEnvBoolOrDefault("AUTO_REPLY_GROUP", false),
+		
+		// Base URL publik untuk subscription URL (misal https://bot.example.com)
+		PublicURL: getEnvOrDefault("PUBLIC_URL", ""),
+		
+		// Resolver DNS untuk {bug_ip}, default resolver sistem dengan cache 10 menit
+		DNSUpstream: getEnvOrDefault("DNS_UPSTREAM", "system"),
+		DNSCacheTTL: getEnvDurationOrDefault("DNS_CACHE_TTL", 10*time.Minute),
+		
+		// Log konversi XRay disimpan selamanya kecuali retensi diatur, fingerprint kredensial opsional
+		XRayLogRetentionDays:  getEnvNumberOrDefault("XRAY_LOG_RETENTION_DAYS", 0),
+		XRayFingerprintSecret: getEnvOrDefault("XRAY_FINGERPRINT_SECRET", ""),
+		XRayBundleSecret:      getEnvOrDefault("XRAY_BUNDLE_SECRET", ""),
 	}
 }
 
@@ -61,4 +97,24 @@ func getEnvBoolOrDefault(key string, defaultValue bool) bool {
 		return value == "true" || value == "1"
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// getEnvNumberOrDefault mengambil angka bebas dari environment variable atau menggunakan default
+func getEnvNumberOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if number, err := strconv.Atoi(value); err == nil {
+			return number
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDurationOrDefault mengambil durasi (misal "10m", "30s") dari environment variable atau menggunakan default
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
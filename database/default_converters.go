@@ -2,94 +2,44 @@
 package database
 
 import (
-	"time"
+	_ "embed"
+	"encoding/json"
+	"fmt"
 )
 
-// DefaultXRayConverters berisi converter default untuk testing
-var DefaultXRayConverters = []XRayConverter{
-	{
-		CommandName:     "convertbizz",
-		DisplayName:     "XL-Line-WC",
-		BugHost:         "ava.game.naver.com",
-		ModifyType:      "wildcard",
-		ServerTemplate:  "", // Empty = use legacy wildcard logic
-		HostTemplate:    "", // Empty = use legacy wildcard logic
-		SNITemplate:     "", // Empty = use legacy wildcard logic
-		PathTemplate:    "/rsv",
-		GrpcServiceName: "",
-		PortOverride:    nil,
-		IsActive:        true,
-		UsageCount:      0,
-		CreatedBy:       "system",
-	},
-	{
-		CommandName:     "convertinsta",
-		DisplayName:     "XL-Instagram-SNI",
-		BugHost:         "chat.instagram.com",
-		ModifyType:      "sni",
-		PathTemplate:    "",
-		GrpcServiceName: "",
-		PortOverride:    nil,
-		IsActive:        true,
-		UsageCount:      0,
-		CreatedBy:       "system",
-	},
-	{
-		CommandName:     "convertnetflix",
-		DisplayName:     "XL-Netflix-WS",
-		BugHost:         "cache.netflix.com",
-		ModifyType:      "ws",
-		PathTemplate:    "/upvmess",
-		GrpcServiceName: "",
-		PortOverride:    nil,
-		IsActive:        true,
-		UsageCount:      0,
-		CreatedBy:       "system",
-	},
-	{
-		CommandName:     "convertgopay",
-		DisplayName:     "XL-Gopay-Midtrans-WC",
-		BugHost:         "api.midtrans.com",
-		ModifyType:      "wildcard",
-		PathTemplate:    "",
-		GrpcServiceName: "vmess-grpc",
-		PortOverride:    nil,
-		IsActive:        true,
-		UsageCount:      0,
-		CreatedBy:       "system",
-	},
-	{
-		CommandName:     "convertgrpc",
-		DisplayName:     "Generic-gRPC",
-		BugHost:         "cloudflare.com",
-		ModifyType:      "grpc",
-		PathTemplate:    "",
-		GrpcServiceName: "grpc-service",
-		PortOverride:    nil,
-		IsActive:        true,
-		UsageCount:      0,
-		CreatedBy:       "system",
-	},
-	{
-		CommandName:     "convertcustom",
-		DisplayName:     "Custom-Template-Demo",
-		BugHost:         "cloudflare.com",
-		ModifyType:      "custom",
-		ServerTemplate:  "{bug_host}",
-		HostTemplate:    "{bug_host}.{original_server}",
-		SNITemplate:     "{bug_host}.{original_server}",
-		PathTemplate:    "",
-		GrpcServiceName: "",
-		PortOverride:    nil,
-		IsActive:        true,
-		UsageCount:      0,
-		CreatedBy:       "system",
-	},
+// defaultConvertersBundle bundle converter default (format sama dengan export dashboard)
+//
+//go:embed default_converters.json
+var defaultConvertersBundle []byte
+
+// DefaultXRayConverters membaca converter default dari bundle yang di-embed
+func DefaultXRayConverters() ([]XRayConverter, error) {
+	var bundle XRayConverterBundle
+	if err := json.Unmarshal(defaultConvertersBundle, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid default converters bundle: %v", err)
+	}
+	
+	converters := make([]XRayConverter, 0, len(bundle.Converters))
+	for _, entry := range bundle.Converters {
+		converter, err := DecodeBundleConverter(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default converter: %v", err)
+		}
+		converter.CreatedBy = bundle.ExportedBy
+		converters = append(converters, *converter)
+	}
+	
+	return converters, nil
 }
 
 // InsertDefaultConverters menambahkan default converters ke database
 func InsertDefaultConverters(repo Repository) error {
-	for _, converter := range DefaultXRayConverters {
+	converters, err := DefaultXRayConverters()
+	if err != nil {
+		return err
+	}
+	
+	for _, converter := range converters {
 		// Cek apakah converter sudah ada
 		existing, err := repo.GetXRayConverter(converter.CommandName)
 		if err != nil {
@@ -98,9 +48,6 @@ func InsertDefaultConverters(repo Repository) error {
 		
 		// Jika belum ada, tambahkan
 		if existing == nil {
-			converter.CreatedAt = time.Now()
-			converter.UpdatedAt = time.Now()
-			
 			err = repo.CreateXRayConverter(&converter)
 			if err != nil {
 				return err
@@ -109,4 +56,4 @@ func InsertDefaultConverters(repo Repository) error {
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
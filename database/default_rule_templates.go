@@ -0,0 +1,55 @@
+// Package database - Default rule template untuk profile Mihomo/OpenClash
+package database
+
+// DefaultXRayRuleTemplates berisi template profile bawaan
+var DefaultXRayRuleTemplates = []XRayRuleTemplate{
+	{
+		Name:          "basic",
+		Description:   "Semua traffic lewat proxy, IP lokal direct",
+		DNSMode:       "fake-ip",
+		RuleProviders: "",
+		Rules: `GEOIP,PRIVATE,DIRECT,no-resolve
+MATCH,PROXY`,
+		IsActive: true,
+	},
+	{
+		Name:        "adblock",
+		Description: "Blokir iklan & tracker (Loyalsoldier), sisanya lewat proxy",
+		DNSMode:     "fake-ip",
+		RuleProviders: `reject:
+  type: http
+  behavior: domain
+  url: "https://cdn.jsdelivr.net/gh/Loyalsoldier/clash-rules@release/reject.txt"
+  path: ./ruleset/reject.yaml
+  interval: 86400
+private:
+  type: http
+  behavior: domain
+  url: "https://cdn.jsdelivr.net/gh/Loyalsoldier/clash-rules@release/private.txt"
+  path: ./ruleset/private.yaml
+  interval: 86400`,
+		Rules: `RULE-SET,private,DIRECT
+RULE-SET,reject,REJECT
+GEOIP,PRIVATE,DIRECT,no-resolve
+MATCH,PROXY`,
+		IsActive: true,
+	},
+}
+
+// InsertDefaultRuleTemplates menambahkan default rule template ke database
+func InsertDefaultRuleTemplates(repo Repository) error {
+	for _, template := range DefaultXRayRuleTemplates {
+		existing, err := repo.GetXRayRuleTemplate(template.Name)
+		if err != nil {
+			return err
+		}
+
+		if existing == nil {
+			if err := repo.CreateXRayRuleTemplate(&template); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -13,10 +13,15 @@ func RunMigrations(db *sql.DB) error {
 		createPromoteTemplatesTable,
 		createPromoteLogsTable,
 		createPromoteStatsTable,
+		createAutoPromoteGroupTemplatesTable,
 		// insertDefaultTemplates, // Dinonaktifkan - admin akan isi manual
 	}
 	
-	return runMigrations(db, migrations, "Auto Promote")
+	if err := runMigrations(db, migrations, "Auto Promote"); err != nil {
+		return err
+	}
+	
+	return runColumnMigrations(db, promoteColumnMigrations, "Auto Promote")
 }
 
 // RunLearningMigrations menjalankan migrasi untuk learning bot
@@ -28,11 +33,110 @@ func RunLearningMigrations(db *sql.DB) error {
 		deleteDuplicateAutoResponses, // Hapus duplikat
 		createCommandUsageLogsTable,
 		createForbiddenWordsTable, // Tambahkan ini
+		createXRaySubscriptionsTable,
+		createXRayRuleTemplatesTable,
+		createXRayQuotaOverridesTable,
+		createXRayConverterVersionsTable,
 		insertDefaultLearningCommands,
 		insertDefaultAutoResponses,
 	}
 	
-	return runMigrations(db, migrations, "Learning Bot")
+	if err := runMigrations(db, migrations, "Learning Bot"); err != nil {
+		return err
+	}
+	
+	return runColumnMigrations(db, learningColumnMigrations, "Learning Bot")
+}
+
+// columnMigration mendeskripsikan kolom baru untuk tabel yang sudah ada
+type columnMigration struct {
+	table      string
+	column     string
+	definition string
+}
+
+// promoteColumnMigrations kolom tambahan untuk tabel auto promote yang sudah ada
+var promoteColumnMigrations = []columnMigration{
+	{"auto_promote_groups", "schedule_cron", "TEXT NOT NULL DEFAULT ''"},
+	{"auto_promote_groups", "window_start", "TEXT NOT NULL DEFAULT ''"},
+	{"auto_promote_groups", "window_end", "TEXT NOT NULL DEFAULT ''"},
+	{"auto_promote_groups", "quiet_days", "TEXT NOT NULL DEFAULT ''"},
+	{"auto_promote_groups", "timezone", "TEXT NOT NULL DEFAULT ''"},
+	{"auto_promote_groups", "next_promote_at", "DATETIME"},
+	{"auto_promote_groups", "rotation_strategy", "TEXT NOT NULL DEFAULT ''"},
+	{"auto_promote_groups", "category_sequence", "TEXT NOT NULL DEFAULT ''"},
+	{"auto_promote_groups", "rotation_cursor", "INTEGER NOT NULL DEFAULT 0"},
+	{"promote_templates", "weight", "INTEGER NOT NULL DEFAULT 1"},
+	{"promote_templates", "media_path", "TEXT NOT NULL DEFAULT ''"},
+	{"promote_templates", "media_type", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// learningColumnMigrations kolom tambahan untuk tabel learning bot yang sudah ada
+var learningColumnMigrations = []columnMigration{
+	{"xray_converters", "output_formats", "TEXT NOT NULL DEFAULT ''"},
+	{"xray_converters", "profile_template", "TEXT NOT NULL DEFAULT ''"},
+	{"xray_converters", "rules", "TEXT NOT NULL DEFAULT ''"},
+	{"xray_converters", "probe_enabled", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"xray_converters", "daily_user_quota", "INTEGER NOT NULL DEFAULT 0"},
+	{"xray_converters", "daily_group_quota", "INTEGER NOT NULL DEFAULT 0"},
+	{"xray_converters", "daily_global_quota", "INTEGER NOT NULL DEFAULT 0"},
+	{"xray_converters", "delivery_mode", "TEXT NOT NULL DEFAULT ''"},
+	{"xray_converters", "category", "TEXT NOT NULL DEFAULT ''"},
+	{"xray_converters", "description", "TEXT NOT NULL DEFAULT ''"},
+	{"xray_converters", "example", "TEXT NOT NULL DEFAULT ''"},
+	{"xray_converters", "yaml_as_document", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"xray_converters", "version_id", "INTEGER NOT NULL DEFAULT 0"},
+	{"xray_converters", "updated_by", "TEXT NOT NULL DEFAULT ''"},
+	{"learning_groups", "xray_delivery_mode", "TEXT NOT NULL DEFAULT ''"},
+	{"xray_conversion_logs", "probe_status", "TEXT NOT NULL DEFAULT ''"},
+	{"xray_conversion_logs", "probe_latency_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"xray_conversion_logs", "probe_error", "TEXT NOT NULL DEFAULT ''"},
+	{"xray_conversion_logs", "credential_fingerprint", "TEXT NOT NULL DEFAULT ''"},
+	{"xray_conversion_logs", "converter_version_id", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// runColumnMigrations menambahkan kolom yang belum ada (SQLite tidak punya ADD COLUMN IF NOT EXISTS)
+func runColumnMigrations(db *sql.DB, columns []columnMigration, systemName string) error {
+	for _, col := range columns {
+		exists, err := columnExists(db, col.table, col.column)
+		if err != nil {
+			return fmt.Errorf("%s column migration %s.%s failed: %v", systemName, col.table, col.column, err)
+		}
+		if exists {
+			continue
+		}
+		
+		fmt.Printf("Adding %s column %s.%s...\n", systemName, col.table, col.column)
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", col.table, col.column, col.definition)
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("%s column migration %s.%s failed: %v", systemName, col.table, col.column, err)
+		}
+	}
+	
+	return nil
+}
+
+// columnExists cek apakah kolom sudah ada di tabel
+func columnExists(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	
+	return false, rows.Err()
 }
 
 // runMigrations helper function untuk menjalankan migrations
@@ -100,6 +204,20 @@ CREATE INDEX IF NOT EXISTS idx_promote_logs_sent_at ON promote_logs(sent_at);
 CREATE INDEX IF NOT EXISTS idx_promote_logs_success ON promote_logs(success);
 `
 
+// SQL untuk membuat tabel auto_promote_group_templates (target template per grup: kategori atau ID template)
+const createAutoPromoteGroupTemplatesTable = `
+CREATE TABLE IF NOT EXISTS auto_promote_group_templates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_jid TEXT NOT NULL,
+    category TEXT NOT NULL DEFAULT '',
+    template_id INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(group_jid, category, template_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_auto_promote_group_templates_group ON auto_promote_group_templates(group_jid);
+`
+
 // SQL untuk membuat tabel promote_stats
 const createPromoteStatsTable = `
 CREATE TABLE IF NOT EXISTS promote_stats (
@@ -534,4 +652,76 @@ CREATE INDEX IF NOT EXISTS idx_xray_converters_is_active ON xray_converters(is_a
 CREATE INDEX IF NOT EXISTS idx_xray_conversion_logs_converter_name ON xray_conversion_logs(converter_name);
 CREATE INDEX IF NOT EXISTS idx_xray_conversion_logs_used_at ON xray_conversion_logs(used_at);
 CREATE INDEX IF NOT EXISTS idx_xray_conversion_logs_user_jid ON xray_conversion_logs(user_jid);
-`
\ No newline at end of file
+`
+
+// SQL untuk membuat tabel subscription XRay per user
+const createXRaySubscriptionsTable = `
+CREATE TABLE IF NOT EXISTS xray_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_jid TEXT UNIQUE NOT NULL,
+    token TEXT UNIQUE NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS xray_subscription_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL,
+    converter_name TEXT NOT NULL,
+    original_link TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(subscription_id, converter_name, original_link),
+    FOREIGN KEY (subscription_id) REFERENCES xray_subscriptions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_xray_subscriptions_token ON xray_subscriptions(token);
+CREATE INDEX IF NOT EXISTS idx_xray_subscription_items_subscription ON xray_subscription_items(subscription_id);
+`
+
+// createXRayRuleTemplatesTable membuat tabel template profile Mihomo (DNS, rule-providers, rules)
+const createXRayRuleTemplatesTable = `
+CREATE TABLE IF NOT EXISTS xray_rule_templates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT UNIQUE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    dns_mode TEXT NOT NULL DEFAULT 'fake-ip' CHECK(dns_mode IN ('fake-ip', 'redir-host')),
+    rule_providers TEXT NOT NULL DEFAULT '',
+    rules TEXT NOT NULL DEFAULT '',
+    is_active BOOLEAN DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+// createXRayQuotaOverridesTable membuat tabel override kuota converter per user
+const createXRayQuotaOverridesTable = `
+CREATE TABLE IF NOT EXISTS xray_quota_overrides (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_number TEXT NOT NULL,
+    converter_name TEXT NOT NULL DEFAULT '',
+    daily_limit INTEGER NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_number, converter_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_xray_quota_overrides_user ON xray_quota_overrides(user_number);
+`
+
+// createXRayConverterVersionsTable membuat tabel riwayat perubahan converter (snapshot + diff per versi)
+const createXRayConverterVersionsTable = `
+CREATE TABLE IF NOT EXISTS xray_converter_versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    converter_name TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    action TEXT NOT NULL CHECK(action IN ('create', 'update', 'rollback', 'delete')),
+    snapshot TEXT NOT NULL,
+    diff TEXT NOT NULL DEFAULT '',
+    changed_by TEXT NOT NULL DEFAULT '',
+    rollback_of INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(converter_name, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_xray_converter_versions_converter ON xray_converter_versions(converter_name);
+`
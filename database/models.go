@@ -14,15 +14,41 @@ type AutoPromoteGroup struct {
 	LastPromoteAt *time.Time `json:"last_promote_at" db:"last_promote_at"` // Waktu terakhir kirim promosi
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`         // Waktu dibuat
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`         // Waktu diupdate
+
+	// Jadwal per grup (kosong = pakai interval global AUTO_PROMOTE_INTERVAL)
+	ScheduleCron  string     `json:"schedule_cron" db:"schedule_cron"`     // Cron 5 field, misal "0 9,15,20 * * *"
+	WindowStart   string     `json:"window_start" db:"window_start"`       // Awal jam kirim (HH:MM)
+	WindowEnd     string     `json:"window_end" db:"window_end"`           // Akhir jam kirim (HH:MM, eksklusif)
+	QuietDays     string     `json:"quiet_days" db:"quiet_days"`           // Hari libur promosi, misal "sat,sun"
+	Timezone      string     `json:"timezone" db:"timezone"`               // Zona waktu IANA (default Asia/Jakarta)
+	NextPromoteAt *time.Time `json:"next_promote_at" db:"next_promote_at"` // Jadwal kirim berikutnya (nil = hitung ulang)
+
+	// Rotasi template per grup
+	RotationStrategy string `json:"rotation_strategy" db:"rotation_strategy"` // random (default), roundrobin, weighted, lru, sequence
+	CategorySequence string `json:"category_sequence" db:"category_sequence"` // Urutan kategori untuk sequence, misal "produk,testimoni,diskon"
+	RotationCursor   int    `json:"rotation_cursor" db:"rotation_cursor"`     // roundrobin: ID template terakhir, sequence: index kategori berikutnya
+}
+
+// AutoPromoteGroupTarget satu target template grup: kategori (Category) atau template tertentu (TemplateID).
+// Grup tanpa target menerima semua template aktif.
+type AutoPromoteGroupTarget struct {
+	ID         int       `json:"id" db:"id"`
+	GroupJID   string    `json:"group_jid" db:"group_jid"`
+	Category   string    `json:"category" db:"category"`       // kosong jika target berupa template
+	TemplateID int       `json:"template_id" db:"template_id"` // 0 jika target berupa kategori
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 }
 
 // PromoteTemplate menyimpan template promosi bisnis
 type PromoteTemplate struct {
 	ID        int       `json:"id" db:"id"`
-	Title     string    `json:"title" db:"title"`         // Judul template (misal: "Produk Unggulan")
-	Content   string    `json:"content" db:"content"`     // Isi template promosi
-	Category  string    `json:"category" db:"category"`   // Kategori (produk, diskon, testimoni, dll)
-	IsActive  bool      `json:"is_active" db:"is_active"` // Status aktif/tidak
+	Title     string    `json:"title" db:"title"`           // Judul template (misal: "Produk Unggulan")
+	Content   string    `json:"content" db:"content"`       // Isi template promosi
+	Category  string    `json:"category" db:"category"`     // Kategori (produk, diskon, testimoni, dll)
+	IsActive  bool      `json:"is_active" db:"is_active"`   // Status aktif/tidak
+	Weight    int       `json:"weight" db:"weight"`         // Bobot untuk rotasi weighted (minimal 1)
+	MediaPath string    `json:"media_path" db:"media_path"` // Lampiran media opsional (path lokal hasil upload)
+	MediaType string    `json:"media_type" db:"media_type"` // image, video, atau document (kosong jika teks saja)
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -350,6 +376,7 @@ type LearningGroup struct {
 	GroupName   string    `json:"group_name" db:"group_name"`     // Nama grup
 	IsActive    bool      `json:"is_active" db:"is_active"`       // Status aktif/tidak
 	Description string    `json:"description" db:"description"`   // Deskripsi grup
+	XRayDeliveryMode string `json:"xray_delivery_mode" db:"xray_delivery_mode"` // group/dm/dm_ack, kosong = group
 	CreatedBy   string    `json:"created_by" db:"created_by"`     // Admin yang menambahkan
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`     // Waktu dibuat
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`     // Waktu diupdate
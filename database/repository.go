@@ -3,7 +3,10 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
 	"time"
 )
 
@@ -14,6 +17,12 @@ type Repository interface {
 	CreateAutoPromoteGroup(groupJID string) (*AutoPromoteGroup, error)
 	UpdateAutoPromoteGroup(group *AutoPromoteGroup) error
 	GetActiveGroups() ([]AutoPromoteGroup, error)
+	GetAllAutoPromoteGroups() ([]AutoPromoteGroup, error)
+	
+	// Auto Promote Group Targets (kategori/template per grup)
+	GetGroupTemplateTargets(groupJID string) ([]AutoPromoteGroupTarget, error)
+	GetAllGroupTemplateTargets() ([]AutoPromoteGroupTarget, error)
+	SetGroupTemplateTargets(groupJID string, targets []AutoPromoteGroupTarget) error
 	
 	// Promote Templates
 	GetAllTemplates() ([]PromoteTemplate, error)
@@ -26,6 +35,7 @@ type Repository interface {
 	// Promote Logs
 	CreateLog(log *PromoteLog) error
 	GetLogsByGroup(groupJID string, limit int) ([]PromoteLog, error)
+	GetLastSentByTemplate(groupJID string) (map[int]time.Time, error)
 	
 	// Stats
 	UpdateStats(date string, totalGroups, totalMessages, successMessages, failedMessages int) error
@@ -74,13 +84,43 @@ type Repository interface {
 	GetAllXRayConverters() ([]XRayConverter, error)
 	GetActiveXRayConverters() ([]XRayConverter, error)
 	UpdateXRayConverter(converter *XRayConverter) error
-	DeleteXRayConverter(commandName string) error
+	DeleteXRayConverter(commandName, deletedBy string) error
 	IncrementConverterUsage(commandName string) error
 	
+	// XRay Converter Versions (riwayat & rollback)
+	GetXRayConverterVersions(commandName string) ([]XRayConverterVersion, error)
+	GetXRayConverterVersion(id int) (*XRayConverterVersion, error)
+	RollbackXRayConverter(versionID int, changedBy string) (*XRayConverter, error)
+	
 	// XRay Conversion Logs
 	LogXRayConversion(log *XRayConversionLog) error
 	GetXRayConversionLogs(limit int) ([]XRayConversionLog, error)
 	GetXRayConversionStats(days int) (map[string]int, error)
+	CountXRayConversions(filter XRayConversionFilter) (int, *time.Time, error)
+	GetXRayConversionTimeAt(filter XRayConversionFilter, offset int) (*time.Time, error)
+	PruneXRayConversionLogs(before time.Time) (int64, error)
+	
+	// XRay Quota Overrides
+	SetXRayQuotaOverride(override *XRayQuotaOverride) error
+	GetXRayQuotaOverride(userNumber, converterName string) (*XRayQuotaOverride, error)
+	GetAllXRayQuotaOverrides() ([]XRayQuotaOverride, error)
+	DeleteXRayQuotaOverride(userNumber, converterName string) error
+	
+	// XRay Subscriptions
+	CreateXRaySubscription(sub *XRaySubscription) error
+	GetXRaySubscriptionByUser(userJID string) (*XRaySubscription, error)
+	GetXRaySubscriptionByToken(token string) (*XRaySubscription, error)
+	UpdateXRaySubscriptionToken(id int, token string) error
+	AddXRaySubscriptionItem(item *XRaySubscriptionItem) error
+	GetXRaySubscriptionItems(subscriptionID int) ([]XRaySubscriptionItem, error)
+	DeleteXRaySubscriptionItems(subscriptionID int) error
+	
+	// XRay Rule Templates (profile Mihomo)
+	CreateXRayRuleTemplate(template *XRayRuleTemplate) error
+	GetXRayRuleTemplate(name string) (*XRayRuleTemplate, error)
+	GetAllXRayRuleTemplates() ([]XRayRuleTemplate, error)
+	UpdateXRayRuleTemplate(template *XRayRuleTemplate) error
+	DeleteXRayRuleTemplate(name string) error
 }
 
 // SQLiteRepository implementasi repository untuk SQLite
@@ -95,22 +135,22 @@ func NewSQLiteRepository(db *sql.DB) Repository {
 
 // === AUTO PROMOTE GROUPS ===
 
-func (r *SQLiteRepository) GetAutoPromoteGroup(groupJID string) (*AutoPromoteGroup, error) {
-	query := `SELECT id, group_jid, is_active, started_at, last_promote_at, created_at, updated_at 
-			  FROM auto_promote_groups WHERE group_jid = ?`
-	
-	row := r.db.QueryRow(query, groupJID)
-	
+// autoPromoteGroupColumns kolom auto_promote_groups sesuai urutan scanAutoPromoteGroup
+const autoPromoteGroupColumns = `id, group_jid, is_active, started_at, last_promote_at, created_at, updated_at,
+			  schedule_cron, window_start, window_end, quiet_days, timezone, next_promote_at,
+			  rotation_strategy, category_sequence, rotation_cursor`
+
+// scanAutoPromoteGroup membaca satu baris auto_promote_groups
+func scanAutoPromoteGroup(row rowScanner) (*AutoPromoteGroup, error) {
 	var group AutoPromoteGroup
-	var startedAt, lastPromoteAt sql.NullTime
-	
-	err := row.Scan(&group.ID, &group.GroupJID, &group.IsActive, 
-		&startedAt, &lastPromoteAt, &group.CreatedAt, &group.UpdatedAt)
+	var startedAt, lastPromoteAt, nextPromoteAt sql.NullTime
 	
+	err := row.Scan(&group.ID, &group.GroupJID, &group.IsActive,
+		&startedAt, &lastPromoteAt, &group.CreatedAt, &group.UpdatedAt,
+		&group.ScheduleCron, &group.WindowStart, &group.WindowEnd, &group.QuietDays,
+		&group.Timezone, &nextPromoteAt, &group.RotationStrategy, &group.CategorySequence,
+		&group.RotationCursor)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil // Group tidak ditemukan
-		}
 		return nil, err
 	}
 	
@@ -120,10 +160,28 @@ func (r *SQLiteRepository) GetAutoPromoteGroup(groupJID string) (*AutoPromoteGro
 	if lastPromoteAt.Valid {
 		group.LastPromoteAt = &lastPromoteAt.Time
 	}
+	if nextPromoteAt.Valid {
+		group.NextPromoteAt = &nextPromoteAt.Time
+	}
 	
 	return &group, nil
 }
 
+func (r *SQLiteRepository) GetAutoPromoteGroup(groupJID string) (*AutoPromoteGroup, error) {
+	query := `SELECT ` + autoPromoteGroupColumns + `
+			  FROM auto_promote_groups WHERE group_jid = ?`
+	
+	group, err := scanAutoPromoteGroup(r.db.QueryRow(query, groupJID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Group tidak ditemukan
+		}
+		return nil, err
+	}
+	
+	return group, nil
+}
+
 func (r *SQLiteRepository) CreateAutoPromoteGroup(groupJID string) (*AutoPromoteGroup, error) {
 	query := `INSERT INTO auto_promote_groups (group_jid, is_active, created_at, updated_at) 
 			  VALUES (?, ?, ?, ?)`
@@ -150,22 +208,37 @@ func (r *SQLiteRepository) CreateAutoPromoteGroup(groupJID string) (*AutoPromote
 
 func (r *SQLiteRepository) UpdateAutoPromoteGroup(group *AutoPromoteGroup) error {
 	query := `UPDATE auto_promote_groups 
-			  SET is_active = ?, started_at = ?, last_promote_at = ?, updated_at = ? 
+			  SET is_active = ?, started_at = ?, last_promote_at = ?, updated_at = ?,
+			      schedule_cron = ?, window_start = ?, window_end = ?, quiet_days = ?, timezone = ?, next_promote_at = ?,
+			      rotation_strategy = ?, category_sequence = ?, rotation_cursor = ?
 			  WHERE id = ?`
 	
 	group.UpdatedAt = time.Now()
 	
 	_, err := r.db.Exec(query, group.IsActive, group.StartedAt, 
-		group.LastPromoteAt, group.UpdatedAt, group.ID)
+		group.LastPromoteAt, group.UpdatedAt, group.ScheduleCron, group.WindowStart,
+		group.WindowEnd, group.QuietDays, group.Timezone, group.NextPromoteAt,
+		group.RotationStrategy, group.CategorySequence, group.RotationCursor, group.ID)
 	
 	return err
 }
 
 func (r *SQLiteRepository) GetActiveGroups() ([]AutoPromoteGroup, error) {
-	query := `SELECT id, group_jid, is_active, started_at, last_promote_at, created_at, updated_at 
+	query := `SELECT ` + autoPromoteGroupColumns + `
 			  FROM auto_promote_groups WHERE is_active = true`
 	
-	rows, err := r.db.Query(query)
+	return r.queryAutoPromoteGroups(query)
+}
+
+func (r *SQLiteRepository) GetAllAutoPromoteGroups() ([]AutoPromoteGroup, error) {
+	query := `SELECT ` + autoPromoteGroupColumns + `
+			  FROM auto_promote_groups ORDER BY is_active DESC, id`
+	
+	return r.queryAutoPromoteGroups(query)
+}
+
+func (r *SQLiteRepository) queryAutoPromoteGroups(query string, args ...interface{}) ([]AutoPromoteGroup, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -174,39 +247,102 @@ func (r *SQLiteRepository) GetActiveGroups() ([]AutoPromoteGroup, error) {
 	var groups []AutoPromoteGroup
 	
 	for rows.Next() {
-		var group AutoPromoteGroup
-		var startedAt, lastPromoteAt sql.NullTime
-		
-		err := rows.Scan(&group.ID, &group.GroupJID, &group.IsActive,
-			&startedAt, &lastPromoteAt, &group.CreatedAt, &group.UpdatedAt)
+		group, err := scanAutoPromoteGroup(rows)
 		if err != nil {
 			return nil, err
 		}
 		
-		if startedAt.Valid {
-			group.StartedAt = &startedAt.Time
-		}
-		if lastPromoteAt.Valid {
-			group.LastPromoteAt = &lastPromoteAt.Time
-		}
-		
-		groups = append(groups, group)
+		groups = append(groups, *group)
 	}
 	
 	return groups, nil
 }
 
+// === AUTO PROMOTE GROUP TARGETS ===
+
+func (r *SQLiteRepository) GetGroupTemplateTargets(groupJID string) ([]AutoPromoteGroupTarget, error) {
+	query := `SELECT id, group_jid, category, template_id, created_at 
+			  FROM auto_promote_group_templates WHERE group_jid = ? ORDER BY category, template_id`
+	
+	return r.queryGroupTemplateTargets(query, groupJID)
+}
+
+func (r *SQLiteRepository) GetAllGroupTemplateTargets() ([]AutoPromoteGroupTarget, error) {
+	query := `SELECT id, group_jid, category, template_id, created_at 
+			  FROM auto_promote_group_templates ORDER BY group_jid, category, template_id`
+	
+	return r.queryGroupTemplateTargets(query)
+}
+
+func (r *SQLiteRepository) queryGroupTemplateTargets(query string, args ...interface{}) ([]AutoPromoteGroupTarget, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var targets []AutoPromoteGroupTarget
+	for rows.Next() {
+		var target AutoPromoteGroupTarget
+		if err := rows.Scan(&target.ID, &target.GroupJID, &target.Category, &target.TemplateID, &target.CreatedAt); err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	
+	return targets, rows.Err()
+}
+
+// SetGroupTemplateTargets mengganti seluruh target template grup (kosong = semua template)
+func (r *SQLiteRepository) SetGroupTemplateTargets(groupJID string, targets []AutoPromoteGroupTarget) error {
+	return r.withTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM auto_promote_group_templates WHERE group_jid = ?`, groupJID); err != nil {
+			return err
+		}
+		
+		now := time.Now()
+		for i := range targets {
+			targets[i].GroupJID = groupJID
+			targets[i].CreatedAt = now
+			result, err := tx.Exec(`INSERT OR IGNORE INTO auto_promote_group_templates (group_jid, category, template_id, created_at) 
+				VALUES (?, ?, ?, ?)`, groupJID, targets[i].Category, targets[i].TemplateID, now)
+			if err != nil {
+				return err
+			}
+			if id, err := result.LastInsertId(); err == nil {
+				targets[i].ID = int(id)
+			}
+		}
+		return nil
+	})
+}
+
 // === PROMOTE TEMPLATES ===
 
+// promoteTemplateColumns kolom promote_templates sesuai urutan scanPromoteTemplate
+const promoteTemplateColumns = `id, title, content, category, is_active, weight, media_path, media_type, created_at, updated_at`
+
+// scanPromoteTemplate membaca satu baris promote_templates
+func scanPromoteTemplate(row rowScanner) (*PromoteTemplate, error) {
+	var template PromoteTemplate
+	err := row.Scan(&template.ID, &template.Title, &template.Content,
+		&template.Category, &template.IsActive, &template.Weight, &template.MediaPath, &template.MediaType,
+		&template.CreatedAt, &template.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
 func (r *SQLiteRepository) GetAllTemplates() ([]PromoteTemplate, error) {
-	query := `SELECT id, title, content, category, is_active, created_at, updated_at 
+	query := `SELECT ` + promoteTemplateColumns + `
 			  FROM promote_templates ORDER BY created_at DESC`
 	
 	return r.queryTemplates(query)
 }
 
 func (r *SQLiteRepository) GetActiveTemplates() ([]PromoteTemplate, error) {
-	query := `SELECT id, title, content, category, is_active, created_at, updated_at 
+	query := `SELECT ` + promoteTemplateColumns + `
 			  FROM promote_templates WHERE is_active = true ORDER BY created_at DESC`
 	
 	return r.queryTemplates(query)
@@ -222,28 +358,21 @@ func (r *SQLiteRepository) queryTemplates(query string, args ...interface{}) ([]
 	var templates []PromoteTemplate
 	
 	for rows.Next() {
-		var template PromoteTemplate
-		err := rows.Scan(&template.ID, &template.Title, &template.Content,
-			&template.Category, &template.IsActive, &template.CreatedAt, &template.UpdatedAt)
+		template, err := scanPromoteTemplate(rows)
 		if err != nil {
 			return nil, err
 		}
-		templates = append(templates, template)
+		templates = append(templates, *template)
 	}
 	
 	return templates, nil
 }
 
 func (r *SQLiteRepository) GetTemplateByID(id int) (*PromoteTemplate, error) {
-	query := `SELECT id, title, content, category, is_active, created_at, updated_at 
+	query := `SELECT ` + promoteTemplateColumns + `
 			  FROM promote_templates WHERE id = ?`
 	
-	row := r.db.QueryRow(query, id)
-	
-	var template PromoteTemplate
-	err := row.Scan(&template.ID, &template.Title, &template.Content,
-		&template.Category, &template.IsActive, &template.CreatedAt, &template.UpdatedAt)
-	
+	template, err := scanPromoteTemplate(r.db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -251,19 +380,23 @@ func (r *SQLiteRepository) GetTemplateByID(id int) (*PromoteTemplate, error) {
 		return nil, err
 	}
 	
-	return &template, nil
+	return template, nil
 }
 
 func (r *SQLiteRepository) CreateTemplate(template *PromoteTemplate) error {
-	query := `INSERT INTO promote_templates (title, content, category, is_active, created_at, updated_at) 
-			  VALUES (?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO promote_templates (title, content, category, is_active, weight, media_path, media_type, created_at, updated_at) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	
 	now := time.Now()
 	template.CreatedAt = now
 	template.UpdatedAt = now
+	if template.Weight < 1 {
+		template.Weight = 1
+	}
 	
 	result, err := r.db.Exec(query, template.Title, template.Content, 
-		template.Category, template.IsActive, template.CreatedAt, template.UpdatedAt)
+		template.Category, template.IsActive, template.Weight, template.MediaPath, template.MediaType,
+		template.CreatedAt, template.UpdatedAt)
 	if err != nil {
 		return err
 	}
@@ -279,21 +412,30 @@ func (r *SQLiteRepository) CreateTemplate(template *PromoteTemplate) error {
 
 func (r *SQLiteRepository) UpdateTemplate(template *PromoteTemplate) error {
 	query := `UPDATE promote_templates 
-			  SET title = ?, content = ?, category = ?, is_active = ?, updated_at = ? 
+			  SET title = ?, content = ?, category = ?, is_active = ?, weight = ?, media_path = ?, media_type = ?, updated_at = ? 
 			  WHERE id = ?`
 	
 	template.UpdatedAt = time.Now()
+	if template.Weight < 1 {
+		template.Weight = 1
+	}
 	
 	_, err := r.db.Exec(query, template.Title, template.Content, 
-		template.Category, template.IsActive, template.UpdatedAt, template.ID)
+		template.Category, template.IsActive, template.Weight, template.MediaPath, template.MediaType,
+		template.UpdatedAt, template.ID)
 	
 	return err
 }
 
 func (r *SQLiteRepository) DeleteTemplate(id int) error {
-	query := `DELETE FROM promote_templates WHERE id = ?`
-	_, err := r.db.Exec(query, id)
-	return err
+	return r.withTx(func(tx *sql.Tx) error {
+		// Target grup yang menunjuk template ini ikut dihapus
+		if _, err := tx.Exec(`DELETE FROM auto_promote_group_templates WHERE template_id = ?`, id); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`DELETE FROM promote_templates WHERE id = ?`, id)
+		return err
+	})
 }
 
 // === PROMOTE LOGS ===
@@ -350,6 +492,30 @@ func (r *SQLiteRepository) GetLogsByGroup(groupJID string, limit int) ([]Promote
 	return logs, nil
 }
 
+// GetLastSentByTemplate waktu terakhir tiap template berhasil dikirim ke grup (template_id -> sent_at)
+func (r *SQLiteRepository) GetLastSentByTemplate(groupJID string) (map[int]time.Time, error) {
+	query := `SELECT template_id, sent_at FROM promote_logs 
+			  WHERE id IN (SELECT MAX(id) FROM promote_logs WHERE group_jid = ? AND success = true GROUP BY template_id)`
+	
+	rows, err := r.db.Query(query, groupJID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	lastSent := make(map[int]time.Time)
+	for rows.Next() {
+		var templateID int
+		var sentAt time.Time
+		if err := rows.Scan(&templateID, &sentAt); err != nil {
+			return nil, err
+		}
+		lastSent[templateID] = sentAt
+	}
+	
+	return lastSent, rows.Err()
+}
+
 // === STATS ===
 
 func (r *SQLiteRepository) UpdateStats(date string, totalGroups, totalMessages, successMessages, failedMessages int) error {
@@ -390,24 +556,24 @@ func (r *SQLiteRepository) GetStats(date string) (*PromoteStats, error) {
 // === LEARNING GROUPS ===
 
 func (r *SQLiteRepository) CreateLearningGroup(group *LearningGroup) error {
-	query := `INSERT INTO learning_groups (group_jid, group_name, is_active, description, created_by, created_at, updated_at) 
-			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO learning_groups (group_jid, group_name, is_active, description, xray_delivery_mode, created_by, created_at, updated_at) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
 	
 	now := time.Now()
 	_, err := r.db.Exec(query, group.GroupJID, group.GroupName, group.IsActive, group.Description, 
-		group.CreatedBy, now, now)
+		group.XRayDeliveryMode, group.CreatedBy, now, now)
 	
 	return err
 }
 
 func (r *SQLiteRepository) GetLearningGroup(groupJID string) (*LearningGroup, error) {
-	query := `SELECT id, group_jid, group_name, is_active, description, created_by, created_at, updated_at 
+	query := `SELECT id, group_jid, group_name, is_active, description, xray_delivery_mode, created_by, created_at, updated_at 
 			  FROM learning_groups WHERE group_jid = ?`
 	
 	var group LearningGroup
 	err := r.db.QueryRow(query, groupJID).Scan(
 		&group.ID, &group.GroupJID, &group.GroupName, &group.IsActive, 
-		&group.Description, &group.CreatedBy, &group.CreatedAt, &group.UpdatedAt,
+		&group.Description, &group.XRayDeliveryMode, &group.CreatedBy, &group.CreatedAt, &group.UpdatedAt,
 	)
 	
 	if err != nil {
@@ -421,7 +587,7 @@ func (r *SQLiteRepository) GetLearningGroup(groupJID string) (*LearningGroup, er
 }
 
 func (r *SQLiteRepository) GetAllLearningGroups() ([]LearningGroup, error) {
-	query := `SELECT id, group_jid, group_name, is_active, description, created_by, created_at, updated_at 
+	query := `SELECT id, group_jid, group_name, is_active, description, xray_delivery_mode, created_by, created_at, updated_at 
 			  FROM learning_groups ORDER BY created_at DESC`
 	
 	rows, err := r.db.Query(query)
@@ -434,7 +600,7 @@ func (r *SQLiteRepository) GetAllLearningGroups() ([]LearningGroup, error) {
 	for rows.Next() {
 		var group LearningGroup
 		err := rows.Scan(&group.ID, &group.GroupJID, &group.GroupName, &group.IsActive,
-			&group.Description, &group.CreatedBy, &group.CreatedAt, &group.UpdatedAt)
+			&group.Description, &group.XRayDeliveryMode, &group.CreatedBy, &group.CreatedAt, &group.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -446,11 +612,11 @@ func (r *SQLiteRepository) GetAllLearningGroups() ([]LearningGroup, error) {
 
 func (r *SQLiteRepository) UpdateLearningGroup(group *LearningGroup) error {
 	query := `UPDATE learning_groups 
-			  SET group_name = ?, is_active = ?, description = ?, updated_at = ? 
+			  SET group_name = ?, is_active = ?, description = ?, xray_delivery_mode = ?, updated_at = ? 
 			  WHERE group_jid = ?`
 	
 	_, err := r.db.Exec(query, group.GroupName, group.IsActive, group.Description, 
-		time.Now(), group.GroupJID)
+		group.XRayDeliveryMode, time.Now(), group.GroupJID)
 	
 	return err
 }
@@ -822,40 +988,33 @@ func InitializeDatabase(dbPath string) (*sql.DB, Repository, error) {
 
 // === XRAY CONVERTERS ===
 
-func (r *SQLiteRepository) CreateXRayConverter(converter *XRayConverter) error {
-	query := `INSERT INTO xray_converters (command_name, display_name, bug_host, modify_type, 
-			  server_template, host_template, sni_template, path_template, grpc_service_name, 
-			  port_override, is_active, usage_count, created_by, created_at, updated_at)
-			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?, datetime('now'), datetime('now'))`
-	
-	_, err := r.db.Exec(query, converter.CommandName, converter.DisplayName, converter.BugHost,
-		converter.ModifyType, converter.ServerTemplate, converter.HostTemplate, converter.SNITemplate,
-		converter.PathTemplate, converter.GrpcServiceName, converter.PortOverride, converter.IsActive, converter.CreatedBy)
-	
-	return err
+// xrayConverterColumns kolom yang dibaca oleh scanXRayConverter (urutan harus sama)
+const xrayConverterColumns = `id, command_name, display_name, bug_host, modify_type, server_template,
+			  host_template, sni_template, path_template, grpc_service_name, port_override, 
+			  output_formats, profile_template, rules, probe_enabled, daily_user_quota, daily_group_quota, daily_global_quota,
+			  delivery_mode, yaml_as_document, category, description, example, is_active, usage_count, created_by,
+			  updated_by, version_id, created_at, updated_at`
+
+// rowScanner interface bersama untuk *sql.Row dan *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-func (r *SQLiteRepository) GetXRayConverter(commandName string) (*XRayConverter, error) {
-	query := `SELECT id, command_name, display_name, bug_host, modify_type, server_template,
-			  host_template, sni_template, path_template, grpc_service_name, port_override, 
-			  is_active, usage_count, created_by, created_at, updated_at
-			  FROM xray_converters WHERE command_name = ?`
-	
-	row := r.db.QueryRow(query, commandName)
-	
+// scanXRayConverter membaca satu baris xray_converters
+func scanXRayConverter(row rowScanner) (*XRayConverter, error) {
 	var converter XRayConverter
 	var portOverride sql.NullInt64
+	var rules string
 	
 	err := row.Scan(&converter.ID, &converter.CommandName, &converter.DisplayName,
 		&converter.BugHost, &converter.ModifyType, &converter.ServerTemplate,
 		&converter.HostTemplate, &converter.SNITemplate, &converter.PathTemplate,
-		&converter.GrpcServiceName, &portOverride, &converter.IsActive,
-		&converter.UsageCount, &converter.CreatedBy, &converter.CreatedAt, &converter.UpdatedAt)
-	
+		&converter.GrpcServiceName, &portOverride, &converter.OutputFormats, &converter.ProfileTemplate, &rules,
+		&converter.ProbeEnabled, &converter.DailyUserQuota, &converter.DailyGroupQuota, &converter.DailyGlobalQuota,
+		&converter.DeliveryMode, &converter.YAMLAsDocument, &converter.Category, &converter.Description, &converter.Example,
+		&converter.IsActive, &converter.UsageCount, &converter.CreatedBy, &converter.UpdatedBy, &converter.VersionID,
+		&converter.CreatedAt, &converter.UpdatedAt)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
 		return nil, err
 	}
 	
@@ -864,16 +1023,31 @@ func (r *SQLiteRepository) GetXRayConverter(commandName string) (*XRayConverter,
 		converter.PortOverride = &port
 	}
 	
+	if rules != "" {
+		if err := json.Unmarshal([]byte(rules), &converter.Rules); err != nil {
+			return nil, fmt.Errorf("invalid rules for converter %s: %v", converter.CommandName, err)
+		}
+	}
+	
 	return &converter, nil
 }
 
-func (r *SQLiteRepository) GetAllXRayConverters() ([]XRayConverter, error) {
-	query := `SELECT id, command_name, display_name, bug_host, modify_type, server_template,
-			  host_template, sni_template, path_template, grpc_service_name, port_override, 
-			  is_active, usage_count, created_by, created_at, updated_at
-			  FROM xray_converters ORDER BY created_at DESC`
+// encodeXRayConverterRules serialisasi rules converter ke JSON (kosong jika tidak ada rule)
+func encodeXRayConverterRules(rules []XRayConverterRule) (string, error) {
+	if len(rules) == 0 {
+		return "", nil
+	}
 	
-	rows, err := r.db.Query(query)
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// queryXRayConverters menjalankan query dan membaca semua baris converter
+func (r *SQLiteRepository) queryXRayConverters(query string, args ...interface{}) ([]XRayConverter, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -882,87 +1056,188 @@ func (r *SQLiteRepository) GetAllXRayConverters() ([]XRayConverter, error) {
 	var converters []XRayConverter
 	
 	for rows.Next() {
-		var converter XRayConverter
-		var portOverride sql.NullInt64
-		
-		err := rows.Scan(&converter.ID, &converter.CommandName, &converter.DisplayName,
-			&converter.BugHost, &converter.ModifyType, &converter.ServerTemplate,
-			&converter.HostTemplate, &converter.SNITemplate, &converter.PathTemplate,
-			&converter.GrpcServiceName, &portOverride, &converter.IsActive,
-			&converter.UsageCount, &converter.CreatedBy, &converter.CreatedAt, &converter.UpdatedAt)
-		
+		converter, err := scanXRayConverter(rows)
 		if err != nil {
 			return nil, err
 		}
 		
-		if portOverride.Valid {
-			port := int(portOverride.Int64)
-			converter.PortOverride = &port
-		}
-		
-		converters = append(converters, converter)
+		converters = append(converters, *converter)
 	}
 	
 	return converters, nil
 }
 
-func (r *SQLiteRepository) GetActiveXRayConverters() ([]XRayConverter, error) {
-	query := `SELECT id, command_name, display_name, bug_host, modify_type, server_template,
-			  host_template, sni_template, path_template, grpc_service_name, port_override, 
-			  is_active, usage_count, created_by, created_at, updated_at
-			  FROM xray_converters WHERE is_active = 1 ORDER BY command_name ASC`
+// CreateXRayConverter menyimpan converter baru dan mencatatnya sebagai versi pertama
+func (r *SQLiteRepository) CreateXRayConverter(converter *XRayConverter) error {
+	return r.withTx(func(tx *sql.Tx) error {
+		return createXRayConverterTx(tx, converter, XRayVersionActionCreate, 0)
+	})
+}
+
+// createXRayConverterTx insert converter lalu mencatat versinya di dalam transaksi
+func createXRayConverterTx(tx *sql.Tx, converter *XRayConverter, action string, rollbackOf int) error {
+	query := `INSERT INTO xray_converters (command_name, display_name, bug_host, modify_type, 
+			  server_template, host_template, sni_template, path_template, grpc_service_name, 
+			  port_override, output_formats, profile_template, rules, probe_enabled, daily_user_quota, daily_group_quota,
+			  daily_global_quota, delivery_mode, yaml_as_document, category, description, example, is_active, usage_count,
+			  created_by, updated_by, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?, ?, datetime('now'), datetime('now'))`
 	
-	rows, err := r.db.Query(query)
+	rules, err := encodeXRayConverterRules(converter.Rules)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 	
-	var converters []XRayConverter
+	changedBy := converterChangedBy(converter)
+	_, err = tx.Exec(query, converter.CommandName, converter.DisplayName, converter.BugHost,
+		converter.ModifyType, converter.ServerTemplate, converter.HostTemplate, converter.SNITemplate,
+		converter.PathTemplate, converter.GrpcServiceName, converter.PortOverride, converter.OutputFormats,
+		converter.ProfileTemplate, rules, converter.ProbeEnabled, converter.DailyUserQuota, converter.DailyGroupQuota,
+		converter.DailyGlobalQuota, converter.DeliveryMode, converter.YAMLAsDocument, converter.Category,
+		converter.Description, converter.Example, converter.IsActive, converter.CreatedBy, changedBy)
+	if err != nil {
+		return err
+	}
 	
-	for rows.Next() {
-		var converter XRayConverter
-		var portOverride sql.NullInt64
-		
-		err := rows.Scan(&converter.ID, &converter.CommandName, &converter.DisplayName,
-			&converter.BugHost, &converter.ModifyType, &converter.ServerTemplate,
-			&converter.HostTemplate, &converter.SNITemplate, &converter.PathTemplate,
-			&converter.GrpcServiceName, &portOverride, &converter.IsActive,
-			&converter.UsageCount, &converter.CreatedBy, &converter.CreatedAt, &converter.UpdatedAt)
-		
-		if err != nil {
-			return nil, err
-		}
-		
-		if portOverride.Valid {
-			port := int(portOverride.Int64)
-			converter.PortOverride = &port
+	after, err := getXRayConverterTx(tx, converter.CommandName)
+	if err != nil {
+		return err
+	}
+	
+	version, err := recordXRayConverterVersion(tx, action, nil, after, changedBy, rollbackOf)
+	if err != nil {
+		return err
+	}
+	
+	converter.ID = after.ID
+	converter.VersionID = version.ID
+	return nil
+}
+
+func (r *SQLiteRepository) GetXRayConverter(commandName string) (*XRayConverter, error) {
+	query := `SELECT ` + xrayConverterColumns + `
+			  FROM xray_converters WHERE command_name = ?`
+	
+	converter, err := scanXRayConverter(r.db.QueryRow(query, commandName))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
 		}
-		
-		converters = append(converters, converter)
+		return nil, err
 	}
 	
-	return converters, nil
+	return converter, nil
+}
+
+func (r *SQLiteRepository) GetAllXRayConverters() ([]XRayConverter, error) {
+	query := `SELECT ` + xrayConverterColumns + `
+			  FROM xray_converters ORDER BY created_at DESC`
+	
+	return r.queryXRayConverters(query)
+}
+
+func (r *SQLiteRepository) GetActiveXRayConverters() ([]XRayConverter, error) {
+	query := `SELECT ` + xrayConverterColumns + `
+			  FROM xray_converters WHERE is_active = 1 ORDER BY command_name ASC`
+	
+	return r.queryXRayConverters(query)
 }
 
+// UpdateXRayConverter menyimpan perubahan converter dan mencatatnya sebagai versi baru (beserta diff)
 func (r *SQLiteRepository) UpdateXRayConverter(converter *XRayConverter) error {
+	return r.withTx(func(tx *sql.Tx) error {
+		return updateXRayConverterTx(tx, converter, XRayVersionActionUpdate, 0)
+	})
+}
+
+// updateXRayConverterTx update converter lalu mencatat versinya di dalam transaksi.
+// Update tanpa perubahan field tidak membuat versi baru.
+func updateXRayConverterTx(tx *sql.Tx, converter *XRayConverter, action string, rollbackOf int) error {
+	before, err := getXRayConverterTx(tx, converter.CommandName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("converter %s not found", converter.CommandName)
+		}
+		return err
+	}
+	
+	// Converter lama (sebelum ada riwayat versi) dicatat dulu sebagai versi awal agar bisa di-rollback
+	if before.VersionID == 0 {
+		baseline, err := recordXRayConverterVersion(tx, XRayVersionActionCreate, nil, before, before.CreatedBy, 0)
+		if err != nil {
+			return err
+		}
+		before.VersionID = baseline.ID
+	}
+	
 	query := `UPDATE xray_converters SET display_name = ?, bug_host = ?, modify_type = ?,
 			  server_template = ?, host_template = ?, sni_template = ?, path_template = ?, 
-			  grpc_service_name = ?, port_override = ?, is_active = ?, updated_at = datetime('now') 
-			  WHERE command_name = ?`
+			  grpc_service_name = ?, port_override = ?, output_formats = ?, profile_template = ?, rules = ?, 
+			  probe_enabled = ?, daily_user_quota = ?, daily_group_quota = ?, daily_global_quota = ?,
+			  delivery_mode = ?, yaml_as_document = ?, category = ?, description = ?, example = ?, is_active = ?,
+			  updated_by = ?, updated_at = datetime('now') WHERE command_name = ?`
+	
+	rules, err := encodeXRayConverterRules(converter.Rules)
+	if err != nil {
+		return err
+	}
 	
-	_, err := r.db.Exec(query, converter.DisplayName, converter.BugHost, converter.ModifyType,
+	changedBy := converterChangedBy(converter)
+	_, err = tx.Exec(query, converter.DisplayName, converter.BugHost, converter.ModifyType,
 		converter.ServerTemplate, converter.HostTemplate, converter.SNITemplate,
 		converter.PathTemplate, converter.GrpcServiceName, converter.PortOverride,
-		converter.IsActive, converter.CommandName)
+		converter.OutputFormats, converter.ProfileTemplate, rules, converter.ProbeEnabled, converter.DailyUserQuota,
+		converter.DailyGroupQuota, converter.DailyGlobalQuota, converter.DeliveryMode, converter.YAMLAsDocument,
+		converter.Category, converter.Description, converter.Example, converter.IsActive, changedBy, converter.CommandName)
+	if err != nil {
+		return err
+	}
 	
-	return err
+	after, err := getXRayConverterTx(tx, converter.CommandName)
+	if err != nil {
+		return err
+	}
+	
+	if action == XRayVersionActionUpdate {
+		diff, err := DiffXRayConverters(before, after)
+		if err != nil {
+			return err
+		}
+		if len(diff) == 0 {
+			converter.VersionID = before.VersionID
+			return nil
+		}
+	}
+	
+	version, err := recordXRayConverterVersion(tx, action, before, after, changedBy, rollbackOf)
+	if err != nil {
+		return err
+	}
+	
+	converter.ID = after.ID
+	converter.VersionID = version.ID
+	return nil
 }
 
-func (r *SQLiteRepository) DeleteXRayConverter(commandName string) error {
-	query := `DELETE FROM xray_converters WHERE command_name = ?`
-	_, err := r.db.Exec(query, commandName)
-	return err
+// DeleteXRayConverter menghapus converter; snapshot terakhir tetap disimpan sebagai versi "delete"
+// sehingga converter bisa dipulihkan lewat rollback
+func (r *SQLiteRepository) DeleteXRayConverter(commandName, deletedBy string) error {
+	return r.withTx(func(tx *sql.Tx) error {
+		before, err := getXRayConverterTx(tx, commandName)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+		
+		if _, err := recordXRayConverterVersion(tx, XRayVersionActionDelete, before, nil, deletedBy, 0); err != nil {
+			return err
+		}
+		
+		_, err = tx.Exec(`DELETE FROM xray_converters WHERE command_name = ?`, commandName)
+		return err
+	})
 }
 
 func (r *SQLiteRepository) IncrementConverterUsage(commandName string) error {
@@ -971,40 +1246,337 @@ func (r *SQLiteRepository) IncrementConverterUsage(commandName string) error {
 	return err
 }
 
-// === XRAY CONVERSION LOGS ===
+// === XRAY CONVERTER VERSIONS ===
 
-func (r *SQLiteRepository) LogXRayConversion(log *XRayConversionLog) error {
-	query := `INSERT INTO xray_conversion_logs (converter_name, user_jid, group_jid, original_protocol,
-			  original_network, original_server, modified_server, success, error_message, used_at)
-			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))`
-	
-	_, err := r.db.Exec(query, log.ConverterName, log.UserJID, log.GroupJID,
-		log.OriginalProtocol, log.OriginalNetwork, log.OriginalServer,
-		log.ModifiedServer, log.Success, log.ErrorMessage)
-	
-	return err
+// Action yang dicatat di xray_converter_versions
+const (
+	XRayVersionActionCreate   = "create"
+	XRayVersionActionUpdate   = "update"
+	XRayVersionActionRollback = "rollback"
+	XRayVersionActionDelete   = "delete"
+)
+
+// xrayConverterVersionColumns kolom yang dibaca oleh scanXRayConverterVersion (urutan harus sama)
+const xrayConverterVersionColumns = `id, converter_name, version, action, snapshot, diff, changed_by, rollback_of, created_at`
+
+// xrayConverterUntrackedFields field tracking (bukan konfigurasi) yang tidak masuk diff versi
+var xrayConverterUntrackedFields = map[string]bool{
+	"id":          true,
+	"usage_count": true,
+	"created_by":  true,
+	"updated_by":  true,
+	"version_id":  true,
+	"created_at":  true,
+	"updated_at":  true,
 }
 
-func (r *SQLiteRepository) GetXRayConversionLogs(limit int) ([]XRayConversionLog, error) {
-	query := `SELECT id, converter_name, user_jid, group_jid, original_protocol, original_network,
-			  original_server, modified_server, success, error_message, used_at
-			  FROM xray_conversion_logs ORDER BY used_at DESC LIMIT ?`
-	
-	rows, err := r.db.Query(query, limit)
+// withTx menjalankan fn di dalam transaksi, commit jika fn berhasil dan rollback jika gagal
+func (r *SQLiteRepository) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 	
-	var logs []XRayConversionLog
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
 	
-	for rows.Next() {
-		var log XRayConversionLog
-		var errorMessage sql.NullString
-		
-		err := rows.Scan(&log.ID, &log.ConverterName, &log.UserJID, &log.GroupJID,
-			&log.OriginalProtocol, &log.OriginalNetwork, &log.OriginalServer,
-			&log.ModifiedServer, &log.Success, &errorMessage, &log.UsedAt)
+	return tx.Commit()
+}
+
+// getXRayConverterTx membaca converter di dalam transaksi (sql.ErrNoRows jika tidak ada)
+func getXRayConverterTx(tx *sql.Tx, commandName string) (*XRayConverter, error) {
+	query := `SELECT ` + xrayConverterColumns + `
+			  FROM xray_converters WHERE command_name = ?`
+	
+	return scanXRayConverter(tx.QueryRow(query, commandName))
+}
+
+// converterChangedBy admin yang tercatat di versi: UpdatedBy, atau CreatedBy jika kosong
+func converterChangedBy(converter *XRayConverter) string {
+	if converter.UpdatedBy != "" {
+		return converter.UpdatedBy
+	}
+	return converter.CreatedBy
+}
+
+// recordXRayConverterVersion menyimpan versi baru converter dan mengarahkan version_id converter ke versi tersebut.
+// after nil berarti converter dihapus (snapshot memakai before).
+func recordXRayConverterVersion(tx *sql.Tx, action string, before, after *XRayConverter, changedBy string, rollbackOf int) (*XRayConverterVersion, error) {
+	snapshot := after
+	if snapshot == nil {
+		snapshot = before
+	}
+	
+	version := &XRayConverterVersion{
+		ConverterName: snapshot.CommandName,
+		Action:        action,
+		Snapshot:      *snapshot,
+		ChangedBy:     changedBy,
+		RollbackOf:    rollbackOf,
+	}
+	
+	if after != nil {
+		diff, err := DiffXRayConverters(before, after)
+		if err != nil {
+			return nil, err
+		}
+		version.Diff = diff
+	}
+	
+	snapshotData, err := json.Marshal(version.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	diffData := ""
+	if len(version.Diff) > 0 {
+		data, err := json.Marshal(version.Diff)
+		if err != nil {
+			return nil, err
+		}
+		diffData = string(data)
+	}
+	
+	err = tx.QueryRow(`SELECT COALESCE(MAX(version), 0) + 1 FROM xray_converter_versions WHERE converter_name = ?`,
+		version.ConverterName).Scan(&version.Version)
+	if err != nil {
+		return nil, err
+	}
+	
+	result, err := tx.Exec(`INSERT INTO xray_converter_versions (converter_name, version, action, snapshot, diff,
+			  changed_by, rollback_of, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'))`,
+		version.ConverterName, version.Version, version.Action, string(snapshotData), diffData,
+		version.ChangedBy, version.RollbackOf)
+	if err != nil {
+		return nil, err
+	}
+	
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	version.ID = int(id)
+	
+	if after != nil {
+		_, err = tx.Exec(`UPDATE xray_converters SET version_id = ? WHERE command_name = ?`, version.ID, version.ConverterName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	
+	return version, nil
+}
+
+// DiffXRayConverters membandingkan field konfigurasi dua converter (before nil = converter baru)
+func DiffXRayConverters(before, after *XRayConverter) ([]XRayConverterFieldChange, error) {
+	beforeFields, err := converterFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := converterFields(after)
+	if err != nil {
+		return nil, err
+	}
+	
+	names := make([]string, 0, len(afterFields))
+	for name := range afterFields {
+		names = append(names, name)
+	}
+	for name := range beforeFields {
+		if _, ok := afterFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	
+	var changes []XRayConverterFieldChange
+	for _, name := range names {
+		if xrayConverterUntrackedFields[name] {
+			continue
+		}
+		
+		oldValue, newValue := beforeFields[name], afterFields[name]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		// Converter baru: field kosong tidak perlu dicatat
+		if before == nil && isEmptyConverterField(newValue) {
+			continue
+		}
+		
+		changes = append(changes, XRayConverterFieldChange{Field: name, Old: oldValue, New: newValue})
+	}
+	
+	return changes, nil
+}
+
+// converterFields mengubah converter menjadi map field (key = tag json) untuk dibandingkan
+func converterFields(converter *XRayConverter) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if converter == nil {
+		return fields, nil
+	}
+	
+	data, err := json.Marshal(converter)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// isEmptyConverterField cek nilai kosong hasil decode JSON (nil, "", false, 0, [])
+func isEmptyConverterField(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// scanXRayConverterVersion membaca satu baris xray_converter_versions
+func scanXRayConverterVersion(row rowScanner) (*XRayConverterVersion, error) {
+	var version XRayConverterVersion
+	var snapshot, diff string
+	
+	err := row.Scan(&version.ID, &version.ConverterName, &version.Version, &version.Action,
+		&snapshot, &diff, &version.ChangedBy, &version.RollbackOf, &version.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	
+	if err := json.Unmarshal([]byte(snapshot), &version.Snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot for converter version %d: %v", version.ID, err)
+	}
+	if diff != "" {
+		if err := json.Unmarshal([]byte(diff), &version.Diff); err != nil {
+			return nil, fmt.Errorf("invalid diff for converter version %d: %v", version.ID, err)
+		}
+	}
+	
+	return &version, nil
+}
+
+// GetXRayConverterVersions riwayat versi converter, terbaru lebih dulu
+func (r *SQLiteRepository) GetXRayConverterVersions(commandName string) ([]XRayConverterVersion, error) {
+	query := `SELECT ` + xrayConverterVersionColumns + `
+			  FROM xray_converter_versions WHERE converter_name = ? ORDER BY version DESC`
+	
+	rows, err := r.db.Query(query, commandName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var versions []XRayConverterVersion
+	
+	for rows.Next() {
+		version, err := scanXRayConverterVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		
+		versions = append(versions, *version)
+	}
+	
+	return versions, nil
+}
+
+func (r *SQLiteRepository) GetXRayConverterVersion(id int) (*XRayConverterVersion, error) {
+	query := `SELECT ` + xrayConverterVersionColumns + `
+			  FROM xray_converter_versions WHERE id = ?`
+	
+	version, err := scanXRayConverterVersion(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	
+	return version, nil
+}
+
+// RollbackXRayConverter mengembalikan converter ke snapshot versi tertentu dan mencatatnya sebagai versi
+// "rollback". Converter yang sudah dihapus dibuat ulang dari snapshot.
+func (r *SQLiteRepository) RollbackXRayConverter(versionID int, changedBy string) (*XRayConverter, error) {
+	version, err := r.GetXRayConverterVersion(versionID)
+	if err != nil {
+		return nil, err
+	}
+	if version == nil {
+		return nil, fmt.Errorf("converter version %d not found", versionID)
+	}
+	
+	converter := version.Snapshot
+	converter.UpdatedBy = changedBy
+	
+	err = r.withTx(func(tx *sql.Tx) error {
+		_, err := getXRayConverterTx(tx, converter.CommandName)
+		if err == sql.ErrNoRows {
+			return createXRayConverterTx(tx, &converter, XRayVersionActionRollback, version.ID)
+		}
+		if err != nil {
+			return err
+		}
+		return updateXRayConverterTx(tx, &converter, XRayVersionActionRollback, version.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	return &converter, nil
+}
+
+// === XRAY CONVERSION LOGS ===
+
+func (r *SQLiteRepository) LogXRayConversion(log *XRayConversionLog) error {
+	query := `INSERT INTO xray_conversion_logs (converter_name, user_jid, group_jid, original_protocol,
+			  original_network, original_server, modified_server, success, error_message, 
+			  probe_status, probe_latency_ms, probe_error, credential_fingerprint, converter_version_id, used_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))`
+	
+	_, err := r.db.Exec(query, log.ConverterName, log.UserJID, log.GroupJID,
+		log.OriginalProtocol, log.OriginalNetwork, log.OriginalServer,
+		log.ModifiedServer, log.Success, log.ErrorMessage,
+		log.ProbeStatus, log.ProbeLatencyMs, log.ProbeError, log.CredentialFingerprint, log.ConverterVersionID)
+	
+	return err
+}
+
+func (r *SQLiteRepository) GetXRayConversionLogs(limit int) ([]XRayConversionLog, error) {
+	query := `SELECT id, converter_name, user_jid, group_jid, original_protocol, original_network,
+			  original_server, modified_server, success, error_message, probe_status, probe_latency_ms,
+			  probe_error, credential_fingerprint, converter_version_id, used_at
+			  FROM xray_conversion_logs ORDER BY used_at DESC LIMIT ?`
+	
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var logs []XRayConversionLog
+	
+	for rows.Next() {
+		var log XRayConversionLog
+		var errorMessage sql.NullString
+		
+		err := rows.Scan(&log.ID, &log.ConverterName, &log.UserJID, &log.GroupJID,
+			&log.OriginalProtocol, &log.OriginalNetwork, &log.OriginalServer,
+			&log.ModifiedServer, &log.Success, &errorMessage, &log.ProbeStatus, &log.ProbeLatencyMs,
+			&log.ProbeError, &log.CredentialFingerprint, &log.ConverterVersionID, &log.UsedAt)
 		
 		if err != nil {
 			return nil, err
@@ -1020,6 +1592,91 @@ func (r *SQLiteRepository) GetXRayConversionLogs(limit int) ([]XRayConversionLog
 	return logs, nil
 }
 
+// CountXRayConversions menghitung conversion sukses sesuai filter dan mengembalikan waktu
+// conversion paling lama di dalam window (nil jika belum ada)
+func (r *SQLiteRepository) CountXRayConversions(filter XRayConversionFilter) (int, *time.Time, error) {
+	where, args := xrayConversionFilterClause(filter)
+	query := `SELECT COUNT(*), MIN(used_at) FROM xray_conversion_logs WHERE ` + where
+	
+	var count int
+	var oldest sql.NullString
+	if err := r.db.QueryRow(query, args...).Scan(&count, &oldest); err != nil {
+		return 0, nil, err
+	}
+	
+	if !oldest.Valid {
+		return count, nil, nil
+	}
+	oldestAt, err := parseSQLiteTime(oldest.String)
+	if err != nil {
+		return 0, nil, err
+	}
+	return count, &oldestAt, nil
+}
+
+// GetXRayConversionTimeAt mengambil waktu conversion sukses ke-(offset+1) dari yang paling lama
+// di dalam window filter (nil jika tidak ada)
+func (r *SQLiteRepository) GetXRayConversionTimeAt(filter XRayConversionFilter, offset int) (*time.Time, error) {
+	where, args := xrayConversionFilterClause(filter)
+	query := `SELECT used_at FROM xray_conversion_logs WHERE ` + where + ` ORDER BY used_at ASC LIMIT 1 OFFSET ?`
+	args = append(args, offset)
+	
+	var usedAt time.Time
+	err := r.db.QueryRow(query, args...).Scan(&usedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &usedAt, nil
+}
+
+// xrayConversionFilterClause menyusun kondisi WHERE conversion sukses sesuai filter
+func xrayConversionFilterClause(filter XRayConversionFilter) (string, []interface{}) {
+	where := `success = 1 AND used_at >= ?`
+	args := []interface{}{filter.Since.UTC().Format(sqliteTimeLayout)}
+	
+	if filter.ConverterName != "" {
+		where += ` AND converter_name = ?`
+		args = append(args, filter.ConverterName)
+	}
+	if filter.UserNumber != "" {
+		// user_jid bisa berformat nomor@s.whatsapp.net atau nomor:device@s.whatsapp.net
+		where += ` AND (user_jid LIKE ? OR user_jid LIKE ?)`
+		args = append(args, filter.UserNumber+"@%", filter.UserNumber+":%")
+	}
+	if filter.GroupJID != "" {
+		where += ` AND group_jid = ?`
+		args = append(args, filter.GroupJID)
+	}
+	return where, args
+}
+
+// PruneXRayConversionLogs menghapus log konversi sebelum waktu tertentu, mengembalikan jumlah baris terhapus
+func (r *SQLiteRepository) PruneXRayConversionLogs(before time.Time) (int64, error) {
+	query := `DELETE FROM xray_conversion_logs WHERE used_at < ?`
+	
+	result, err := r.db.Exec(query, before.UTC().Format(sqliteTimeLayout))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// sqliteTimeLayout format datetime('now') SQLite (UTC)
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+// parseSQLiteTime membaca nilai DATETIME hasil agregasi (MIN/MAX) yang dikembalikan sebagai string
+func parseSQLiteTime(value string) (time.Time, error) {
+	for _, layout := range []string{sqliteTimeLayout, time.RFC3339Nano, "2006-01-02T15:04:05Z"} {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid datetime: %s", value)
+}
+
 func (r *SQLiteRepository) GetXRayConversionStats(days int) (map[string]int, error) {
 	query := `SELECT converter_name, COUNT(*) as count
 			  FROM xray_conversion_logs 
@@ -1048,4 +1705,254 @@ func (r *SQLiteRepository) GetXRayConversionStats(days int) (map[string]int, err
 	}
 	
 	return stats, nil
-}
\ No newline at end of file
+}
+
+// === XRAY SUBSCRIPTIONS ===
+
+func (r *SQLiteRepository) CreateXRaySubscription(sub *XRaySubscription) error {
+	query := `INSERT INTO xray_subscriptions (user_jid, token, created_at, updated_at) VALUES (?, ?, ?, ?)`
+	
+	now := time.Now()
+	result, err := r.db.Exec(query, sub.UserJID, sub.Token, now, now)
+	if err != nil {
+		return err
+	}
+	
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	
+	sub.ID = int(id)
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+	
+	return nil
+}
+
+func (r *SQLiteRepository) getXRaySubscription(where string, arg interface{}) (*XRaySubscription, error) {
+	query := `SELECT id, user_jid, token, created_at, updated_at FROM xray_subscriptions WHERE ` + where
+	
+	var sub XRaySubscription
+	err := r.db.QueryRow(query, arg).Scan(&sub.ID, &sub.UserJID, &sub.Token, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	
+	return &sub, nil
+}
+
+func (r *SQLiteRepository) GetXRaySubscriptionByUser(userJID string) (*XRaySubscription, error) {
+	return r.getXRaySubscription("user_jid = ?", userJID)
+}
+
+func (r *SQLiteRepository) GetXRaySubscriptionByToken(token string) (*XRaySubscription, error) {
+	return r.getXRaySubscription("token = ?", token)
+}
+
+func (r *SQLiteRepository) UpdateXRaySubscriptionToken(id int, token string) error {
+	query := `UPDATE xray_subscriptions SET token = ?, updated_at = ? WHERE id = ?`
+	_, err := r.db.Exec(query, token, time.Now(), id)
+	return err
+}
+
+func (r *SQLiteRepository) AddXRaySubscriptionItem(item *XRaySubscriptionItem) error {
+	query := `INSERT OR IGNORE INTO xray_subscription_items (subscription_id, converter_name, original_link, created_at)
+			  VALUES (?, ?, ?, datetime('now'))`
+	
+	_, err := r.db.Exec(query, item.SubscriptionID, item.ConverterName, item.OriginalLink)
+	if err != nil {
+		return err
+	}
+	
+	_, err = r.db.Exec(`UPDATE xray_subscriptions SET updated_at = ? WHERE id = ?`, time.Now(), item.SubscriptionID)
+	return err
+}
+
+func (r *SQLiteRepository) GetXRaySubscriptionItems(subscriptionID int) ([]XRaySubscriptionItem, error) {
+	query := `SELECT id, subscription_id, converter_name, original_link, created_at
+			  FROM xray_subscription_items WHERE subscription_id = ? ORDER BY id ASC`
+	
+	rows, err := r.db.Query(query, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var items []XRaySubscriptionItem
+	
+	for rows.Next() {
+		var item XRaySubscriptionItem
+		
+		err := rows.Scan(&item.ID, &item.SubscriptionID, &item.ConverterName, &item.OriginalLink, &item.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		
+		items = append(items, item)
+	}
+	
+	return items, nil
+}
+
+func (r *SQLiteRepository) DeleteXRaySubscriptionItems(subscriptionID int) error {
+	query := `DELETE FROM xray_subscription_items WHERE subscription_id = ?`
+	_, err := r.db.Exec(query, subscriptionID)
+	return err
+}
+
+// === XRAY RULE TEMPLATES ===
+
+// xrayRuleTemplateColumns kolom yang dibaca oleh scanXRayRuleTemplate (urutan harus sama)
+const xrayRuleTemplateColumns = `id, name, description, dns_mode, rule_providers, rules, is_active, created_at, updated_at`
+
+// scanXRayRuleTemplate membaca satu baris xray_rule_templates
+func scanXRayRuleTemplate(row rowScanner) (*XRayRuleTemplate, error) {
+	var template XRayRuleTemplate
+	
+	err := row.Scan(&template.ID, &template.Name, &template.Description, &template.DNSMode,
+		&template.RuleProviders, &template.Rules, &template.IsActive, &template.CreatedAt, &template.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	
+	return &template, nil
+}
+
+func (r *SQLiteRepository) CreateXRayRuleTemplate(template *XRayRuleTemplate) error {
+	query := `INSERT INTO xray_rule_templates (name, description, dns_mode, rule_providers, rules, is_active, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`
+	
+	result, err := r.db.Exec(query, template.Name, template.Description, template.DNSMode,
+		template.RuleProviders, template.Rules, template.IsActive)
+	if err != nil {
+		return err
+	}
+	
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	
+	template.ID = int(id)
+	return nil
+}
+
+func (r *SQLiteRepository) GetXRayRuleTemplate(name string) (*XRayRuleTemplate, error) {
+	query := `SELECT ` + xrayRuleTemplateColumns + ` FROM xray_rule_templates WHERE name = ?`
+	
+	template, err := scanXRayRuleTemplate(r.db.QueryRow(query, name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	
+	return template, nil
+}
+
+func (r *SQLiteRepository) GetAllXRayRuleTemplates() ([]XRayRuleTemplate, error) {
+	query := `SELECT ` + xrayRuleTemplateColumns + ` FROM xray_rule_templates ORDER BY name ASC`
+	
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var templates []XRayRuleTemplate
+	
+	for rows.Next() {
+		template, err := scanXRayRuleTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		
+		templates = append(templates, *template)
+	}
+	
+	return templates, nil
+}
+
+func (r *SQLiteRepository) UpdateXRayRuleTemplate(template *XRayRuleTemplate) error {
+	query := `UPDATE xray_rule_templates SET description = ?, dns_mode = ?, rule_providers = ?, rules = ?,
+			  is_active = ?, updated_at = datetime('now') WHERE name = ?`
+	
+	_, err := r.db.Exec(query, template.Description, template.DNSMode, template.RuleProviders,
+		template.Rules, template.IsActive, template.Name)
+	
+	return err
+}
+
+func (r *SQLiteRepository) DeleteXRayRuleTemplate(name string) error {
+	query := `DELETE FROM xray_rule_templates WHERE name = ?`
+	_, err := r.db.Exec(query, name)
+	return err
+}
+
+// === XRAY QUOTA OVERRIDES ===
+
+func (r *SQLiteRepository) SetXRayQuotaOverride(override *XRayQuotaOverride) error {
+	query := `INSERT INTO xray_quota_overrides (user_number, converter_name, daily_limit, created_by, created_at)
+			  VALUES (?, ?, ?, ?, datetime('now'))
+			  ON CONFLICT(user_number, converter_name) DO UPDATE SET
+			  daily_limit = excluded.daily_limit, created_by = excluded.created_by, created_at = excluded.created_at`
+	
+	_, err := r.db.Exec(query, override.UserNumber, override.ConverterName, override.DailyLimit, override.CreatedBy)
+	return err
+}
+
+// GetXRayQuotaOverride mengambil override untuk converter tertentu, fallback ke override semua converter
+func (r *SQLiteRepository) GetXRayQuotaOverride(userNumber, converterName string) (*XRayQuotaOverride, error) {
+	query := `SELECT id, user_number, converter_name, daily_limit, created_by, created_at
+			  FROM xray_quota_overrides WHERE user_number = ? AND converter_name IN (?, '')
+			  ORDER BY converter_name DESC LIMIT 1`
+	
+	var override XRayQuotaOverride
+	err := r.db.QueryRow(query, userNumber, converterName).Scan(&override.ID, &override.UserNumber,
+		&override.ConverterName, &override.DailyLimit, &override.CreatedBy, &override.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	
+	return &override, nil
+}
+
+func (r *SQLiteRepository) GetAllXRayQuotaOverrides() ([]XRayQuotaOverride, error) {
+	query := `SELECT id, user_number, converter_name, daily_limit, created_by, created_at
+			  FROM xray_quota_overrides ORDER BY user_number ASC, converter_name ASC`
+	
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var overrides []XRayQuotaOverride
+	
+	for rows.Next() {
+		var override XRayQuotaOverride
+		err := rows.Scan(&override.ID, &override.UserNumber, &override.ConverterName,
+			&override.DailyLimit, &override.CreatedBy, &override.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		
+		overrides = append(overrides, override)
+	}
+	
+	return overrides, nil
+}
+
+func (r *SQLiteRepository) DeleteXRayQuotaOverride(userNumber, converterName string) error {
+	query := `DELETE FROM xray_quota_overrides WHERE user_number = ? AND converter_name = ?`
+	_, err := r.db.Exec(query, userNumber, converterName)
+	return err
+}
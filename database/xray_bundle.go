@@ -0,0 +1,51 @@
+// Package database - serialisasi converter ke/dari bundle JSON (export/import dan converter default)
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Identitas bundle converter
+const (
+	XRayBundleFormat  = "promote-xray-converters"
+	XRayBundleVersion = 1
+)
+
+// BundleConverterEntry mengambil field konfigurasi converter untuk bundle (tanpa id, usage, versi, dan waktu)
+func BundleConverterEntry(converter *XRayConverter) (map[string]interface{}, error) {
+	fields, err := converterFields(converter)
+	if err != nil {
+		return nil, err
+	}
+
+	for name := range xrayConverterUntrackedFields {
+		delete(fields, name)
+	}
+	return fields, nil
+}
+
+// DecodeBundleConverter membaca satu entry bundle menjadi converter (field tracking diabaikan)
+func DecodeBundleConverter(entry map[string]interface{}) (*XRayConverter, error) {
+	config := make(map[string]interface{}, len(entry))
+	for name, value := range entry {
+		if !xrayConverterUntrackedFields[name] {
+			config[name] = value
+		}
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var converter XRayConverter
+	if err := json.Unmarshal(data, &converter); err != nil {
+		return nil, fmt.Errorf("converter %v: %v", entry["command_name"], err)
+	}
+	if converter.CommandName == "" {
+		return nil, fmt.Errorf("converter is missing command_name")
+	}
+
+	return &converter, nil
+}
@@ -11,6 +11,11 @@ type XRayConverter struct {
 	CommandName     string    `json:"command_name" db:"command_name"`         // "convertbizz"
 	DisplayName     string    `json:"display_name" db:"display_name"`         // "XL-Line-WC"
 	
+	// Info menu .converters
+	Category        string    `json:"category" db:"category"`                 // operator: "XL", "Telkomsel", "Indosat"
+	Description     string    `json:"description" db:"description"`           // penjelasan singkat bug/paket
+	Example         string    `json:"example" db:"example"`                   // contoh pemakaian
+	
 	// Basic settings
 	BugHost         string    `json:"bug_host" db:"bug_host"`                 // "ava.game.naver.com"
 	ModifyType      string    `json:"modify_type" db:"modify_type"`           // "wildcard", "sni", "ws", "grpc", "custom"
@@ -25,14 +30,82 @@ type XRayConverter struct {
 	GrpcServiceName string    `json:"grpc_service_name" db:"grpc_service_name"` // for grpc
 	PortOverride    *int      `json:"port_override" db:"port_override"`       // optional port override
 	
+	// Output settings
+	OutputFormats   string    `json:"output_formats" db:"output_formats"`     // format tambahan default, misal "singbox,surge"
+	ProfileTemplate string    `json:"profile_template" db:"profile_template"` // nama XRayRuleTemplate, kosong = hanya "proxies:"
+	
+	// Probe koneksi setelah konversi (TCP/TLS/ws upgrade)
+	ProbeEnabled    bool      `json:"probe_enabled" db:"probe_enabled"`
+	
+	// Kuota konversi per 24 jam (0 = tanpa batas)
+	DailyUserQuota   int      `json:"daily_user_quota" db:"daily_user_quota"`     // per user
+	DailyGroupQuota  int      `json:"daily_group_quota" db:"daily_group_quota"`   // per grup
+	DailyGlobalQuota int      `json:"daily_global_quota" db:"daily_global_quota"` // semua user
+	
+	// Pengiriman hasil: group/dm/dm_ack (kosong = ikut setting grup)
+	DeliveryMode    string    `json:"delivery_mode" db:"delivery_mode"`
+	YAMLAsDocument  bool      `json:"yaml_as_document" db:"yaml_as_document"` // kirim YAML sebagai file .yaml
+	
+	// Rule tambahan (urut) yang dijalankan setelah template/modify type, disimpan sebagai JSON
+	Rules           []XRayConverterRule `json:"rules" db:"rules"`
+	
 	// Status and tracking
 	IsActive        bool      `json:"is_active" db:"is_active"`               // status aktif/tidak
 	UsageCount      int       `json:"usage_count" db:"usage_count"`           // jumlah penggunaan
 	CreatedBy       string    `json:"created_by" db:"created_by"`             // admin yang membuat
+	UpdatedBy       string    `json:"updated_by" db:"updated_by"`             // admin yang terakhir mengubah
+	VersionID       int       `json:"version_id" db:"version_id"`             // id XRayConverterVersion yang sedang aktif
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`             // waktu dibuat
 	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`             // waktu diupdate
 }
 
+// XRayConverterVersion satu versi converter: snapshot lengkap setelah perubahan beserta diff-nya
+type XRayConverterVersion struct {
+	ID            int                        `json:"id" db:"id"`
+	ConverterName string                     `json:"converter_name" db:"converter_name"`
+	Version       int                        `json:"version" db:"version"`         // nomor urut per converter (mulai dari 1)
+	Action        string                     `json:"action" db:"action"`           // create/update/rollback/delete
+	Snapshot      XRayConverter              `json:"snapshot" db:"snapshot"`       // isi converter setelah perubahan (disimpan sebagai JSON)
+	Diff          []XRayConverterFieldChange `json:"diff" db:"diff"`               // field yang berubah dibanding versi sebelumnya
+	ChangedBy     string                     `json:"changed_by" db:"changed_by"`   // admin yang melakukan perubahan
+	RollbackOf    int                        `json:"rollback_of" db:"rollback_of"` // id versi yang dipulihkan (khusus action rollback)
+	CreatedAt     time.Time                  `json:"created_at" db:"created_at"`
+}
+
+// XRayConverterFieldChange perubahan satu field converter (nama field mengikuti tag json)
+type XRayConverterFieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// XRayConverterBundle bundle JSON berisi converter untuk export/import antar instance bot
+type XRayConverterBundle struct {
+	Format     string                   `json:"format"`                // selalu "promote-xray-converters"
+	Version    int                      `json:"version"`               // versi skema bundle
+	ExportedAt string                   `json:"exported_at,omitempty"` // RFC3339
+	ExportedBy string                   `json:"exported_by,omitempty"`
+	Converters []map[string]interface{} `json:"converters"`            // field konfigurasi converter (key = tag json XRayConverter)
+	Signature  string                   `json:"signature,omitempty"`   // HMAC-SHA256 (hex) atas isi bundle tanpa signature
+}
+
+// XRayConverterRule satu rule deklaratif untuk mengubah field config hasil deteksi
+type XRayConverterRule struct {
+	Field  string            `json:"field"`           // server/host/sni/path/port/service_name/remarks
+	Action string            `json:"action"`          // set/replace/regex
+	Match  string            `json:"match,omitempty"` // substring (replace) atau pattern (regex)
+	Value  string            `json:"value"`           // nilai baru / pengganti, mendukung placeholder template dan $1 untuk regex
+	When   XRayRuleCondition `json:"when"`            // kondisi rule dijalankan (kosong = selalu)
+}
+
+// XRayRuleCondition kondisi rule berdasarkan config asli (semua kondisi harus terpenuhi)
+type XRayRuleCondition struct {
+	Protocols []string `json:"protocols,omitempty"` // vmess/vless/trojan/shadowsocks
+	Networks  []string `json:"networks,omitempty"`  // ws/grpc/tcp/h2/httpupgrade
+	TLS       *bool    `json:"tls,omitempty"`       // true = hanya TLS/REALITY, false = hanya non-TLS
+	Ports     []int    `json:"ports,omitempty"`     // port asli
+}
+
 // XRayConversionLog menyimpan log penggunaan converter
 type XRayConversionLog struct {
 	ID               int       `json:"id" db:"id"`
@@ -45,6 +118,11 @@ type XRayConversionLog struct {
 	ModifiedServer   string    `json:"modified_server" db:"modified_server"`     // server setelah modifikasi
 	Success          bool      `json:"success" db:"success"`                     // status berhasil/gagal
 	ErrorMessage     *string   `json:"error_message" db:"error_message"`         // pesan error jika gagal
+	ProbeStatus      string    `json:"probe_status" db:"probe_status"`           // kosong (tidak di-probe), ok, failed
+	ProbeLatencyMs   int64     `json:"probe_latency_ms" db:"probe_latency_ms"`   // latency probe dalam ms
+	ProbeError       string    `json:"probe_error" db:"probe_error"`             // alasan probe gagal (termasuk tahapnya)
+	CredentialFingerprint string `json:"credential_fingerprint" db:"credential_fingerprint"` // HMAC UUID/password, kosong jika nonaktif
+	ConverterVersionID int `json:"converter_version_id" db:"converter_version_id"` // versi converter saat konversi dijalankan
 	UsedAt           time.Time `json:"used_at" db:"used_at"`                     // waktu penggunaan
 }
 
@@ -55,15 +133,33 @@ type DetectedXRayConfig struct {
 	Port          int               `json:"port"`           // port number
 	UUID          string            `json:"uuid"`           // user id / password
 	Network       string            `json:"network"`        // ws/grpc/tcp/h2/httpupgrade/kcp
-	TLS           bool              `json:"tls"`            // tls enabled
+	TLS           bool              `json:"tls"`            // tls enabled (true juga untuk reality)
+	Security      string            `json:"security"`       // none/tls/reality
 	SNI           string            `json:"sni"`            // server name indication
 	Host          string            `json:"host"`           // host header
 	Path          string            `json:"path"`           // path for ws/httpupgrade/h2
 	ServiceName   string            `json:"service_name"`   // grpc service name
-	HeaderType    string            `json:"header_type"`    // kcp header type
+	HeaderType    string            `json:"header_type"`    // header type tcp/kcp/quic: none/http/srtp/utp/wechat-video/dtls/wireguard
 	AlterID       int               `json:"alter_id"`       // vmess alter id
 	Cipher        string            `json:"cipher"`         // encryption method
 	Remarks       string            `json:"remarks"`        // connection name/remarks
+	
+	// Transport KCP / QUIC / XHTTP
+	Seed          string            `json:"seed"`           // kcp seed (obfuscation password)
+	QUICSecurity  string            `json:"quic_security"`  // quic: none/aes-128-gcm/chacha20-poly1305
+	QUICKey       string            `json:"quic_key"`       // quic key
+	Mode          string            `json:"mode"`           // xhttp mode: auto/packet-up/stream-up/stream-one
+	
+	// Shadowsocks plugin (SIP003), misal "v2ray-plugin" dengan opts "mode=websocket;host=x;tls"
+	Plugin        string            `json:"plugin"`         // nama plugin: v2ray-plugin/obfs-local/simple-obfs
+	PluginOpts    string            `json:"plugin_opts"`    // opsi plugin mentah (dipisah ';')
+	
+	// XTLS & REALITY settings
+	Flow          string            `json:"flow"`           // xtls flow, misal "xtls-rprx-vision"
+	Fingerprint   string            `json:"fingerprint"`    // utls client fingerprint (fp)
+	PublicKey     string            `json:"public_key"`     // reality public key (pbk)
+	ShortID       string            `json:"short_id"`       // reality short id (sid)
+	SpiderX       string            `json:"spider_x"`       // reality spider path (spx)
 	RawConfig     map[string]interface{} `json:"raw_config"` // original config for reconstruction
 }
 
@@ -77,4 +173,119 @@ type ModifiedXRayConfig struct {
 	ModifiedServer string              `json:"modified_server"`
 	ModifiedHost   string              `json:"modified_host"`
 	ModifiedSNI    string              `json:"modified_sni"`
-}
\ No newline at end of file
+	ProxyName      string              `json:"proxy_name"`      // nama proxy untuk output client
+	
+	// ModifiedConfig adalah DetectedConfig dengan nilai hasil modifikasi (server/host/sni/path/port)
+	ModifiedConfig *DetectedXRayConfig   `json:"modified_config"`
+	Outputs        []XRayFormattedOutput `json:"outputs,omitempty"` // output tambahan (sing-box, surge, dll)
+	
+	// ProfileConfig profile Mihomo/OpenClash lengkap jika converter memakai profile template
+	ProfileConfig  string                `json:"profile_config,omitempty"`
+	
+	// YAMLError alasan YAMLConfig kosong (transport tidak didukung Clash/Mihomo, link tetap valid)
+	YAMLError      string                `json:"yaml_error,omitempty"`
+	RuleTemplate   *XRayRuleTemplate     `json:"-"` // template yang dipakai untuk ProfileConfig
+	
+	// Probe hasil koneksi ke server hasil modifikasi (nil jika tidak di-probe)
+	Probe          *XRayProbeResult      `json:"probe,omitempty"`
+}
+
+// XRayProbeResult hasil probe koneksi satu config
+type XRayProbeResult struct {
+	Target    string `json:"target"`          // server:port yang di-probe
+	Success   bool   `json:"success"`
+	Stage     string `json:"stage"`           // tahap terakhir: tcp, tls, upgrade
+	LatencyMs int64  `json:"latency_ms"`      // waktu sampai tahap terakhir (ms)
+	Error     string `json:"error,omitempty"` // alasan gagal
+}
+
+// XRayFormattedOutput hasil render satu format output
+type XRayFormattedOutput struct {
+	Format  string `json:"format"`          // nama format: singbox, xray, v2rayn, surge, quanx, clash, link
+	Label   string `json:"label"`           // label untuk ditampilkan ke user
+	Content string `json:"content"`         // isi konfigurasi
+	Error   string `json:"error,omitempty"` // alasan jika format tidak didukung untuk config ini
+}
+
+// XRayBatchItem hasil konversi satu link di dalam batch
+type XRayBatchItem struct {
+	Index        int                 `json:"index"`         // urutan link (mulai dari 1)
+	OriginalLink string              `json:"original_link"` // link asli
+	Result       *ModifiedXRayConfig `json:"result"`        // hasil jika berhasil
+	Error        string              `json:"error"`         // pesan error jika gagal
+}
+
+// XRayBatchResult hasil konversi banyak link / subscription sekaligus
+type XRayBatchResult struct {
+	Items        []XRayBatchItem `json:"items"`
+	SuccessCount int             `json:"success_count"`
+	FailedCount  int             `json:"failed_count"`
+	ClashProxies string          `json:"clash_proxies"` // gabungan "proxies:" untuk Clash/OpenClash
+	ClashProfile string          `json:"clash_profile"` // profile Mihomo lengkap jika converter memakai template
+	Subscription string          `json:"subscription"`  // base64 gabungan semua link hasil modifikasi
+}
+
+// XRayProfileImport hasil parsing profile Clash YAML / sing-box JSON yang di-import user
+type XRayProfileImport struct {
+	Format  string                `json:"format"`  // format sumber: clash/singbox
+	Links   []string              `json:"links"`   // share link per proxy yang berhasil dibaca
+	Configs []*DetectedXRayConfig `json:"configs"` // hasil DetectXRayConfig untuk setiap link
+	Skipped []string              `json:"skipped"` // proxy yang dilewati beserta alasannya
+}
+
+// XRayImportResult hasil konversi profile yang di-import
+type XRayImportResult struct {
+	Format  string           `json:"format"`  // format sumber: clash/singbox
+	Batch   *XRayBatchResult `json:"batch"`   // hasil konversi per proxy + subscription base64
+	Profile string           `json:"profile"` // profile hasil modifikasi dalam format yang sama dengan sumber
+	Skipped []string         `json:"skipped"` // proxy yang tidak bisa di-import
+}
+
+// XRayRuleTemplate template DNS, rule-providers, dan rules untuk profile Mihomo lengkap
+type XRayRuleTemplate struct {
+	ID            int       `json:"id" db:"id"`
+	Name          string    `json:"name" db:"name"`                     // "default", "adblock"
+	Description   string    `json:"description" db:"description"`
+	DNSMode       string    `json:"dns_mode" db:"dns_mode"`             // "fake-ip" atau "redir-host"
+	RuleProviders string    `json:"rule_providers" db:"rule_providers"` // isi YAML di bawah "rule-providers:"
+	Rules         string    `json:"rules" db:"rules"`                   // satu rule per baris, misal "RULE-SET,reject,REJECT"
+	IsActive      bool      `json:"is_active" db:"is_active"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// XRaySubscription menyimpan subscription URL per user (token-protected)
+type XRaySubscription struct {
+	ID        int       `json:"id" db:"id"`
+	UserJID   string    `json:"user_jid" db:"user_jid"`     // pemilik subscription
+	Token     string    `json:"token" db:"token"`           // token rahasia untuk URL
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// XRaySubscriptionItem link asli yang disimpan di subscription, dikonversi ulang setiap kali diambil
+type XRaySubscriptionItem struct {
+	ID             int       `json:"id" db:"id"`
+	SubscriptionID int       `json:"subscription_id" db:"subscription_id"`
+	ConverterName  string    `json:"converter_name" db:"converter_name"` // converter yang dipakai
+	OriginalLink   string    `json:"original_link" db:"original_link"`   // link asli dari user
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// XRayQuotaOverride batas kuota khusus user yang ditetapkan admin
+type XRayQuotaOverride struct {
+	ID            int       `json:"id" db:"id"`
+	UserNumber    string    `json:"user_number" db:"user_number"`       // nomor WhatsApp tanpa @s.whatsapp.net
+	ConverterName string    `json:"converter_name" db:"converter_name"` // kosong = semua converter
+	DailyLimit    int       `json:"daily_limit" db:"daily_limit"`       // 0 = tanpa batas (juga lewati kuota grup/global)
+	CreatedBy     string    `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// XRayConversionFilter filter untuk menghitung conversion sukses di xray_conversion_logs
+type XRayConversionFilter struct {
+	ConverterName string    // kosong = semua converter
+	UserNumber    string    // kosong = semua user
+	GroupJID      string    // kosong = semua grup
+	Since         time.Time // hanya conversion setelah waktu ini
+}
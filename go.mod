@@ -6,6 +6,7 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.32
 	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	go.mau.fi/whatsmeow v0.0.0-20250829123043-72d2ed58e998
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
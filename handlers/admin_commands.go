@@ -8,6 +8,7 @@ import (
 
 	"go.mau.fi/whatsmeow/types/events"
 
+	"github.com/nabilulilalbab/promote/database"
 	"github.com/nabilulilalbab/promote/services"
 	"github.com/nabilulilalbab/promote/utils"
 )
@@ -115,7 +116,11 @@ func (h *AdminCommandHandler) HandleAddTemplateCommand(evt *events.Message, args
 💡 *TIPS PENTING*
 • Gunakan tanda kutip untuk teks spasi
 • Kategori: produk, diskon, testimoni, flashsale
-• Konten bisa pakai emoji dan formatting WhatsApp`
+• Konten bisa pakai emoji dan formatting WhatsApp
+• Variabel: {{GROUP_NAME}}, {{MEMBER_COUNT}}, {DATE}, {DAY}
+• Kondisi: {{#if MEMBER_COUNT > 100}}...{{else}}...{{/if}}
+• Loop produk: {{#each PRODUCTS 5}}{{NAME}} {{PRICE}}{{/each}}
+• Spintax acak: {Halo|Hai|Hey}`
 	}
 
 	// Parse arguments (simplified parsing)
@@ -1852,10 +1857,23 @@ func (h *AdminCommandHandler) HandleGroupStatusCommand(evt *events.Message, args
 		lastPromoteInfo = "Belum pernah"
 	}
 
-	// Ambil jumlah template aktif
+	// Ambil jumlah template aktif (sesuai target template grup)
 	templates, _ := h.templateService.GetActiveTemplates()
+	targetInfo := "Semua template"
+	if h.autoPromoteService != nil {
+		if targets, err := h.autoPromoteService.GetGroupTemplateTargets(groupInfo.JID); err == nil {
+			templates = services.FilterTemplatesForGroup(targets, templates)
+			targetInfo = services.DescribeTemplateTargets(targets)
+		}
+	}
 	templateCount := len(templates)
 
+	scheduleInfo, nextPromoteInfo := h.describeGroupSchedule(dbGroup)
+	rotationInfo := "Acak"
+	if dbGroup != nil {
+		rotationInfo = services.DescribeRotation(dbGroup)
+	}
+
 	return fmt.Sprintf(`📊 *STATUS GRUP AUTO PROMOTE*
 
 ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
@@ -1873,6 +1891,10 @@ func (h *AdminCommandHandler) HandleGroupStatusCommand(evt *events.Message, args
 🎯 *Status Auto Promote:* %s
 📅 *Promote Dimulai:* %s
 ⏰ *Promosi Terakhir:* %s
+🗓️ *Jadwal:* %s
+⏭️ *Promosi Berikutnya:* %s
+🔄 *Rotasi Template:* %s
+🎯 *Target Template:* %s
 📝 *Total Template Aktif:* %d template
 
 ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
@@ -1895,11 +1917,308 @@ func (h *AdminCommandHandler) HandleGroupStatusCommand(evt *events.Message, args
 • *.testgroup %d*
 	 _Kirim promosi test_
 
+• *.setschedule %d*
+	 _Atur jadwal promosi_
+
+• *.setrotation %d*
+	 _Atur rotasi template_
+
+• *.settemplates %d*
+	 _Atur target template_
+
 • *.listgroups*
 	 _Kembali ke daftar grup_`,
 		groupInfo.Name, groupInfo.ID, groupInfo.MemberCount, status,
-		startedInfo, lastPromoteInfo, templateCount, groupInfo.JID,
-		groupID, groupID, groupID)
+		startedInfo, lastPromoteInfo, scheduleInfo, nextPromoteInfo, rotationInfo, targetInfo, templateCount, groupInfo.JID,
+		groupID, groupID, groupID, groupID, groupID, groupID)
+}
+
+// describeGroupSchedule ringkasan jadwal grup dan waktu promosi berikutnya untuk .groupstatus
+func (h *AdminCommandHandler) describeGroupSchedule(dbGroup *database.AutoPromoteGroup) (string, string) {
+	if h.autoPromoteService == nil || dbGroup == nil {
+		return "Default", "-"
+	}
+
+	schedule, next, err := h.autoPromoteService.GroupSchedule(dbGroup)
+	if err != nil {
+		return fmt.Sprintf("⚠️ Tidak valid (%v)", err), "-"
+	}
+
+	nextInfo := "-"
+	if !dbGroup.IsActive {
+		nextInfo = "Auto promote tidak aktif"
+	} else if next.IsZero() {
+		nextInfo = "Tidak ada jadwal dalam 1 tahun"
+	} else {
+		nextInfo = next.In(schedule.Location()).Format("2006-01-02 15:04 MST")
+	}
+	return schedule.Describe(), nextInfo
+}
+
+// HandleSetScheduleCommand menangani command .setschedule [ID] [opsi...]
+func (h *AdminCommandHandler) HandleSetScheduleCommand(evt *events.Message, args []string) string {
+	// Cek admin permission
+	if !h.isAdmin(evt.Info.Sender.User) {
+		return "" // Tidak ada response untuk non-admin
+	}
+
+	if len(args) < 3 {
+		return `❌ *FORMAT SALAH*
+
+📝 **Format:** .setschedule [ID] [opsi...]
+
+⚙️ **Opsi:**
+• cron="0 9,15,20 * * *" _(menit jam tanggal bulan hari)_
+• window=08:00-21:00 _(jam boleh kirim)_
+• quiet=sat,sun _(hari libur promosi)_
+• tz=Asia/Jakarta _(atau WIB/WITA/WIT)_
+• Nilai *off* menghapus opsi, *reset* menghapus semua
+
+📋 **Contoh:**
+• .setschedule 3 window=08:00-21:00 quiet=sun
+• .setschedule 3 cron="0 9,13,19 * * mon-fri" tz=WIB
+• .setschedule 3 reset
+
+💡 Tanpa cron, grup memakai interval default dari config`
+	}
+
+	if h.groupManagerService == nil || h.autoPromoteService == nil {
+		return "❌ *SERVICE TIDAK TERSEDIA*\n\n🚫 Service auto promote tidak dikonfigurasi"
+	}
+
+	groupID, err := strconv.Atoi(args[1])
+	if err != nil {
+		return "❌ *ID TIDAK VALID*\n\n🚫 ID grup harus berupa angka.\n📝 Gunakan .listgroups untuk melihat ID"
+	}
+
+	groupInfo, err := h.groupManagerService.GetGroupByID(groupID)
+	if err != nil {
+		return fmt.Sprintf("❌ *GRUP TIDAK DITEMUKAN*\n\n🚫 %v", err)
+	}
+
+	// Parse opsi key=value (nilai dengan spasi pakai tanda kutip)
+	options := make(map[string]string)
+	for _, part := range h.parseQuotedArgs(strings.Join(args[2:], " ")) {
+		if strings.EqualFold(part, "reset") {
+			for _, key := range []string{"cron", "window", "quiet", "tz"} {
+				options[key] = "off"
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return fmt.Sprintf("❌ *OPSI TIDAK VALID*\n\n🚫 Opsi harus berbentuk key=value: %s", part)
+		}
+		options[strings.ToLower(key)] = value
+	}
+
+	dbGroup, schedule, err := h.autoPromoteService.SetGroupSchedule(groupInfo.JID, options)
+	if err != nil {
+		h.logger.Errorf("Failed to set schedule for group %d: %v", groupID, err)
+		return fmt.Sprintf("❌ *GAGAL MENGATUR JADWAL*\n\n🚫 %v", err)
+	}
+
+	_, nextInfo := h.describeGroupSchedule(dbGroup)
+	return fmt.Sprintf(`✅ *JADWAL GRUP DIPERBARUI*
+
+👥 *Grup:* %s
+🗓️ *Jadwal:* %s
+⏭️ *Promosi Berikutnya:* %s
+
+💡 Gunakan *.groupstatus %d* untuk melihat detail`,
+		groupInfo.Name, schedule.Describe(), nextInfo, groupID)
+}
+
+// HandleSetRotationCommand menangani command .setrotation [ID] [strategi] [urutan kategori]
+func (h *AdminCommandHandler) HandleSetRotationCommand(evt *events.Message, args []string) string {
+	// Cek admin permission
+	if !h.isAdmin(evt.Info.Sender.User) {
+		return "" // Tidak ada response untuk non-admin
+	}
+
+	if len(args) < 3 {
+		return fmt.Sprintf(`❌ *FORMAT SALAH*
+
+📝 **Format:** .setrotation [ID] [strategi] [urutan]
+
+🔄 **Strategi:** %s
+• *random* - acak (default)
+• *roundrobin* - bergiliran sesuai ID template
+• *weighted* - acak berbobot (atur dengan .setweight)
+• *lru* - template yang paling lama tidak dikirim ke grup
+• *sequence* - bergiliran per kategori
+
+📋 **Contoh:**
+• .setrotation 3 roundrobin
+• .setrotation 3 sequence produk,testimoni,diskon`, strings.Join(services.RotationStrategies(), ", "))
+	}
+
+	if h.groupManagerService == nil || h.autoPromoteService == nil {
+		return "❌ *SERVICE TIDAK TERSEDIA*\n\n🚫 Service auto promote tidak dikonfigurasi"
+	}
+
+	groupID, err := strconv.Atoi(args[1])
+	if err != nil {
+		return "❌ *ID TIDAK VALID*\n\n🚫 ID grup harus berupa angka.\n📝 Gunakan .listgroups untuk melihat ID"
+	}
+
+	groupInfo, err := h.groupManagerService.GetGroupByID(groupID)
+	if err != nil {
+		return fmt.Sprintf("❌ *GRUP TIDAK DITEMUKAN*\n\n🚫 %v", err)
+	}
+
+	sequence := strings.Join(args[3:], ",")
+	dbGroup, err := h.autoPromoteService.SetGroupRotation(groupInfo.JID, args[2], sequence)
+	if err != nil {
+		h.logger.Errorf("Failed to set rotation for group %d: %v", groupID, err)
+		return fmt.Sprintf("❌ *GAGAL MENGATUR ROTASI*\n\n🚫 %v", err)
+	}
+
+	return fmt.Sprintf(`✅ *ROTASI TEMPLATE DIPERBARUI*
+
+👥 *Grup:* %s
+🔄 *Rotasi:* %s
+
+💡 Gunakan *.groupstatus %d* untuk melihat detail`,
+		groupInfo.Name, services.DescribeRotation(dbGroup), groupID)
+}
+
+// HandleSetTemplatesCommand menangani command .settemplates [ID] [kategori,#ID template,...]
+func (h *AdminCommandHandler) HandleSetTemplatesCommand(evt *events.Message, args []string) string {
+	// Cek admin permission
+	if !h.isAdmin(evt.Info.Sender.User) {
+		return "" // Tidak ada response untuk non-admin
+	}
+
+	if len(args) < 3 {
+		return `❌ *FORMAT SALAH*
+
+📝 **Format:** .settemplates [ID] [target]
+
+🎯 **Target:**
+• Kategori template, misal *vpn,ssh*
+• ID template dengan #, misal *#12*
+• *all* untuk kembali ke semua template
+
+📋 **Contoh:**
+• .settemplates 3 vpn,ssh
+• .settemplates 3 kuota,#12,#15
+• .settemplates 3 all`
+	}
+
+	if h.groupManagerService == nil || h.autoPromoteService == nil {
+		return "❌ *SERVICE TIDAK TERSEDIA*\n\n🚫 Service auto promote tidak dikonfigurasi"
+	}
+
+	groupID, err := strconv.Atoi(args[1])
+	if err != nil {
+		return "❌ *ID TIDAK VALID*\n\n🚫 ID grup harus berupa angka.\n📝 Gunakan .listgroups untuk melihat ID"
+	}
+
+	groupInfo, err := h.groupManagerService.GetGroupByID(groupID)
+	if err != nil {
+		return fmt.Sprintf("❌ *GRUP TIDAK DITEMUKAN*\n\n🚫 %v", err)
+	}
+
+	categories, templateIDs, err := services.ParseTemplateTargets(strings.Join(args[2:], ","))
+	if err != nil {
+		return fmt.Sprintf("❌ *TARGET TIDAK VALID*\n\n🚫 %v", err)
+	}
+
+	targets, err := h.autoPromoteService.SetGroupTemplates(groupInfo.JID, categories, templateIDs)
+	if err != nil {
+		h.logger.Errorf("Failed to set template targets for group %d: %v", groupID, err)
+		return fmt.Sprintf("❌ *GAGAL MENGATUR TARGET*\n\n🚫 %v", err)
+	}
+
+	templates, _ := h.autoPromoteService.GetTemplatesForGroup(groupInfo.JID)
+	warning := ""
+	if len(templates) == 0 {
+		warning = "\n\n⚠️ Belum ada template aktif yang cocok, promosi grup ini akan ditunda sampai ada template."
+	}
+
+	return fmt.Sprintf(`✅ *TARGET TEMPLATE DIPERBARUI*
+
+👥 *Grup:* %s
+🎯 *Target:* %s
+📝 *Template Cocok:* %d template%s
+
+💡 Gunakan *.groupstatus %d* untuk melihat detail`,
+		groupInfo.Name, services.DescribeTemplateTargets(targets), len(templates), warning, groupID)
+}
+
+// HandleSetWeightCommand menangani command .setweight [ID template] [bobot]
+func (h *AdminCommandHandler) HandleSetWeightCommand(evt *events.Message, args []string) string {
+	// Cek admin permission
+	if !h.isAdmin(evt.Info.Sender.User) {
+		return "" // Tidak ada response untuk non-admin
+	}
+
+	if len(args) < 3 {
+		return `❌ *FORMAT SALAH*
+
+📝 **Format:** .setweight [ID] [bobot]
+📋 **Contoh:** .setweight 5 3
+
+💡 Bobot 1-100, dipakai grup dengan rotasi *weighted*.
+Template berbobot 3 terkirim ±3x lebih sering dari bobot 1.`
+	}
+
+	templateID, errID := strconv.Atoi(args[1])
+	weight, errWeight := strconv.Atoi(args[2])
+	if errID != nil || errWeight != nil {
+		return "❌ *FORMAT SALAH*\n\n🚫 ID template dan bobot harus berupa angka.\n📋 Contoh: .setweight 5 3"
+	}
+
+	template, err := h.templateService.SetTemplateWeight(templateID, weight)
+	if err != nil {
+		return fmt.Sprintf("❌ *GAGAL MENGUBAH BOBOT*\n\n🚫 %v", err)
+	}
+
+	return fmt.Sprintf(`✅ *BOBOT TEMPLATE DIPERBARUI*
+
+🆔 *ID:* %d
+📝 *Judul:* %s
+⚖️ *Bobot:* %d`, template.ID, template.Title, template.Weight)
+}
+
+// HandleSetMediaCommand menangani command .setmedia [ID template] [path file|off]
+func (h *AdminCommandHandler) HandleSetMediaCommand(evt *events.Message, args []string) string {
+	// Cek admin permission
+	if !h.isAdmin(evt.Info.Sender.User) {
+		return "" // Tidak ada response untuk non-admin
+	}
+
+	if len(args) < 3 {
+		return `❌ *FORMAT SALAH*
+
+📝 **Format:** .setmedia [ID] [path file|off]
+📋 **Contoh:** .setmedia 5 media/promote/banner.jpg
+
+💡 Upload file lewat dashboard (tab Auto Promote) lalu pakai path hasil upload.
+Konten template menjadi caption. Gunakan *off* untuk kembali ke teks saja.`
+	}
+
+	templateID, err := strconv.Atoi(args[1])
+	if err != nil {
+		return "❌ *FORMAT SALAH*\n\n🚫 ID template harus berupa angka.\n📋 Contoh: .setmedia 5 media/promote/banner.jpg"
+	}
+
+	mediaPath := strings.Join(args[2:], " ")
+	if strings.EqualFold(mediaPath, "off") || strings.EqualFold(mediaPath, "hapus") {
+		mediaPath = ""
+	}
+
+	template, err := h.templateService.SetTemplateMedia(templateID, mediaPath)
+	if err != nil {
+		return fmt.Sprintf("❌ *GAGAL MENGUBAH MEDIA*\n\n🚫 %v", err)
+	}
+
+	return fmt.Sprintf(`✅ *MEDIA TEMPLATE DIPERBARUI*
+
+🆔 *ID:* %d
+📝 *Judul:* %s
+📎 *Media:* %s`, template.ID, template.Title, services.DescribeTemplateMedia(template))
 }
 
 // HandleTestGroupCommand menangani command .testgroup [ID]
@@ -2111,6 +2430,15 @@ func (h *AdminCommandHandler) HandleAdminCommands(evt *events.Message, messageTe
 	case ".testgroup":
 		return h.HandleTestGroupCommand(evt, args)
 
+	case ".setschedule":
+		return h.HandleSetScheduleCommand(evt, args)
+
+	case ".setrotation":
+		return h.HandleSetRotationCommand(evt, args)
+
+	case ".settemplates":
+		return h.HandleSetTemplatesCommand(evt, args)
+
 	// Template Management Commands
 	case ".addtemplate":
 		return h.HandleAddTemplateCommand(evt, args)
@@ -2142,6 +2470,12 @@ func (h *AdminCommandHandler) HandleAdminCommands(evt *events.Message, messageTe
 	case ".deletemulti":
 		return h.HandleDeleteMultipleTemplatesCommand(evt, args)
 
+	case ".setweight":
+		return h.HandleSetWeightCommand(evt, args)
+
+	case ".setmedia":
+		return h.HandleSetMediaCommand(evt, args)
+
 	default:
 		return ""
 	}
@@ -0,0 +1,147 @@
+// Package handlers - export/import XRay converter sebagai bundle JSON lewat chat admin
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/nabilulilalbab/promote/services"
+)
+
+// isConverterBundleCommand cek apakah caption/command adalah .importconverters
+func isConverterBundleCommand(command string) bool {
+	return strings.EqualFold(command, ".importconverters")
+}
+
+// handleExportConvertersCommand menangani .exportconverters [converter...] dan mengirim bundle sebagai file .json
+func (h *LearningMessageHandler) handleExportConvertersCommand(evt *events.Message, userJID, command string) {
+	names := strings.Fields(command)[1:]
+
+	bundle, err := h.xrayConverterService.ExportConverters(names, services.NormalizeUserNumber(userJID))
+	if err != nil {
+		h.logger.Errorf("Failed to export converters: %v", err)
+		h.sendAdminMessage(evt.Info.Chat, fmt.Sprintf("❌ Gagal export converter: %v", err))
+		return
+	}
+
+	data, err := services.EncodeConverterBundle(bundle)
+	if err != nil {
+		h.logger.Errorf("Failed to encode converter bundle: %v", err)
+		h.sendAdminMessage(evt.Info.Chat, fmt.Sprintf("❌ Gagal export converter: %v", err))
+		return
+	}
+
+	signed := "tanpa signature"
+	if bundle.Signature != "" {
+		signed = "bertanda tangan"
+	}
+	fileName := fmt.Sprintf("xray-converters-%s.json", time.Now().Format("20060102-150405"))
+	caption := fmt.Sprintf("📦 %d converter (%s)\n\nImport di bot lain: kirim file ini dengan caption .importconverters [skip|overwrite|rename] [--dry-run]", len(bundle.Converters), signed)
+
+	if err := h.sendDocument(evt.Info.Chat.String(), fileName, "application/json", data, caption); err != nil {
+		h.logger.Errorf("Failed to send converter bundle: %v", err)
+		h.sendAdminMessage(evt.Info.Chat, fmt.Sprintf("❌ Gagal mengirim bundle: %v", err))
+	}
+}
+
+// handleImportConvertersCommand menangani .importconverters [skip|overwrite|rename] [--dry-run] diikuti
+// isi bundle JSON (file dengan caption command atau JSON yang di-paste)
+func (h *LearningMessageHandler) handleImportConvertersCommand(evt *events.Message, userJID, command string) {
+	header, body, _ := strings.Cut(strings.TrimSpace(command), "\n")
+	args := strings.Fields(header)[1:]
+
+	// JSON boleh di-paste langsung setelah argumen di baris pertama
+	if i := strings.Index(header, "{"); i >= 0 {
+		args = strings.Fields(header[:i])[1:]
+		body = header[i:] + "\n" + body
+	}
+
+	mode := services.BundleConflictSkip
+	dryRun := false
+	for _, arg := range args {
+		switch strings.ToLower(arg) {
+		case "--dry-run", "-n", "dryrun":
+			dryRun = true
+		default:
+			mode = strings.ToLower(arg)
+		}
+	}
+	if err := services.ValidateConflictMode(mode); err != nil {
+		h.sendAdminMessage(evt.Info.Chat, "❌ Mode tidak dikenal!\n\nMode: skip (default), overwrite, rename\nContoh: .importconverters rename --dry-run")
+		return
+	}
+
+	if strings.TrimSpace(body) == "" {
+		h.sendAdminMessage(evt.Info.Chat, "❌ Bundle kosong!\n\nKirim file .json hasil .exportconverters dengan caption:\n.importconverters [skip|overwrite|rename] [--dry-run]")
+		return
+	}
+
+	bundle, err := h.xrayConverterService.ParseConverterBundle([]byte(body))
+	if err != nil {
+		h.sendAdminMessage(evt.Info.Chat, fmt.Sprintf("❌ Bundle ditolak: %v", err))
+		return
+	}
+
+	result, err := h.xrayConverterService.ImportConverters(bundle, mode, dryRun, services.NormalizeUserNumber(userJID))
+	if err != nil {
+		h.logger.Errorf("Failed to import converters: %v", err)
+		h.sendAdminMessage(evt.Info.Chat, fmt.Sprintf("❌ Gagal import converter: %v", err))
+		return
+	}
+
+	h.sendAdminMessage(evt.Info.Chat, formatBundleImportResult(result))
+}
+
+// bundleActionIcons ikon hasil import per converter
+var bundleActionIcons = map[string]string{
+	services.BundleActionCreate:    "🆕",
+	services.BundleActionOverwrite: "♻️",
+	services.BundleActionRename:    "✏️",
+	services.BundleActionSkip:      "⏭️",
+	services.BundleActionInvalid:   "❌",
+}
+
+// formatBundleImportResult menyusun ringkasan import / preview dry-run
+func formatBundleImportResult(result *services.XRayBundleImportResult) string {
+	var builder strings.Builder
+	if result.DryRun {
+		builder.WriteString("🔍 **PREVIEW IMPORT CONVERTER** (dry-run, belum disimpan)\n\n")
+	} else {
+		builder.WriteString("✅ **IMPORT CONVERTER SELESAI**\n\n")
+	}
+
+	signed := "tidak diverifikasi"
+	if result.Signed {
+		signed = "valid"
+	}
+	builder.WriteString(fmt.Sprintf("📦 **Dari:** %s (%s)\n🔏 **Signature:** %s\n⚙️ **Mode:** %s\n\n", result.ExportedBy, result.ExportedAt, signed, result.Mode))
+
+	for _, item := range result.Items {
+		line := fmt.Sprintf("%s .%s - %s", bundleActionIcons[item.Action], item.CommandName, item.Action)
+		if item.ImportedAs != "" && item.ImportedAs != item.CommandName {
+			line += " → ." + item.ImportedAs
+		}
+		if len(item.Diff) > 0 {
+			fields := make([]string, 0, len(item.Diff))
+			for _, change := range item.Diff {
+				fields = append(fields, change.Field)
+			}
+			line += " (" + strings.Join(fields, ", ") + ")"
+		}
+		if item.Error != "" {
+			line += ": " + item.Error
+		}
+		builder.WriteString(line + "\n")
+	}
+
+	builder.WriteString(fmt.Sprintf("\n📊 Baru: %d | Overwrite: %d | Rename: %d | Skip: %d | Invalid: %d",
+		result.Created, result.Overwritten, result.Renamed, result.Skipped, result.Invalid))
+	if result.DryRun {
+		builder.WriteString("\n\n💡 Kirim ulang tanpa --dry-run untuk menyimpan.")
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,124 @@
+// Package handlers - pengiriman hasil konversi XRay ke grup atau chat pribadi user
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/nabilulilalbab/promote/services"
+)
+
+// conversionChat menentukan chat tujuan hasil konversi. Mode dm/dm_ack di grup
+// mengarahkan hasil ke chat pribadi user agar UUID/password tidak terlihat anggota lain.
+func (h *LearningMessageHandler) conversionChat(groupJID, userJID string, delivery services.XRayDelivery) string {
+	if !delivery.Private() || !strings.HasSuffix(groupJID, "@g.us") {
+		return groupJID
+	}
+
+	userChat, err := types.ParseJID(userJID)
+	if err != nil {
+		h.logger.Errorf("Failed to parse user JID for private delivery: %v", err)
+		return userJID
+	}
+	return userChat.ToNonAD().String()
+}
+
+// notifyDelivery memberi kabar di grup setelah hasil dikirim ke chat pribadi:
+// pesan gagal jika DM tidak terkirim, atau konfirmasi singkat untuk mode dm_ack
+func (h *LearningMessageHandler) notifyDelivery(groupJID, chat, commandName string, delivery services.XRayDelivery, err error) {
+	if chat == groupJID {
+		return
+	}
+
+	if err != nil {
+		h.sendErrorMessage(groupJID, fmt.Sprintf("❌ Hasil .%s gagal dikirim ke chat pribadi.\n\n💡 Kirim pesan apa saja ke bot terlebih dahulu, lalu coba lagi.", commandName))
+		return
+	}
+
+	if delivery.Mode == services.DeliveryModeDMAck {
+		h.sendTextMessage(groupJID, fmt.Sprintf("✅ Hasil .%s sudah dikirim ke chat pribadi kamu 📩", commandName))
+	}
+}
+
+// sendYAML mengirim config YAML sebagai teks (code block) atau sebagai file .yaml
+func (h *LearningMessageHandler) sendYAML(chat, title, fileName, content string, asDocument bool) error {
+	if asDocument {
+		return h.sendDocument(chat, fileName, "text/yaml", []byte(content), title)
+	}
+
+	chatJID, err := types.ParseJID(chat)
+	if err != nil {
+		return err
+	}
+
+	text := title + "\n```yaml\n" + content + "```"
+	msg := &waProto.Message{
+		Conversation: &text,
+	}
+	_, err = h.client.SendMessage(context.Background(), chatJID, msg)
+	return err
+}
+
+// sendDocument upload dan kirim file dokumen ke chat
+func (h *LearningMessageHandler) sendDocument(chat, fileName, mimeType string, data []byte, caption string) error {
+	chatJID, err := types.ParseJID(chat)
+	if err != nil {
+		return err
+	}
+
+	uploaded, err := h.client.Upload(context.Background(), data, whatsmeow.MediaDocument)
+	if err != nil {
+		return fmt.Errorf("failed to upload document: %v", err)
+	}
+
+	msg := &waProto.Message{
+		DocumentMessage: &waProto.DocumentMessage{
+			Caption:       &caption,
+			FileName:      &fileName,
+			Mimetype:      &mimeType,
+			URL:           &uploaded.URL,
+			DirectPath:    &uploaded.DirectPath,
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    &uploaded.FileLength,
+		},
+	}
+
+	if _, err := h.client.SendMessage(context.Background(), chatJID, msg); err != nil {
+		return fmt.Errorf("failed to send document: %v", err)
+	}
+	return nil
+}
+
+// handleSetDeliveryCommand menangani .setdelivery [JID grup] [group|dm|dm_ack]
+func (h *LearningMessageHandler) handleSetDeliveryCommand(evt *events.Message, command string) {
+	parts := strings.Fields(command)
+	if len(parts) < 3 {
+		h.sendAdminMessage(evt.Info.Chat, "❌ Format salah!\n\nContoh: .setdelivery 120363420243864186@g.us dm_ack\n\nMode: group, dm, dm_ack")
+		return
+	}
+
+	groupJID := parts[1]
+	mode := strings.ToLower(parts[2])
+	if mode == services.DeliveryModeGroup {
+		mode = "" // default
+	}
+
+	if err := h.xrayConverterService.SetGroupDeliveryMode(groupJID, mode); err != nil {
+		h.logger.Errorf("Failed to set delivery mode: %v", err)
+		h.sendAdminMessage(evt.Info.Chat, fmt.Sprintf("❌ Gagal mengatur mode pengiriman: %v", err))
+		return
+	}
+
+	if mode == "" {
+		mode = services.DeliveryModeGroup
+	}
+	h.sendAdminMessage(evt.Info.Chat, fmt.Sprintf("✅ Mode pengiriman hasil konversi diperbarui!\n\n🆔 **Grup:** %s\n📩 **Mode:** %s\n\nSetting per converter (dashboard) tetap diutamakan.", groupJID, mode))
+}
@@ -0,0 +1,71 @@
+// Package handlers - dry-run converter lewat chat admin (.testconvert)
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/nabilulilalbab/promote/services"
+)
+
+// handleTestConvertCommand menangani .testconvert [converter] [link]: kirim diff field dan peringatan,
+// lalu preview pesan persis seperti yang diterima user. Tidak mencatat log, kuota, maupun usage.
+func (h *LearningMessageHandler) handleTestConvertCommand(evt *events.Message, command string) {
+	fields := strings.Fields(command)
+	if len(fields) < 3 {
+		h.sendAdminMessage(evt.Info.Chat, "❌ Format salah!\n\nContoh: .testconvert convertbizz vmess://xxx")
+		return
+	}
+
+	converterName := strings.TrimPrefix(fields[1], ".")
+	dryRun, err := h.xrayConverterService.DryRunConversion(converterName, fields[2], evt.Info.Chat.String())
+	if err != nil {
+		h.sendAdminMessage(evt.Info.Chat, fmt.Sprintf("❌ *Dry-run gagal*\n\n🔧 *Converter:* %s\n📝 *Error:* %v", converterName, err))
+		return
+	}
+
+	h.sendAdminMessage(evt.Info.Chat, formatDryRunReport(dryRun))
+	if err := h.sendChatMessages(evt.Info.Chat, dryRun.Messages); err != nil {
+		h.logger.Errorf("Failed to send dry-run preview: %v", err)
+	}
+}
+
+// formatDryRunReport menyusun ringkasan dry-run: diff per field dan peringatan
+func formatDryRunReport(dryRun *services.XRayDryRunResult) string {
+	var builder strings.Builder
+	builder.WriteString("🧪 *DRY-RUN CONVERTER*\n\n")
+	builder.WriteString(fmt.Sprintf("🏷️ *Converter:* %s (.%s)\n", dryRun.DisplayName, dryRun.ConverterName))
+	detected := dryRun.Result.DetectedConfig
+	builder.WriteString(fmt.Sprintf("📡 *Protocol:* %s | *Network:* %s\n\n", strings.ToUpper(detected.Protocol), strings.ToUpper(detected.Network)))
+
+	builder.WriteString(fmt.Sprintf("🔍 *Diff (%d field berubah):*\n", dryRun.ChangedFields()))
+	for _, diff := range dryRun.Diff {
+		if diff.Changed {
+			builder.WriteString(fmt.Sprintf("• %s: %s → %s\n", diff.Field, emptyDash(diff.Original), emptyDash(diff.Modified)))
+		} else {
+			builder.WriteString(fmt.Sprintf("• %s: %s _(tetap)_\n", diff.Field, emptyDash(diff.Original)))
+		}
+	}
+
+	if len(dryRun.Warnings) > 0 {
+		builder.WriteString("\n⚠️ *Peringatan:*\n")
+		for _, warning := range dryRun.Warnings {
+			builder.WriteString("• " + warning + "\n")
+		}
+	} else {
+		builder.WriteString("\n✅ Tidak ada peringatan\n")
+	}
+
+	builder.WriteString(fmt.Sprintf("\n👁️ _Preview %d pesan untuk user dikirim di bawah ini..._", len(dryRun.Messages)))
+	return builder.String()
+}
+
+// emptyDash tampilkan "-" untuk nilai kosong
+func emptyDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
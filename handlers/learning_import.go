@@ -0,0 +1,163 @@
+// Package handlers - import profile Clash YAML / sing-box JSON untuk XRay converter
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/nabilulilalbab/promote/services"
+	"github.com/nabilulilalbab/promote/utils"
+)
+
+// maxImportDocumentSize batas ukuran file profile yang di-download (256 KB)
+const maxImportDocumentSize = 256 * 1024
+
+// importDocumentExtensions ekstensi file yang dianggap profile Clash/sing-box
+var importDocumentExtensions = map[string]bool{
+	".yaml": true,
+	".yml":  true,
+	".json": true,
+	".txt":  true,
+	".conf": true,
+}
+
+// getDocumentCommandText mengembalikan "caption + isi file" jika pesan adalah dokumen profile
+// dengan caption command converter (misal file config.yaml dengan caption .convertbizz atau .convert 1),
+// atau bundle converter dengan caption .importconverters. Dokumen baru di-download setelah
+// caption dan izin pengirim lolos cek yang sama dengan pesan teks.
+func (h *LearningMessageHandler) getDocumentCommandText(evt *events.Message) string {
+	doc := evt.Message.GetDocumentMessage()
+	if doc == nil {
+		return ""
+	}
+
+	caption := strings.TrimSpace(doc.GetCaption())
+	fields := strings.Fields(caption)
+	if len(fields) == 0 || !(h.isXRayConverterName(fields[0]) || isConverterMenuDocument(fields) || isConverterBundleCommand(fields[0])) {
+		return ""
+	}
+	if !h.canUseDocumentCommand(evt, fields[0]) {
+		h.logger.Debugf("📎 Document command ignored: %s | From: %s | Chat: %s", fields[0], evt.Info.Sender.String(), evt.Info.Chat.String())
+		return ""
+	}
+
+	fileName := strings.ToLower(doc.GetFileName())
+	if fileName != "" && !importDocumentExtensions[filepath.Ext(fileName)] {
+		return ""
+	}
+	if doc.GetFileLength() > maxImportDocumentSize {
+		h.logger.Warningf("Profile document too large: %s (%d bytes)", fileName, doc.GetFileLength())
+		return ""
+	}
+
+	data, err := h.client.Download(context.Background(), doc)
+	if err != nil {
+		h.logger.Errorf("Failed to download profile document: %v", err)
+		return ""
+	}
+	if len(data) > maxImportDocumentSize {
+		return ""
+	}
+
+	command := fields[0]
+	if isConverterBundleCommand(command) {
+		command = caption // mode & --dry-run import bundle
+	}
+	if isConverterMenuDocument(fields) {
+		command += " " + fields[1] // .convert <nomor> dari menu
+	}
+	return command + "\n" + string(data)
+}
+
+// isConverterMenuDocument cek caption .convert <nomor> (converter dipilih dari menu .converters)
+func isConverterMenuDocument(fields []string) bool {
+	return strings.EqualFold(fields[0], ".convert") && len(fields) > 1
+}
+
+// canUseDocumentCommand cek izin sebelum dokumen di-download: pesan grup hanya dari grup yang
+// diizinkan, chat pribadi hanya dari admin (sama seperti pesan teks). Bundle converter
+// (.importconverters) hanya diproses di chat pribadi admin.
+func (h *LearningMessageHandler) canUseDocumentCommand(evt *events.Message, command string) bool {
+	isGroup := evt.Info.Chat.Server == types.GroupServer
+	if isConverterBundleCommand(command) {
+		return !isGroup && h.isAdmin(evt.Info.Sender.String())
+	}
+	if isGroup {
+		return h.learningService.IsGroupAllowed(evt.Info.Chat.String())
+	}
+	return h.isAdmin(evt.Info.Sender.String())
+}
+
+// handleProfileImport mengkonversi semua proxy di profile Clash/sing-box lalu mengirim
+// ringkasan, profile hasil modifikasi, dan subscription base64
+func (h *LearningMessageHandler) handleProfileImport(groupJID, userJID, commandName, content string) {
+	h.logger.Infof("🔄 Processing XRay profile import: %s | %d bytes", commandName, len(content))
+
+	result, err := h.xrayConverterService.ProcessProfileImport(commandName, content, userJID, groupJID)
+	if err != nil {
+		h.logger.Errorf("XRay profile import failed: %v", err)
+		h.sendErrorMessage(groupJID, fmt.Sprintf("❌ **Import Failed!**\n\n🔧 **Command:** %s\n📝 **Error:** %s", commandName, utils.RedactSecrets(err.Error())))
+		return
+	}
+	batch := result.Batch
+
+	// === PESAN 1: RINGKASAN PER PROXY ===
+	sourceLabel := "Clash YAML"
+	if result.Format == services.ProfileFormatSingBox {
+		sourceLabel = "sing-box JSON"
+	}
+	summary := h.formatBatchSummary(fmt.Sprintf("📥 *Profile Import Result* (%s)", sourceLabel), batch)
+	if len(result.Skipped) > 0 {
+		summary += fmt.Sprintf("\n⏭️ *Dilewati (%d):*\n", len(result.Skipped))
+		for _, skipped := range result.Skipped {
+			summary += "• " + skipped + "\n"
+		}
+	}
+	h.sendTextMessage(groupJID, summary)
+
+	if batch.SuccessCount == 0 {
+		return
+	}
+
+	// Profile dan share link berisi kredensial, kirim sesuai mode pengiriman
+	delivery := h.xrayConverterService.ResolveDelivery(commandName, groupJID)
+	chat := h.conversionChat(groupJID, userJID, delivery)
+
+	// === PESAN 2: PROFILE HASIL MODIFIKASI (format sama dengan sumber) ===
+	time.Sleep(500 * time.Millisecond)
+	if result.Format == services.ProfileFormatSingBox {
+		h.sendTextMessage(chat, "📦 *sing-box Profile:*\n```json\n"+result.Profile+"\n```")
+	} else {
+		err = h.sendYAML(chat, "📁 *Clash/OpenClash Profile:*", commandName+"-profile.yaml", result.Profile, delivery.YAMLAsDocument)
+	}
+	if err != nil {
+		h.notifyDelivery(groupJID, chat, commandName, delivery, err)
+		return
+	}
+
+	// === PESAN 3: SHARE LINKS ===
+	var links []string
+	var originals []string
+	for _, item := range batch.Items {
+		if item.Result != nil {
+			links = append(links, item.Result.ModifiedLink)
+			originals = append(originals, item.OriginalLink)
+		}
+	}
+	time.Sleep(500 * time.Millisecond)
+	h.sendTextMessage(chat, "🔗 *Share Links:*")
+	time.Sleep(300 * time.Millisecond)
+	h.sendTextMessage(chat, strings.Join(links, "\n"))
+
+	// Simpan link asli hasil import ke subscription user
+	h.saveToSubscription(userJID, commandName, originals)
+	h.notifyDelivery(groupJID, chat, commandName, delivery, nil)
+
+	h.logger.Infof("✅ Profile import result sent to %s (%d/%d success)", chat, batch.SuccessCount, len(batch.Items))
+}
@@ -0,0 +1,83 @@
+// Package handlers - menu XRay converter per operator (.converters) dan pilih converter via nomor (.convert)
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// isConverterMenuCommand cek apakah command adalah .converters atau .convert <nomor>
+func (h *LearningMessageHandler) isConverterMenuCommand(command string) bool {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case ".converters", ".convert":
+		return true
+	}
+	return false
+}
+
+// handleConverterMenuCommand menampilkan menu converter atau menjalankan converter berdasarkan nomor menu
+func (h *LearningMessageHandler) handleConverterMenuCommand(chatJID, userJID, command string) {
+	fields := strings.Fields(command)
+	if strings.ToLower(fields[0]) == ".converters" || len(fields) < 3 {
+		h.sendTextMessage(chatJID, h.formatConverterMenu())
+		return
+	}
+
+	number, err := strconv.Atoi(fields[1])
+	if err != nil {
+		h.sendErrorMessage(chatJID, "❌ Format salah!\n\nContoh: .convert 1 vmess://xxx\n\nKetik .converters untuk melihat nomor converter.")
+		return
+	}
+
+	converter, err := h.xrayConverterService.GetConverterByMenuNumber(number)
+	if err != nil {
+		h.sendErrorMessage(chatJID, fmt.Sprintf("❌ %v\n\nKetik .converters untuk melihat nomor converter.", err))
+		return
+	}
+
+	// Susun ulang menjadi ".<converter> <input>" tanpa mengubah isi input (bisa berisi YAML multi-baris)
+	input := strings.TrimSpace(command)
+	input = strings.TrimSpace(strings.TrimPrefix(input, fields[0]))
+	input = strings.TrimSpace(strings.TrimPrefix(input, fields[1]))
+
+	h.handleXRayConverterCommand(chatJID, userJID, "."+converter.CommandName+" "+input)
+}
+
+// formatConverterMenu menyusun menu converter aktif dikelompokkan per operator
+func (h *LearningMessageHandler) formatConverterMenu() string {
+	menu, err := h.xrayConverterService.GetConverterMenu()
+	if err != nil {
+		h.logger.Errorf("Failed to build converter menu: %v", err)
+		return "❌ Gagal mengambil daftar converter"
+	}
+	if len(menu) == 0 {
+		return "📋 Belum ada converter aktif."
+	}
+
+	var builder strings.Builder
+	builder.WriteString("🔄 *MENU XRAY CONVERTER*\n")
+	for _, category := range menu {
+		builder.WriteString(fmt.Sprintf("\n📶 *%s*\n", category.Name))
+		for _, item := range category.Converters {
+			converter := item.Converter
+			builder.WriteString(fmt.Sprintf("%d. *%s* (.%s)\n", item.Number, converter.DisplayName, converter.CommandName))
+			if converter.Description != "" {
+				builder.WriteString(fmt.Sprintf("    %s\n", converter.Description))
+			}
+			if converter.Example != "" {
+				builder.WriteString(fmt.Sprintf("    _Contoh: %s_\n", converter.Example))
+			}
+		}
+	}
+	builder.WriteString("\n💡 *Cara pakai:*\n")
+	builder.WriteString("• .convert [nomor] [link] - contoh: .convert 1 vmess://xxx\n")
+	builder.WriteString("• atau langsung .[command] [link]")
+
+	return builder.String()
+}
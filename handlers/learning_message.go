@@ -3,6 +3,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -25,6 +26,9 @@ type LearningMessageHandler struct {
 	logger             *utils.Logger
 	adminNumbers       []string // Daftar nomor admin
 	
+	// Base URL publik untuk link subscription (diatur via SetSubscriptionBaseURL)
+	subscriptionBaseURL string
+	
 	// Rate limiting: map[userJID]lastCommandTime
 	commandCooldown    map[string]time.Time
 }
@@ -56,6 +60,10 @@ func (h *LearningMessageHandler) HandleMessage(evt *events.Message) {
 
 	// STEP 2: Ambil teks dari pesan
 	messageText := h.getMessageText(evt.Message)
+	if messageText == "" {
+		// Dokumen profile Clash/sing-box dengan caption command converter
+		messageText = h.getDocumentCommandText(evt)
+	}
 	if messageText == "" {
 		return // Skip jika bukan pesan teks
 	}
@@ -72,7 +80,7 @@ func (h *LearningMessageHandler) HandleMessage(evt *events.Message) {
 	}
 
 	h.logger.Debugf("📨 Message [%s]: %s | From: %s | Group: %s",
-		chatType, h.truncateString(messageText, 50), userJID, groupJID)
+		chatType, describeMessageForLog(messageText), userJID, groupJID)
 
 	// STEP 4: Proses berdasarkan jenis chat
 	if isGroup {
@@ -141,7 +149,7 @@ func (h *LearningMessageHandler) handleLearningCommand(groupJID, userJID, comman
 	
 	if lastTime, exists := h.commandCooldown[cooldownKey]; exists {
 		if now.Sub(lastTime) < 3*time.Second {
-			h.logger.Debugf("🕒 Rate limit: User %s in cooldown, ignoring command: %s", userJID, command)
+			h.logger.Debugf("🕒 Rate limit: User %s in cooldown, ignoring %s", userJID, describeMessageForLog(command))
 			return
 		}
 	}
@@ -149,9 +157,21 @@ func (h *LearningMessageHandler) handleLearningCommand(groupJID, userJID, comman
 	// Update cooldown time
 	h.commandCooldown[cooldownKey] = now
 	
-	h.logger.Infof("🔧 Processing learning command: %s | Group: %s | User: %s",
-		command, groupJID, userJID)
+	h.logger.Infof("🔧 Processing learning %s | Group: %s | User: %s",
+		describeMessageForLog(command), groupJID, userJID)
 
+	// Cek apakah ini command subscription (.mysub, .resetsub, .clearsub)
+	if h.isSubscriptionCommand(command) {
+		h.handleSubscriptionCommand(groupJID, userJID, command)
+		return
+	}
+	
+	// Cek apakah ini menu converter (.converters / .convert <nomor> <link>)
+	if h.isConverterMenuCommand(command) {
+		h.handleConverterMenuCommand(groupJID, userJID, command)
+		return
+	}
+	
 	// Cek apakah ini XRay converter command
 	if h.isXRayConverterCommand(command) {
 		h.handleXRayConverterCommand(groupJID, userJID, command)
@@ -161,7 +181,7 @@ func (h *LearningMessageHandler) handleLearningCommand(groupJID, userJID, comman
 	// Process normal learning command
 	err := h.learningService.ProcessCommand(groupJID, userJID, command)
 	if err != nil {
-		h.logger.Errorf("Failed to process command %s: %v", command, err)
+		h.logger.Errorf("Failed to process %s: %v", describeMessageForLog(command), err)
 	}
 }
 
@@ -184,7 +204,7 @@ func (h *LearningMessageHandler) handleAdminCommand(evt *events.Message, userJID
 	
 	if lastTime, exists := h.commandCooldown[cooldownKey]; exists {
 		if now.Sub(lastTime) < 2*time.Second {
-			h.logger.Debugf("🕒 Admin rate limit: User %s in cooldown, ignoring command: %s", userJID, command)
+			h.logger.Debugf("🕒 Admin rate limit: User %s in cooldown, ignoring %s", userJID, describeMessageForLog(command))
 			return
 		}
 	}
@@ -192,7 +212,19 @@ func (h *LearningMessageHandler) handleAdminCommand(evt *events.Message, userJID
 	// Update cooldown time
 	h.commandCooldown[cooldownKey] = now
 	
-	h.logger.Infof("🔧 Processing admin command: %s | User: %s", command, userJID)
+	h.logger.Infof("🔧 Processing admin %s | User: %s", describeMessageForLog(command), userJID)
+	
+	// Cek apakah ini command subscription
+	if h.isSubscriptionCommand(command) {
+		h.handleSubscriptionCommand(evt.Info.Chat.String(), userJID, command)
+		return
+	}
+	
+	// Cek apakah ini menu converter (.converters / .convert <nomor> <link>)
+	if h.isConverterMenuCommand(command) {
+		h.handleConverterMenuCommand(evt.Info.Chat.String(), userJID, command)
+		return
+	}
 	
 	// Cek apakah ini XRay converter command
 	if h.isXRayConverterCommand(command) {
@@ -208,6 +240,20 @@ func (h *LearningMessageHandler) handleAdminCommand(evt *events.Message, userJID
 		h.handleRemoveGroupCommand(evt, userJID, command)
 	case strings.HasPrefix(command, ".listgroups"):
 		h.handleListGroupsCommand(evt, userJID)
+	case strings.HasPrefix(command, ".setdelivery"):
+		h.handleSetDeliveryCommand(evt, command)
+	case strings.HasPrefix(command, ".setquota"):
+		h.handleSetQuotaCommand(evt, userJID, command)
+	case strings.HasPrefix(command, ".delquota"):
+		h.handleDeleteQuotaCommand(evt, command)
+	case strings.HasPrefix(command, ".quotas"):
+		h.handleListQuotasCommand(evt)
+	case strings.HasPrefix(command, ".testconvert"):
+		h.handleTestConvertCommand(evt, command)
+	case strings.HasPrefix(command, ".exportconverters"):
+		h.handleExportConvertersCommand(evt, userJID, command)
+	case strings.HasPrefix(command, ".importconverters"):
+		h.handleImportConvertersCommand(evt, userJID, command)
 	case strings.HasPrefix(command, ".stats"):
 		h.handleStatsCommand(evt, userJID)
 	case strings.HasPrefix(command, ".logs"):
@@ -218,7 +264,7 @@ func (h *LearningMessageHandler) handleAdminCommand(evt *events.Message, userJID
 		// Try processing as learning command
 		err := h.learningService.ProcessCommand(evt.Info.Chat.String(), userJID, command)
 		if err != nil {
-			h.logger.Errorf("Failed to process admin command %s: %v", command, err)
+			h.logger.Errorf("Failed to process admin %s: %v", describeMessageForLog(command), err)
 			h.sendAdminMessage(evt.Info.Chat, fmt.Sprintf("❌ Command tidak dikenali: %s\n\nKetik .help untuk bantuan.", command))
 		}
 	}
@@ -234,14 +280,15 @@ func (h *LearningMessageHandler) isXRayConverterCommand(command string) bool {
 		return false
 	}
 	
-	commandName := parts[0]
-	
-	// Cek apakah command dimulai dengan .convert atau custom command yang ada di database
-	if strings.HasPrefix(commandName, ".convert") {
-		return true
+	return h.isXRayConverterName(parts[0])
+}
+
+// isXRayConverterName cek apakah nama command (dengan prefix ".") adalah XRay converter
+func (h *LearningMessageHandler) isXRayConverterName(commandName string) bool {
+	// Hanya converter aktif di database; .convert dan .converters adalah menu, bukan nama converter
+	if !strings.HasPrefix(commandName, ".") {
+		return false
 	}
-	
-	// Cek di database apakah ada converter dengan nama ini
 	converterName := strings.TrimPrefix(commandName, ".")
 	converter, err := h.xrayConverterService.GetAllConverters()
 	if err != nil {
@@ -267,125 +314,225 @@ func (h *LearningMessageHandler) handleXRayConverterCommand(groupJID, userJID, c
 	}
 	
 	commandName := strings.TrimPrefix(parts[0], ".")
-	xrayLink := parts[1]
 	
-	h.logger.Infof("🔄 Processing XRay conversion: %s | Link: %s", commandName, h.truncateString(xrayLink, 50))
+	// Profile Clash YAML / sing-box JSON -> import semua proxy di dalamnya
+	body := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(command), parts[0]))
+	if services.DetectProfileFormat(body) != "" {
+		h.handleProfileImport(groupJID, userJID, commandName, body)
+		return
+	}
+	
+	inputs, options := h.parseConverterArgs(parts[1:])
+	links := services.ExtractXRayLinks(strings.Join(inputs, "\n"))
+	
+	// Banyak link atau subscription base64 -> batch conversion
+	if len(links) > 1 {
+		h.handleBatchConversion(groupJID, userJID, commandName, links)
+		return
+	}
+	
+	xrayLink := ""
+	if len(links) == 1 {
+		xrayLink = links[0]
+	} else if len(inputs) > 0 {
+		xrayLink = inputs[0] // biarkan service yang melaporkan link tidak valid
+	}
+	if xrayLink == "" {
+		h.sendErrorMessage(groupJID, "❌ Format salah!\n\nContoh: .convertbizz vmess://xxx --format singbox")
+		return
+	}
+	
+	h.logger.Infof("🔄 Processing XRay conversion: %s | Link: %s", commandName, utils.RedactProxyLink(xrayLink))
 	
 	// Process conversion
-	result, err := h.xrayConverterService.ProcessConversion(commandName, xrayLink, userJID, groupJID)
+	result, err := h.xrayConverterService.ProcessConversionWithOptions(commandName, xrayLink, userJID, groupJID, options)
 	if err != nil {
 		h.logger.Errorf("XRay conversion failed: %v", err)
 		
-		errorMsg := fmt.Sprintf("❌ **Conversion Failed!**\n\n🔧 **Command:** %s\n📝 **Error:** %s\n\n💡 **Tips:**\n• Pastikan link XRay valid\n• Cek format: vmess://, vless://, trojan://\n• Command tersedia: %s", 
-			commandName, err.Error(), h.getAvailableConverters())
+		var quotaErr *services.QuotaExceededError
+		if errors.As(err, &quotaErr) {
+			h.sendErrorMessage(groupJID, formatQuotaExceeded(quotaErr))
+			return
+		}
+		
+		errorMsg := fmt.Sprintf("❌ **Conversion Failed!**\n\n🔧 **Command:** %s\n📝 **Error:** %s\n\n💡 **Tips:**\n• Pastikan link XRay valid\n• Cek format: vmess://, vless://, trojan://, ss://\n• Command tersedia: %s", 
+			commandName, utils.RedactSecrets(err.Error()), h.getAvailableConverters())
 		
 		h.sendErrorMessage(groupJID, errorMsg)
 		return
 	}
 	
-	// Send success response
-	h.sendConversionResult(groupJID, result, commandName)
+	// Send success response (grup atau chat pribadi sesuai mode pengiriman)
+	delivery := h.xrayConverterService.ResolveDelivery(commandName, groupJID)
+	chat := h.conversionChat(groupJID, userJID, delivery)
+	err = h.sendConversionResult(chat, result, commandName, delivery)
+	h.notifyDelivery(groupJID, chat, commandName, delivery, err)
+	
+	// Simpan ke subscription user
+	h.saveToSubscription(userJID, commandName, []string{xrayLink})
 }
 
-// sendConversionResult mengirim hasil conversion ke grup (2 pesan terpisah)
-func (h *LearningMessageHandler) sendConversionResult(groupJID string, result *database.ModifiedXRayConfig, commandName string) {
-	// Parse JID untuk chat target
-	chatJID, err := types.ParseJID(groupJID)
-	if err != nil {
-		h.logger.Errorf("Failed to parse group JID: %v", err)
-		return
-	}
-	
-	// Get converter info
-	converter, _ := h.xrayConverterService.GetAllConverters()
-	var displayName string
-	for _, conv := range converter {
-		if conv.CommandName == commandName {
-			displayName = conv.DisplayName
-			break
+// parseConverterArgs memisahkan input link dan opsi --format (contoh: --format singbox,surge atau --format=xray)
+// serta --probe untuk cek koneksi hasil konversi
+func (h *LearningMessageHandler) parseConverterArgs(args []string) ([]string, services.XRayConversionOptions) {
+	var inputs []string
+	var options services.XRayConversionOptions
+	
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "--format="):
+			options.Formats = append(options.Formats, services.ParseFormatList(strings.TrimPrefix(arg, "--format="))...)
+		case arg == "--format" || arg == "-f":
+			if i+1 < len(args) {
+				options.Formats = append(options.Formats, services.ParseFormatList(args[i+1])...)
+				i++
+			}
+		case arg == "--probe" || arg == "-p":
+			options.Probe = true
+		default:
+			inputs = append(inputs, arg)
 		}
 	}
-	if displayName == "" {
-		displayName = strings.ToUpper(commandName)
-	}
-	
-	// === PESAN 1: INFO & DETAILS ===
-	var infoBuilder strings.Builder
-	
-	// Header dengan emoji dan info
-	infoBuilder.WriteString("✅ *Conversion Success!*\n\n")
-	infoBuilder.WriteString(fmt.Sprintf("🏷️ *Converter:* %s\n", displayName))
-	infoBuilder.WriteString(fmt.Sprintf("🔧 *Type:* %s\n", strings.ToUpper(result.ModifyType)))
-	infoBuilder.WriteString(fmt.Sprintf("📡 *Protocol:* %s | *Network:* %s | *TLS:* %s\n\n", 
-		strings.ToUpper(result.DetectedConfig.Protocol), 
-		strings.ToUpper(result.DetectedConfig.Network),
-		func() string { if result.DetectedConfig.TLS { return "Yes" } else { return "No" } }()))
-	
-	// Modification details dengan format rapi
-	infoBuilder.WriteString("🔍 *Modification Details:*\n")
-	infoBuilder.WriteString(fmt.Sprintf("• Original Server: %s\n", result.DetectedConfig.Server))
-	infoBuilder.WriteString(fmt.Sprintf("• Bug Host: %s\n", result.BugHost))
-	
-	switch result.ModifyType {
-	case "wildcard":
-		infoBuilder.WriteString(fmt.Sprintf("• Modified Server: %s\n", result.ModifiedServer))
-		infoBuilder.WriteString(fmt.Sprintf("• Modified Host: %s\n", result.ModifiedHost))
-		if result.DetectedConfig.TLS {
-			infoBuilder.WriteString(fmt.Sprintf("• Modified SNI: %s\n", result.ModifiedSNI))
-		}
-	case "sni":
-		infoBuilder.WriteString(fmt.Sprintf("• Modified SNI: %s\n", result.ModifiedSNI))
-		infoBuilder.WriteString("• Server & Host: _unchanged_\n")
-	case "ws", "grpc":
-		infoBuilder.WriteString(fmt.Sprintf("• Modified Server: %s\n", result.ModifiedServer))
-		infoBuilder.WriteString("• Host & SNI: _unchanged_\n")
+	
+	return inputs, options
+}
+
+// handleBatchConversion mengkonversi banyak link sekaligus dan mengirim ringkasan + hasil gabungan
+func (h *LearningMessageHandler) handleBatchConversion(groupJID, userJID, commandName string, links []string) {
+	h.logger.Infof("🔄 Processing XRay batch conversion: %s | %d links", commandName, len(links))
+	
+	batch, err := h.xrayConverterService.ProcessBatchConversion(commandName, links, userJID, groupJID)
+	if err != nil {
+		h.logger.Errorf("XRay batch conversion failed: %v", err)
+		h.sendErrorMessage(groupJID, fmt.Sprintf("❌ **Batch Conversion Failed!**\n\n🔧 **Command:** %s\n📝 **Error:** %s", commandName, utils.RedactSecrets(err.Error())))
+		return
 	}
 	
-	// YAML Configuration dengan format rapi
-	infoBuilder.WriteString("\n📁 *YAML Configuration:*\n")
-	infoBuilder.WriteString("```yaml\n")
-	infoBuilder.WriteString(result.YAMLConfig)
-	infoBuilder.WriteString("```\n\n")
+	// Ringkasan tetap di grup; hasil berisi kredensial dikirim sesuai mode pengiriman
+	delivery := h.xrayConverterService.ResolveDelivery(commandName, groupJID)
+	chat := h.conversionChat(groupJID, userJID, delivery)
 	
-	infoBuilder.WriteString("💡 *Usage Instructions:*\n")
-	infoBuilder.WriteString("1. Copy modified link untuk V2Ray/Xray\n")
-	infoBuilder.WriteString("2. Copy YAML config untuk Clash/OpenClash\n")
-	infoBuilder.WriteString("3. Restart aplikasi setelah config\n\n")
-	infoBuilder.WriteString("📱 _Modified link akan dikirim di pesan berikutnya untuk kemudahan copy..._")
+	// === PESAN 1: RINGKASAN PER LINK ===
+	h.sendTextMessage(groupJID, h.formatBatchSummary("📦 *Batch Conversion Result*", batch))
 	
-	// Kirim pesan 1
-	infoText := infoBuilder.String()
-	msg1 := &waProto.Message{
-		Conversation: &infoText,
+	if batch.SuccessCount == 0 {
+		return
 	}
 	
-	_, err = h.client.SendMessage(context.Background(), chatJID, msg1)
+	// === PESAN 2: GABUNGAN CLASH PROXIES (atau profile lengkap) ===
+	time.Sleep(500 * time.Millisecond)
+	if batch.ClashProfile != "" {
+		err = h.sendYAML(chat, "📁 *Mihomo/OpenClash Profile:*", commandName+"-profile.yaml", batch.ClashProfile, delivery.YAMLAsDocument)
+	} else {
+		err = h.sendYAML(chat, "📁 *Clash/OpenClash Proxies:*", commandName+"-proxies.yaml", batch.ClashProxies, delivery.YAMLAsDocument)
+	}
 	if err != nil {
-		h.logger.Errorf("Failed to send conversion info: %v", err)
+		h.notifyDelivery(groupJID, chat, commandName, delivery, err)
 		return
 	}
 	
-	// Delay sedikit sebelum kirim pesan kedua
+	// === PESAN 3: SUBSCRIPTION BASE64 ===
 	time.Sleep(500 * time.Millisecond)
+	h.sendTextMessage(chat, "🔗 *Subscription (base64):*")
+	time.Sleep(300 * time.Millisecond)
+	h.sendTextMessage(chat, batch.Subscription)
+	
+	// Simpan link yang berhasil ke subscription user
+	var converted []string
+	for _, item := range batch.Items {
+		if item.Result != nil {
+			converted = append(converted, item.OriginalLink)
+		}
+	}
+	h.saveToSubscription(userJID, commandName, converted)
+	h.notifyDelivery(groupJID, chat, commandName, delivery, nil)
 	
-	// === PESAN 2: MODIFIED LINK ONLY ===
-	linkText := result.ModifiedLink
+	h.logger.Infof("✅ Batch conversion result sent to %s (%d/%d success)", chat, batch.SuccessCount, len(batch.Items))
+}
+
+// formatBatchSummary menyusun ringkasan hasil per link dari batch conversion
+func (h *LearningMessageHandler) formatBatchSummary(title string, batch *database.XRayBatchResult) string {
+	var summary strings.Builder
+	summary.WriteString(title + "\n\n")
+	summary.WriteString(fmt.Sprintf("✅ Berhasil: %d | ❌ Gagal: %d | Total: %d\n\n", batch.SuccessCount, batch.FailedCount, len(batch.Items)))
+	for _, item := range batch.Items {
+		if item.Result != nil {
+			detected := item.Result.DetectedConfig
+			label := detected.Remarks
+			if label == "" {
+				label = detected.Server
+			}
+			summary.WriteString(fmt.Sprintf("%d. ✅ %s (%s/%s)\n", item.Index, label,
+				strings.ToUpper(detected.Protocol), strings.ToUpper(detected.Network)))
+			if item.Result.Probe != nil {
+				summary.WriteString("    " + services.FormatProbeResult(item.Result.Probe) + "\n")
+			}
+		} else {
+			summary.WriteString(fmt.Sprintf("%d. ❌ %s\n", item.Index, item.Error))
+		}
+	}
+	return summary.String()
+}
+
+// sendConversionResult mengirim hasil conversion ke chat tujuan (info, output tambahan, lalu link).
+// Error dikembalikan jika pesan pertama gagal terkirim.
+func (h *LearningMessageHandler) sendConversionResult(groupJID string, result *database.ModifiedXRayConfig, commandName string, delivery services.XRayDelivery) error {
+	// Parse JID untuk chat target
+	chatJID, err := types.ParseJID(groupJID)
+	if err != nil {
+		h.logger.Errorf("Failed to parse group JID: %v", err)
+		return err
+	}
 	
-	// Kirim pesan 2
-	msg2 := &waProto.Message{
-		Conversation: &linkText,
+	displayName := h.xrayConverterService.ConverterDisplayName(commandName)
+	messages := services.BuildConversionMessages(result, displayName, commandName, delivery)
+	if err := h.sendChatMessages(chatJID, messages); err != nil {
+		return err
 	}
 	
-	_, err = h.client.SendMessage(context.Background(), chatJID, msg2)
-	if err != nil {
-		h.logger.Errorf("Failed to send conversion link: %v", err)
-	} else {
-		h.logger.Infof("✅ Conversion result sent to %s (2 messages)", groupJID)
+	h.logger.Infof("✅ Conversion result sent to %s (%d messages)", groupJID, len(messages))
+	return nil
+}
+
+// sendChatMessages mengirim pesan hasil konversi berurutan (teks atau dokumen).
+// Error dikembalikan jika pesan pertama gagal terkirim.
+func (h *LearningMessageHandler) sendChatMessages(chatJID types.JID, messages []services.XRayChatMessage) error {
+	for i, message := range messages {
+		// Delay sedikit antar pesan agar urutan tetap terjaga
+		if i > 0 {
+			time.Sleep(500 * time.Millisecond)
+		}
+		
+		if message.IsDocument() {
+			if err := h.sendDocument(chatJID.String(), message.FileName, message.MimeType, []byte(message.Content), message.Caption); err != nil {
+				h.logger.Errorf("Failed to send %s document: %v", message.FileName, err)
+			}
+			continue
+		}
+		
+		text := message.Text
+		msg := &waProto.Message{
+			Conversation: &text,
+		}
+		if _, err := h.client.SendMessage(context.Background(), chatJID, msg); err != nil {
+			if i == 0 {
+				h.logger.Errorf("Failed to send conversion info: %v", err)
+				return err
+			}
+			h.logger.Errorf("Failed to send conversion message %d: %v", i+1, err)
+		}
 	}
+	return nil
 }
 
 // sendErrorMessage mengirim pesan error
 func (h *LearningMessageHandler) sendErrorMessage(groupJID, errorMsg string) {
+	h.sendTextMessage(groupJID, errorMsg)
+}
+
+// sendTextMessage mengirim pesan teks biasa ke chat
+func (h *LearningMessageHandler) sendTextMessage(groupJID, text string) {
 	chatJID, err := types.ParseJID(groupJID)
 	if err != nil {
 		h.logger.Errorf("Failed to parse group JID: %v", err)
@@ -393,10 +540,12 @@ func (h *LearningMessageHandler) sendErrorMessage(groupJID, errorMsg string) {
 	}
 	
 	msg := &waProto.Message{
-		Conversation: &errorMsg,
+		Conversation: &text,
 	}
 	
-	h.client.SendMessage(context.Background(), chatJID, msg)
+	if _, err := h.client.SendMessage(context.Background(), chatJID, msg); err != nil {
+		h.logger.Errorf("Failed to send message: %v", err)
+	}
 }
 
 // getAvailableConverters mendapatkan daftar converter yang tersedia
@@ -411,7 +560,7 @@ func (h *LearningMessageHandler) getAvailableConverters() string {
 		available = append(available, fmt.Sprintf(".%s", conv.CommandName))
 	}
 	
-	return strings.Join(available, ", ")
+	return strings.Join(available, ", ") + "\n• Ketik .converters untuk menu lengkap"
 }
 
 // === ADMIN COMMAND HANDLERS ===
@@ -443,6 +592,41 @@ func (h *LearningMessageHandler) sendAdminHelp(chatJID types.JID) {
 • .convertnetflix [vmess://xxx] - XL-Netflix-WS
 • .convertgopay [vmess://xxx] - XL-Gopay-Midtrans-WC
 • .convertgrpc [vmess://xxx] - Generic-gRPC
+• .converters - Menu converter per operator
+• .convert [nomor] [vmess://xxx] - Pakai converter dari nomor menu
+
+📋 **Batch:** kirim banyak link (pisah spasi/baris baru) atau subscription base64 dalam satu pesan
+
+📦 **Output Format (opsional):**
+• .convertbizz [link] --format singbox,surge
+• Format: clash, mihomo, singbox, xray, v2rayn, surge, quanx
+
+🩺 **Probe Koneksi (opsional):**
+• .convertbizz [link] --probe - Cek TCP/TLS/WebSocket ke server hasil konversi
+
+📥 **Import Profile:**
+• .convertbizz + paste YAML Clash (proxies:) atau JSON sing-box
+• Kirim file .yaml/.json dengan caption .convertbizz atau .convert [nomor]
+
+📩 **Pengiriman Hasil (admin):**
+• .setdelivery [JID grup] [group|dm|dm_ack] - Balas di grup, DM saja, atau DM + konfirmasi di grup
+
+📊 **Kuota Converter (admin):**
+• .setquota [nomor] [converter|all] [limit|unlimited] - Kuota khusus user
+• .delquota [nomor] [converter|all] - Hapus kuota khusus
+• .quotas - Lihat kuota converter & kuota khusus user
+
+🧪 **Tes Converter (admin):**
+• .testconvert [converter] [link] - Dry-run: diff field, peringatan, dan preview pesan user (tanpa log/kuota)
+
+📦 **Bundle Converter (admin):**
+• .exportconverters [converter...] - Kirim file bundle JSON (kosong = semua)
+• .importconverters [skip|overwrite|rename] [--dry-run] - Caption file bundle / paste JSON
+
+🔗 **Subscription:**
+• .mysub - Kirim URL subscription pribadi (V2Ray, Clash, sing-box)
+• .resetsub - Ganti token URL subscription
+• .clearsub - Kosongkan isi subscription
 
 ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
            **LEARNING COMMANDS**
@@ -640,10 +824,11 @@ func (h *LearningMessageHandler) isAdmin(userJID string) bool {
 	return false
 }
 
-// truncateString memotong string jika terlalu panjang untuk logging
-func (h *LearningMessageHandler) truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+// describeMessageForLog ringkasan pesan untuk log tanpa isi pesan (bisa berisi link/kredensial):
+// hanya nama command dan panjang pesan
+func describeMessageForLog(messageText string) string {
+	if strings.HasPrefix(messageText, ".") {
+		return fmt.Sprintf("command %s (%d chars)", strings.Fields(messageText)[0], len(messageText))
 	}
-	return s[:maxLen] + "..."
+	return fmt.Sprintf("text (%d chars)", len(messageText))
 }
\ No newline at end of file
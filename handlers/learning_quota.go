@@ -0,0 +1,138 @@
+// Package handlers - kuota XRay converter dan override kuota per user oleh admin
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/nabilulilalbab/promote/services"
+)
+
+// quotaScopeLabels label cakupan kuota untuk pesan ke user
+var quotaScopeLabels = map[string]string{
+	services.QuotaScopeUser:   "per user",
+	services.QuotaScopeGroup:  "per grup",
+	services.QuotaScopeGlobal: "semua user",
+}
+
+// formatQuotaExceeded menyusun pesan kuota habis untuk user
+func formatQuotaExceeded(err *services.QuotaExceededError) string {
+	return fmt.Sprintf("⏳ *Kuota Habis!*\n\n🔧 *Converter:* %s\n📊 *Batas:* %d konversi/hari (%s)\n🕒 *Reset jam:* %s\n\nSilakan coba lagi setelah kuota reset.",
+		err.ConverterName, err.Limit, quotaScopeLabels[err.Scope], err.ResetAt.Local().Format("15:04"))
+}
+
+// handleSetQuotaCommand menangani .setquota [nomor] [converter|all] [limit|unlimited]
+func (h *LearningMessageHandler) handleSetQuotaCommand(evt *events.Message, userJID, command string) {
+	parts := strings.Fields(command)
+	if len(parts) < 4 {
+		h.sendAdminMessage(evt.Info.Chat, "❌ Format salah!\n\nContoh:\n.setquota 6281234567890 convertbizz 50\n.setquota 6281234567890 all unlimited")
+		return
+	}
+
+	converterName := quotaConverterArg(parts[2])
+
+	limit := 0
+	if !strings.EqualFold(parts[3], "unlimited") {
+		value, err := strconv.Atoi(parts[3])
+		if err != nil || value <= 0 {
+			h.sendAdminMessage(evt.Info.Chat, "❌ Limit harus angka > 0 atau 'unlimited'")
+			return
+		}
+		limit = value
+	}
+
+	if err := h.xrayConverterService.SetQuotaOverride(parts[1], converterName, limit, userJID); err != nil {
+		h.logger.Errorf("Failed to set quota override: %v", err)
+		h.sendAdminMessage(evt.Info.Chat, fmt.Sprintf("❌ Gagal menyimpan kuota: %v", err))
+		return
+	}
+
+	h.sendAdminMessage(evt.Info.Chat, fmt.Sprintf("✅ Kuota khusus disimpan!\n\n👤 **User:** %s\n🔧 **Converter:** %s\n📊 **Limit:** %s",
+		services.NormalizeUserNumber(parts[1]), quotaConverterLabel(converterName), quotaLimitLabel(limit)))
+}
+
+// handleDeleteQuotaCommand menangani .delquota [nomor] [converter|all]
+func (h *LearningMessageHandler) handleDeleteQuotaCommand(evt *events.Message, command string) {
+	parts := strings.Fields(command)
+	if len(parts) < 2 {
+		h.sendAdminMessage(evt.Info.Chat, "❌ Format salah!\n\nContoh: .delquota 6281234567890 convertbizz")
+		return
+	}
+
+	converterName := ""
+	if len(parts) > 2 {
+		converterName = quotaConverterArg(parts[2])
+	}
+
+	if err := h.xrayConverterService.RemoveQuotaOverride(parts[1], converterName); err != nil {
+		h.logger.Errorf("Failed to delete quota override: %v", err)
+		h.sendAdminMessage(evt.Info.Chat, fmt.Sprintf("❌ Gagal menghapus kuota: %v", err))
+		return
+	}
+
+	h.sendAdminMessage(evt.Info.Chat, fmt.Sprintf("✅ Kuota khusus dihapus!\n\n👤 **User:** %s\n🔧 **Converter:** %s",
+		services.NormalizeUserNumber(parts[1]), quotaConverterLabel(converterName)))
+}
+
+// handleListQuotasCommand menangani .quotas (daftar kuota converter dan override user)
+func (h *LearningMessageHandler) handleListQuotasCommand(evt *events.Message) {
+	converters, err := h.xrayConverterService.GetAllConverters()
+	if err != nil {
+		h.logger.Errorf("Failed to get converters: %v", err)
+		h.sendAdminMessage(evt.Info.Chat, "❌ Gagal mengambil daftar converter")
+		return
+	}
+
+	var response strings.Builder
+	response.WriteString("📊 **KUOTA XRAY CONVERTER** (per 24 jam)\n\n")
+	for _, converter := range converters {
+		response.WriteString(fmt.Sprintf("🔧 .%s → user: %s | grup: %s | global: %s\n", converter.CommandName,
+			quotaLimitLabel(converter.DailyUserQuota), quotaLimitLabel(converter.DailyGroupQuota),
+			quotaLimitLabel(converter.DailyGlobalQuota)))
+	}
+
+	overrides, err := h.xrayConverterService.GetQuotaOverrides()
+	if err != nil {
+		h.logger.Errorf("Failed to get quota overrides: %v", err)
+		h.sendAdminMessage(evt.Info.Chat, "❌ Gagal mengambil kuota khusus user")
+		return
+	}
+
+	response.WriteString("\n👤 **Kuota Khusus User:**\n")
+	if len(overrides) == 0 {
+		response.WriteString("Belum ada. Gunakan .setquota untuk menambah.")
+	}
+	for _, override := range overrides {
+		response.WriteString(fmt.Sprintf("• %s | %s | %s\n", override.UserNumber,
+			quotaConverterLabel(override.ConverterName), quotaLimitLabel(override.DailyLimit)))
+	}
+
+	h.sendAdminMessage(evt.Info.Chat, response.String())
+}
+
+// quotaConverterArg mengubah argumen converter ("all", ".convertbizz") ke nama converter
+func quotaConverterArg(arg string) string {
+	if strings.EqualFold(arg, "all") || arg == "*" {
+		return ""
+	}
+	return strings.TrimPrefix(arg, ".")
+}
+
+// quotaConverterLabel label converter untuk override (kosong = semua)
+func quotaConverterLabel(converterName string) string {
+	if converterName == "" {
+		return "semua converter"
+	}
+	return "." + converterName
+}
+
+// quotaLimitLabel label limit kuota (0 = tanpa batas)
+func quotaLimitLabel(limit int) string {
+	if limit <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d/hari", limit)
+}
@@ -0,0 +1,101 @@
+// Package handlers - command subscription URL untuk hasil konversi XRay
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// SetSubscriptionBaseURL mengatur base URL publik untuk link subscription (contoh: https://bot.example.com)
+func (h *LearningMessageHandler) SetSubscriptionBaseURL(baseURL string) {
+	h.subscriptionBaseURL = strings.TrimRight(baseURL, "/")
+}
+
+// isSubscriptionCommand cek apakah command adalah command subscription
+func (h *LearningMessageHandler) isSubscriptionCommand(command string) bool {
+	switch strings.ToLower(strings.TrimSpace(command)) {
+	case ".mysub", ".resetsub", ".clearsub":
+		return true
+	}
+	return false
+}
+
+// handleSubscriptionCommand menangani .mysub, .resetsub, dan .clearsub
+// URL subscription selalu dikirim lewat chat pribadi agar token tidak bocor di grup
+func (h *LearningMessageHandler) handleSubscriptionCommand(chatJID, userJID, command string) {
+	owner := subscriptionOwner(userJID)
+
+	switch strings.ToLower(strings.TrimSpace(command)) {
+	case ".mysub":
+		sub, err := h.xrayConverterService.GetOrCreateSubscription(owner)
+		if err != nil {
+			h.logger.Errorf("Failed to get subscription: %v", err)
+			h.sendErrorMessage(chatJID, "❌ Gagal mengambil subscription, coba lagi nanti.")
+			return
+		}
+		count := h.xrayConverterService.CountSubscriptionItems(sub)
+		h.sendSubscriptionURLs(chatJID, owner, sub.Token, count)
+
+	case ".resetsub":
+		sub, err := h.xrayConverterService.ResetSubscriptionToken(owner)
+		if err != nil {
+			h.logger.Errorf("Failed to reset subscription token: %v", err)
+			h.sendErrorMessage(chatJID, "❌ Gagal reset subscription, coba lagi nanti.")
+			return
+		}
+		count := h.xrayConverterService.CountSubscriptionItems(sub)
+		h.sendSubscriptionURLs(chatJID, owner, sub.Token, count)
+
+	case ".clearsub":
+		if err := h.xrayConverterService.ClearSubscription(owner); err != nil {
+			h.logger.Errorf("Failed to clear subscription: %v", err)
+			h.sendErrorMessage(chatJID, "❌ Gagal mengosongkan subscription, coba lagi nanti.")
+			return
+		}
+		h.sendTextMessage(chatJID, "🗑️ Subscription kamu sudah dikosongkan. URL tetap sama, konversi berikutnya akan ditambahkan otomatis.")
+	}
+}
+
+// sendSubscriptionURLs mengirim URL subscription ke chat pribadi user
+func (h *LearningMessageHandler) sendSubscriptionURLs(chatJID, owner, token string, count int) {
+	baseURL := fmt.Sprintf("%s/sub/%s", h.subscriptionBaseURL, token)
+
+	var builder strings.Builder
+	builder.WriteString("🔗 *Subscription URL Kamu*\n\n")
+	builder.WriteString(fmt.Sprintf("📦 Jumlah config: %d\n\n", count))
+	builder.WriteString("*V2Ray/Xray (base64):*\n")
+	builder.WriteString(baseURL + "\n\n")
+	builder.WriteString("*Clash/OpenClash:*\n")
+	builder.WriteString(baseURL + "?format=clash\n\n")
+	builder.WriteString("*sing-box:*\n")
+	builder.WriteString(baseURL + "?format=singbox\n\n")
+	builder.WriteString("💡 _Setiap konversi otomatis ditambahkan. Gunakan .resetsub untuk ganti URL, .clearsub untuk mengosongkan._")
+
+	h.sendTextMessage(owner, builder.String())
+
+	// Beri tahu di grup bahwa URL dikirim lewat chat pribadi
+	if chatJID != owner {
+		h.sendTextMessage(chatJID, "📩 URL subscription sudah dikirim lewat chat pribadi.")
+	}
+}
+
+// saveToSubscription menyimpan link yang berhasil dikonversi ke subscription user
+func (h *LearningMessageHandler) saveToSubscription(userJID, converterName string, links []string) {
+	if len(links) == 0 {
+		return
+	}
+	if _, err := h.xrayConverterService.AddToSubscription(subscriptionOwner(userJID), converterName, links); err != nil {
+		h.logger.Warningf("Failed to save links to subscription: %v", err)
+	}
+}
+
+// subscriptionOwner normalisasi JID user (tanpa device) sebagai pemilik subscription
+func subscriptionOwner(userJID string) string {
+	jid, err := types.ParseJID(userJID)
+	if err != nil {
+		return userJID
+	}
+	return jid.ToNonAD().String()
+}
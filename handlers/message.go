@@ -332,9 +332,9 @@ func (h *MessageHandler) handleAutoPromoteCommand(evt *events.Message, messageTe
 	// Cek apakah ini admin command
 	adminCommands := []string{
 		// Group Management Commands
-		".listgroups", ".enablegroup", ".enablemulti", ".disablegroup", ".groupstatus", ".testgroup",
+		".listgroups", ".enablegroup", ".enablemulti", ".disablegroup", ".groupstatus", ".testgroup", ".setschedule", ".setrotation", ".settemplates",
 		// Template Management Commands
-		".addtemplate", ".edittemplate", ".deletetemplate", ".templatestats", ".promotestats", ".activegroups", ".fetchproducts", ".productstats", ".deleteall", ".deletemulti"}
+		".addtemplate", ".edittemplate", ".deletetemplate", ".templatestats", ".promotestats", ".activegroups", ".fetchproducts", ".productstats", ".deleteall", ".deletemulti", ".setweight", ".setmedia"}
 	for _, cmd := range adminCommands {
 		if strings.HasPrefix(lowerText, cmd) {
 			if h.adminCommandHandler != nil {
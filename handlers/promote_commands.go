@@ -415,6 +415,10 @@ func (h *PromoteCommandHandler) HandleListTemplatesCommand(evt *events.Message)
 
 		result.WriteString(fmt.Sprintf("🆔 *ID: %d* - %s\n", template.ID, template.Title))
 		result.WriteString(fmt.Sprintf("📂 *Kategori:* %s\n", template.Category))
+		result.WriteString(fmt.Sprintf("⚖️ *Bobot:* %d\n", template.Weight))
+		if template.MediaPath != "" {
+			result.WriteString(fmt.Sprintf("📎 *Media:* %s\n", services.DescribeTemplateMedia(&template)))
+		}
 		result.WriteString(fmt.Sprintf("📅 *Dibuat:* %s\n", template.CreatedAt.Format("2006-01-02")))
 		result.WriteString(fmt.Sprintf("✅ *Status:* %s\n", getTemplateStatusText(template.IsActive)))
 
@@ -583,6 +587,10 @@ func (h *PromoteCommandHandler) HandleAllTemplatesCommand(evt *events.Message) s
 
 		result.WriteString(fmt.Sprintf("%s *ID: %d* - %s\n", statusIcon, template.ID, template.Title))
 		result.WriteString(fmt.Sprintf("📂 *Kategori:* %s\n", template.Category))
+		result.WriteString(fmt.Sprintf("⚖️ *Bobot:* %d\n", template.Weight))
+		if template.MediaPath != "" {
+			result.WriteString(fmt.Sprintf("📎 *Media:* %s\n", services.DescribeTemplateMedia(&template)))
+		}
 		result.WriteString(fmt.Sprintf("📅 *Dibuat:* %s\n", template.CreatedAt.Format("2006-01-02")))
 		result.WriteString(fmt.Sprintf("✅ *Status:* %s\n", getTemplateStatusText(template.IsActive)))
 
@@ -637,6 +645,18 @@ func (h *PromoteCommandHandler) HandleHelpCommand(evt *events.Message) string {
   _Kirim promosi ke grup_
   Contoh: .testgroup 3
 
+• *.setschedule* [ID] [opsi]
+  _Atur jadwal promosi grup_
+  Contoh: .setschedule 3 window=08:00-21:00 quiet=sun
+
+• *.setrotation* [ID] [strategi]
+  _Atur rotasi template grup_
+  Contoh: .setrotation 3 sequence produk,testimoni,diskon
+
+• *.settemplates* [ID] [kategori/#ID]
+  _Batasi template yang dikirim ke grup_
+  Contoh: .settemplates 3 vpn,ssh,#12
+
 ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
 
 📝 *TEMPLATE MANAGEMENT*
@@ -673,6 +693,12 @@ func (h *PromoteCommandHandler) HandleHelpCommand(evt *events.Message) string {
 • *.deletemulti* [ID1,ID2,ID3]
   _Hapus multiple template_
 
+• *.setweight* [ID] [bobot]
+  _Bobot template untuk rotasi weighted (1-100)_
+
+• *.setmedia* [ID] [path|off]
+  _Lampiran gambar/video/dokumen, konten jadi caption_
+
 • *.templatestats*
   _Statistik template_
 
@@ -750,7 +776,8 @@ Sistem otomatis untuk mengirim promosi bisnis sesuai interval yang dikonfigurasi
 • 10+ template promosi bisnis siap pakai
 • Random selection untuk variasi
 • Admin bisa tambah/edit template
-• Support variables: {DATE}, {TIME}, dll
+• Support variables: {DATE}, {TIME}, {{GROUP_NAME}}, {{MEMBER_COUNT}}, dll
+• Kondisi {{#if}}, loop {{#each PRODUCTS}} dan spintax {Halo|Hai}
 
 ❓ **Butuh bantuan?**
 Hubungi admin atau gunakan command di atas`
@@ -768,6 +795,9 @@ func (h *PromoteCommandHandler) IsPromoteCommand(messageText string) bool {
 		".disablegroup",
 		".groupstatus",
 		".testgroup",
+		".setschedule",
+		".setrotation",
+		".settemplates",
 		// Template Commands
 		".listtemplates",
 		".alltemplates",
@@ -783,6 +813,8 @@ func (h *PromoteCommandHandler) IsPromoteCommand(messageText string) bool {
 		".productstats",
 		".deleteall",
 		".deletemulti",
+		".setweight",
+		".setmedia",
 		".help",
 	}
 
@@ -5,6 +5,8 @@ import (
 	"math/rand"
 	"strings"
 	"time"
+
+	"github.com/nabilulilalbab/promote/utils"
 )
 
 type MessageTemplate interface {
@@ -15,8 +17,14 @@ type SimpleTemplate struct {
 	Format string
 }
 
+// Render memakai template engine bersama (utils.ParseTemplate) sehingga layout juga mendukung
+// kondisi, loop, dan spintax. Format yang sintaksnya rusak tetap dirender dengan replace biasa.
 func (t SimpleTemplate) Render(data map[string]string) string {
-	msg := t.Format
+	msg, err := utils.RenderTemplate(t.Format, utils.TemplateData{Vars: data})
+	if err == nil {
+		return msg
+	}
+
 	for k, v := range data {
 		placeholder := fmt.Sprintf("{{%s}}", k)
 		msg = strings.ReplaceAll(msg, placeholder, v)
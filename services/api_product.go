@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nabilulilalbab/promote/utils"
@@ -17,8 +19,16 @@ type APIProductService struct {
 	templateService *TemplateService
 	logger          *utils.Logger
 	apiBaseURL      string
+
+	// Cache produk untuk loop {{#each PRODUCTS}} di template promosi
+	productMutex   sync.Mutex
+	productCache   []map[string]string
+	productCacheAt time.Time
 }
 
+// productCacheTTL lama cache produk untuk template sebelum diambil ulang dari API
+const productCacheTTL = 15 * time.Minute
+
 // ProductResponse struktur response dari API sesuai dokumentasi
 type ProductResponse struct {
 	StatusCode int       `json:"statusCode"`
@@ -378,6 +388,47 @@ func (s *APIProductService) formatPrice(price float64) string {
 	}
 }
 
+// PromoteProducts data produk untuk template promosi ({{#each PRODUCTS}}), di-cache productCacheTTL.
+// Jika API gagal, cache lama tetap dipakai.
+func (s *APIProductService) PromoteProducts() ([]map[string]string, error) {
+	s.productMutex.Lock()
+	defer s.productMutex.Unlock()
+
+	if s.productCache != nil && time.Since(s.productCacheAt) < productCacheTTL {
+		return s.productCache, nil
+	}
+
+	products, err := s.fetchProductsFromAPI()
+	if err != nil {
+		if s.productCache != nil {
+			s.logger.Warningf("Failed to refresh products, using cached data: %v", err)
+			return s.productCache, nil
+		}
+		return nil, err
+	}
+
+	rows := make([]map[string]string, 0, len(products))
+	for _, product := range products {
+		if product.PackageNameShort == "" || product.PackageHarga == "" {
+			continue // Skip produk dengan data kosong
+		}
+		rows = append(rows, map[string]string{
+			"NAME":        product.PackageName,
+			"SHORT_NAME":  product.PackageNameShort,
+			"CODE":        product.PackageCode,
+			"PRICE":       product.PackageHarga,
+			"PRICE_INT":   strconv.Itoa(product.PackageHargaInt),
+			"DESCRIPTION": product.PackageDescription,
+			"DAILY_LIMIT": strconv.FormatBool(product.HaveDailyLimit),
+			"NO_LOGIN":    strconv.FormatBool(product.NoNeedLogin),
+		})
+	}
+
+	s.productCache = rows
+	s.productCacheAt = time.Now()
+	return rows, nil
+}
+
 // UpdateAPIBaseURL mengupdate URL API
 func (s *APIProductService) UpdateAPIBaseURL(newURL string) {
 	s.apiBaseURL = newURL
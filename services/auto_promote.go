@@ -24,8 +24,16 @@ type AutoPromoteService struct {
 	scheduler  *SchedulerService
 	isRunning  bool
 	interval   time.Duration // Interval auto promote dalam durasi
+	products   ProductSource // Sumber data {{#each PRODUCTS}} (opsional)
 }
 
+const (
+	// schedulerMaxWait batas tidur scheduler agar grup/jadwal yang diubah dari luar tetap terbaca
+	schedulerMaxWait = 5 * time.Minute
+	// promoteRetryDelay jeda sebelum mencoba lagi grup yang gagal dikirimi promosi
+	promoteRetryDelay = 15 * time.Minute
+)
+
 // NewAutoPromoteService membuat service baru
 func NewAutoPromoteService(client *whatsmeow.Client, repo database.Repository, logger *utils.Logger) *AutoPromoteService {
 	// Inisialisasi random seed sekali saja
@@ -51,6 +59,11 @@ func (s *AutoPromoteService) SetInterval(hours int) {
 	s.logger.Infof("Auto promote interval set to %d hours", hours)
 }
 
+// SetProductSource mengatur sumber data produk untuk loop {{#each PRODUCTS}} di template
+func (s *AutoPromoteService) SetProductSource(products ProductSource) {
+	s.products = products
+}
+
 // StartAutoPromote mengaktifkan auto promote untuk grup tertentu
 func (s *AutoPromoteService) StartAutoPromote(groupJID string) error {
 	s.logger.Infof("Starting auto promote for group: %s", groupJID)
@@ -78,15 +91,18 @@ func (s *AutoPromoteService) StartAutoPromote(groupJID string) error {
 	now := time.Now()
 	group.IsActive = true
 	group.StartedAt = &now
+	group.NextPromoteAt = nil // hitung ulang dari jadwal grup
 	
 	err = s.repository.UpdateAutoPromoteGroup(group)
 	if err != nil {
 		return fmt.Errorf("failed to update group: %v", err)
 	}
 	
-	// Start scheduler jika belum berjalan
+	// Start scheduler jika belum berjalan, atau minta hitung ulang jadwal
 	if !s.isRunning {
 		s.StartScheduler()
+	} else {
+		s.scheduler.Wake()
 	}
 	
 	s.logger.Successf("Auto promote activated for group: %s", groupJID)
@@ -114,6 +130,7 @@ func (s *AutoPromoteService) StopAutoPromote(groupJID string) error {
 	// Nonaktifkan auto promote
 	group.IsActive = false
 	group.StartedAt = nil
+	group.NextPromoteAt = nil
 	
 	err = s.repository.UpdateAutoPromoteGroup(group)
 	if err != nil {
@@ -136,10 +153,33 @@ func (s *AutoPromoteService) StartScheduler() {
 	}
 	
 	s.logger.Info("Starting auto promote scheduler...")
-	s.logger.Infof("Scheduler will run every %v", s.interval)
-	s.scheduler.Start(s.interval)
+	s.logger.Infof("Default interval %v (grup dengan jadwal sendiri memakai cron/window masing-masing)", s.interval)
+	s.scheduler.StartDynamic(s.nextScheduledRun, schedulerMaxWait)
 	s.isRunning = true
-	s.logger.Successf("Auto promote scheduler started with %v interval!", s.interval)
+	s.logger.Success("Auto promote scheduler started!")
+}
+
+// nextScheduledRun waktu jatuh tempo paling awal di antara grup aktif (zero jika tidak ada)
+func (s *AutoPromoteService) nextScheduledRun(now time.Time) time.Time {
+	groups, err := s.repository.GetActiveGroups()
+	if err != nil {
+		s.logger.Errorf("Failed to get active groups for scheduling: %v", err)
+		return time.Time{}
+	}
+	
+	var earliest time.Time
+	for i := range groups {
+		schedule, err := NewPromoteSchedule(&groups[i], s.interval)
+		if err != nil {
+			continue // dilaporkan saat processScheduledPromotes
+		}
+		due := schedule.Due(&groups[i], now)
+		if !due.IsZero() && (earliest.IsZero() || due.Before(earliest)) {
+			earliest = due
+		}
+	}
+	
+	return earliest
 }
 
 // StopScheduler menghentikan scheduler
@@ -162,7 +202,7 @@ func (s *AutoPromoteService) processScheduledPromotes() {
 		}
 	}()
 
-	s.logger.Info("Processing scheduled promotes...")
+	s.logger.Debug("Processing scheduled promotes...")
 	
 	// Ambil semua grup yang aktif dengan retry mechanism
 	activeGroups, err := s.getActiveGroupsWithRetry(3)
@@ -172,11 +212,40 @@ func (s *AutoPromoteService) processScheduledPromotes() {
 	}
 	
 	if len(activeGroups) == 0 {
-		s.logger.Info("No active groups for auto promote")
+		s.logger.Debug("No active groups for auto promote")
 		return
 	}
 	
-	s.logger.Infof("Found %d active groups", len(activeGroups))
+	s.logger.Debugf("Found %d active groups", len(activeGroups))
+	
+	// Pilih grup yang sudah jatuh tempo sesuai jadwal masing-masing
+	now := time.Now()
+	var dueGroups []database.AutoPromoteGroup
+	var schedules []*PromoteSchedule
+	skippedCount := 0
+	
+	for _, group := range activeGroups {
+		schedule, err := NewPromoteSchedule(&group, s.interval)
+		if err != nil {
+			s.logger.Errorf("Invalid schedule for group %s: %v", group.GroupJID, err)
+			skippedCount++
+			continue
+		}
+		
+		if due := schedule.Due(&group, now); due.IsZero() || due.After(now) {
+			skippedCount++
+			s.logger.Debugf("Skipping group %s (next: %v)", group.GroupJID, due)
+			continue
+		}
+		
+		dueGroups = append(dueGroups, group)
+		schedules = append(schedules, schedule)
+	}
+	
+	if len(dueGroups) == 0 {
+		s.logger.Debugf("No groups due for promote (%d skipped)", skippedCount)
+		return
+	}
 	
 	// Ambil template aktif dengan retry mechanism
 	templates, err := s.getActiveTemplatesWithRetry(3)
@@ -186,7 +255,10 @@ func (s *AutoPromoteService) processScheduledPromotes() {
 	}
 	
 	if len(templates) == 0 {
-		s.logger.Warning("No active templates available")
+		s.logger.Warning("No active templates available, postponing due groups")
+		for i := range dueGroups {
+			s.scheduleNextPromote(&dueGroups[i], schedules[i].NextAllowed(now.Add(promoteRetryDelay)))
+		}
 		return
 	}
 	
@@ -195,31 +267,41 @@ func (s *AutoPromoteService) processScheduledPromotes() {
 	// Proses setiap grup dengan error handling individual
 	successCount := 0
 	failCount := 0
-	skippedCount := 0
 	
-	for _, group := range activeGroups {
-		// Cek apakah sudah waktunya untuk promote (4 jam sejak terakhir)
-		if s.shouldSkipGroup(&group) {
+	for i := range dueGroups {
+		group := &dueGroups[i]
+		
+		// Batasi template sesuai target kategori/template grup
+		targets, err := s.repository.GetGroupTemplateTargets(group.GroupJID)
+		if err != nil {
+			s.logger.Errorf("Failed to get template targets for group %s: %v", group.GroupJID, err)
+			failCount++
+			s.scheduleNextPromote(group, schedules[i].NextAllowed(now.Add(promoteRetryDelay)))
+			continue
+		}
+		groupTemplates := FilterTemplatesForGroup(targets, templates)
+		if len(groupTemplates) == 0 {
+			s.logger.Warningf("No active templates match targets of group %s (%s), postponing", group.GroupJID, DescribeTemplateTargets(targets))
 			skippedCount++
-			s.logger.Debugf("Skipping group %s (not yet time)", group.GroupJID)
+			s.scheduleNextPromote(group, schedules[i].NextAllowed(now.Add(promoteRetryDelay)))
 			continue
 		}
 		
+		// Pilih template sesuai strategi rotasi grup
+		selection := s.selectTemplateForGroup(group, groupTemplates)
+		
 		// Kirim promosi dengan retry mechanism
-		err := s.sendPromoteToGroupWithRetry(group.GroupJID, templates, 2)
+		err = s.sendTemplateToGroupWithRetry(group.GroupJID, selection.Template, 2)
+		sentAt := time.Now()
 		if err != nil {
 			s.logger.Errorf("Failed to send promote to group %s after retries: %v", group.GroupJID, err)
 			failCount++
+			s.scheduleNextPromote(group, schedules[i].NextAllowed(sentAt.Add(promoteRetryDelay)))
 		} else {
 			successCount++
-			
-			// Update last promote time dengan error handling
-			now := time.Now()
-			group.LastPromoteAt = &now
-			updateErr := s.repository.UpdateAutoPromoteGroup(&group)
-			if updateErr != nil {
-				s.logger.Errorf("Failed to update group %s last promote time: %v", group.GroupJID, updateErr)
-			}
+			group.LastPromoteAt = &sentAt
+			group.RotationCursor = selection.Cursor
+			s.scheduleNextPromote(group, schedules[i].Next(sentAt))
 		}
 	}
 	
@@ -233,23 +315,172 @@ func (s *AutoPromoteService) processScheduledPromotes() {
 	}
 }
 
-// shouldSkipGroup mengecek apakah grup harus dilewati
-func (s *AutoPromoteService) shouldSkipGroup(group *database.AutoPromoteGroup) bool {
-	// Jika belum pernah kirim promosi, kirim sekarang
-	if group.LastPromoteAt == nil {
-		return false
+// scheduleNextPromote menyimpan waktu kirim berikutnya grup (zero = hitung ulang dari jadwal)
+func (s *AutoPromoteService) scheduleNextPromote(group *database.AutoPromoteGroup, next time.Time) {
+	group.NextPromoteAt = nil
+	if !next.IsZero() {
+		group.NextPromoteAt = &next
 	}
 	
-	// Cek apakah sudah mencapai interval yang ditentukan sejak promosi terakhir
-	intervalAgo := time.Now().Add(-s.interval)
-	return group.LastPromoteAt.After(intervalAgo)
+	if err := s.repository.UpdateAutoPromoteGroup(group); err != nil {
+		s.logger.Errorf("Failed to update group %s schedule: %v", group.GroupJID, err)
+	}
 }
 
-// sendPromoteToGroup mengirim promosi ke grup tertentu
+// SetGroupSchedule mengubah jadwal grup. Opsi: cron, window (08:00-21:00), quiet (sat,sun), tz.
+// Nilai "off" atau kosong menghapus opsi tersebut (kembali ke default).
+func (s *AutoPromoteService) SetGroupSchedule(groupJID string, options map[string]string) (*database.AutoPromoteGroup, *PromoteSchedule, error) {
+	group, err := s.repository.GetAutoPromoteGroup(groupJID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get group: %v", err)
+	}
+	if group == nil {
+		if group, err = s.repository.CreateAutoPromoteGroup(groupJID); err != nil {
+			return nil, nil, fmt.Errorf("failed to create group: %v", err)
+		}
+	}
+	
+	for key, value := range options {
+		value = strings.TrimSpace(value)
+		if strings.EqualFold(value, "off") {
+			value = ""
+		}
+		
+		switch strings.ToLower(key) {
+		case "cron":
+			group.ScheduleCron = value
+		case "window":
+			group.WindowStart, group.WindowEnd = "", ""
+			if value != "" {
+				bounds := strings.SplitN(value, "-", 2)
+				if len(bounds) != 2 {
+					return nil, nil, fmt.Errorf("format window harus HH:MM-HH:MM: %s", value)
+				}
+				group.WindowStart, group.WindowEnd = strings.TrimSpace(bounds[0]), strings.TrimSpace(bounds[1])
+			}
+		case "quiet":
+			days, err := ParseQuietDays(value)
+			if err != nil {
+				return nil, nil, err
+			}
+			group.QuietDays = FormatQuietDays(days)
+		case "tz", "timezone":
+			if alias, ok := timezoneAliases[strings.ToUpper(value)]; ok {
+				value = alias
+			}
+			group.Timezone = value
+		default:
+			return nil, nil, fmt.Errorf("opsi jadwal tidak dikenal: %s", key)
+		}
+	}
+	
+	schedule, err := NewPromoteSchedule(group, s.interval)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	group.NextPromoteAt = nil // hitung ulang dengan jadwal baru
+	if err := s.repository.UpdateAutoPromoteGroup(group); err != nil {
+		return nil, nil, fmt.Errorf("failed to update group: %v", err)
+	}
+	
+	s.scheduler.Wake()
+	s.logger.Infof("Schedule updated for group %s: %s", groupJID, schedule.Describe())
+	return group, schedule, nil
+}
+
+// GroupSchedule jadwal efektif grup beserta waktu kirim berikutnya (zero jika tidak ada)
+func (s *AutoPromoteService) GroupSchedule(group *database.AutoPromoteGroup) (*PromoteSchedule, time.Time, error) {
+	schedule, err := NewPromoteSchedule(group, s.interval)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return schedule, schedule.Due(group, time.Now()), nil
+}
+
+// SetGroupRotation mengubah strategi rotasi template grup. sequence hanya dipakai strategi sequence.
+func (s *AutoPromoteService) SetGroupRotation(groupJID, strategy, sequence string) (*database.AutoPromoteGroup, error) {
+	strategy, err := NormalizeRotationStrategy(strategy)
+	if err != nil {
+		return nil, err
+	}
+	
+	categories := parseCategorySequence(sequence)
+	if strategy == RotationSequence && len(categories) == 0 {
+		return nil, fmt.Errorf("strategi sequence butuh urutan kategori, misal produk,testimoni,diskon")
+	}
+	if strategy != RotationSequence {
+		categories = nil
+	}
+	
+	group, err := s.repository.GetAutoPromoteGroup(groupJID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %v", err)
+	}
+	if group == nil {
+		if group, err = s.repository.CreateAutoPromoteGroup(groupJID); err != nil {
+			return nil, fmt.Errorf("failed to create group: %v", err)
+		}
+	}
+	
+	group.RotationStrategy = strategy
+	group.CategorySequence = strings.Join(categories, ",")
+	group.RotationCursor = 0 // mulai dari awal urutan
+	if err := s.repository.UpdateAutoPromoteGroup(group); err != nil {
+		return nil, fmt.Errorf("failed to update group: %v", err)
+	}
+	
+	s.logger.Infof("Rotation updated for group %s: %s", groupJID, DescribeRotation(group))
+	return group, nil
+}
+
+// selectTemplateForGroup memilih template sesuai strategi rotasi grup (riwayat kirim dari promote_logs)
+func (s *AutoPromoteService) selectTemplateForGroup(group *database.AutoPromoteGroup, templates []database.PromoteTemplate) templateSelection {
+	lastSent, err := s.repository.GetLastSentByTemplate(group.GroupJID)
+	if err != nil {
+		s.logger.Warningf("Failed to get send history for group %s: %v", group.GroupJID, err)
+	}
+	return selectTemplateForGroup(group, templates, lastSent)
+}
+
+// sendPromoteToGroup mengirim promosi ke grup tertentu dengan strategi rotasi grup, lalu
+// menyimpan cursor rotasi agar kiriman terjadwal berikutnya melanjutkan urutan yang sama
 func (s *AutoPromoteService) sendPromoteToGroup(groupJID string, templates []database.PromoteTemplate) error {
-	// Pilih template secara random
-	template := s.selectRandomTemplate(templates)
+	group, err := s.repository.GetAutoPromoteGroup(groupJID)
+	if err != nil {
+		return fmt.Errorf("failed to get group: %v", err)
+	}
 	
+	// Grup yang belum pernah diatur memakai rotasi default (acak) tanpa menyimpan cursor
+	stored := group != nil
+	if !stored {
+		group = &database.AutoPromoteGroup{GroupJID: groupJID}
+	}
+	
+	selection := s.selectTemplateForGroup(group, templates)
+	if err := s.sendTemplateToGroup(groupJID, selection.Template); err != nil {
+		return err
+	}
+	
+	if stored {
+		s.saveRotationCursor(group, selection)
+	}
+	return nil
+}
+
+// saveRotationCursor menyimpan cursor rotasi grup setelah template terpilih berhasil dikirim
+func (s *AutoPromoteService) saveRotationCursor(group *database.AutoPromoteGroup, selection templateSelection) {
+	if selection.Cursor == group.RotationCursor {
+		return
+	}
+	group.RotationCursor = selection.Cursor
+	if err := s.repository.UpdateAutoPromoteGroup(group); err != nil {
+		s.logger.Errorf("Failed to save rotation cursor for group %s: %v", group.GroupJID, err)
+	}
+}
+
+// sendTemplateToGroup mengirim satu template ke grup dan mencatat log
+func (s *AutoPromoteService) sendTemplateToGroup(groupJID string, template database.PromoteTemplate) error {
 	// Parse JID grup
 	jid, err := types.ParseJID(groupJID)
 	if err != nil {
@@ -259,8 +490,8 @@ func (s *AutoPromoteService) sendPromoteToGroup(groupJID string, templates []dat
 	// Proses template (replace variables)
 	content := s.processTemplate(template.Content, jid)
 	
-	// Kirim pesan
-	err = s.sendMessage(jid, content)
+	// Kirim pesan (dengan lampiran media jika ada)
+	err = s.sendTemplateMessage(jid, template, content)
 	
 	// Log hasil
 	log := &database.PromoteLog{
@@ -281,38 +512,9 @@ func (s *AutoPromoteService) sendPromoteToGroup(groupJID string, templates []dat
 	return err
 }
 
-// selectRandomTemplate memilih template secara random
-func (s *AutoPromoteService) selectRandomTemplate(templates []database.PromoteTemplate) database.PromoteTemplate {
-	if len(templates) == 0 {
-		// Return empty template jika tidak ada
-		return database.PromoteTemplate{}
-	}
-	
-	// Pilih index random
-	index := rand.Intn(len(templates))
-	return templates[index]
-}
-
-// processTemplate memproses template dengan mengganti variables
+// processTemplate merender template (variabel, kondisi, loop produk, spintax) untuk grup tujuan
 func (s *AutoPromoteService) processTemplate(content string, groupJID types.JID) string {
-	now := time.Now()
-	
-	// Replace variables yang tersedia
-	replacements := map[string]string{
-		"{DATE}":     now.Format("2006-01-02"),
-		"{TIME}":     now.Format("15:04"),
-		"{DAY}":      getDayName(now.Weekday()),
-		"{MONTH}":    getMonthName(now.Month()),
-		"{YEAR}":     fmt.Sprintf("%d", now.Year()),
-		"{GROUP_ID}": groupJID.User,
-	}
-	
-	result := content
-	for placeholder, value := range replacements {
-		result = strings.ReplaceAll(result, placeholder, value)
-	}
-	
-	return result
+	return renderPromoteTemplate(s.client, s.products, s.logger, content, groupJID)
 }
 
 // sendMessage mengirim pesan ke grup
@@ -332,16 +534,30 @@ func (s *AutoPromoteService) sendMessage(groupJID types.JID, content string) err
 	return nil
 }
 
+// sendTemplateMessage mengirim konten template, sebagai caption media jika template punya lampiran
+func (s *AutoPromoteService) sendTemplateMessage(groupJID types.JID, template database.PromoteTemplate, content string) error {
+	if !hasPromoteMedia(template, s.logger) {
+		return s.sendMessage(groupJID, content)
+	}
+	
+	if err := sendPromoteMedia(s.client, groupJID, template, content); err != nil {
+		return err
+	}
+	
+	s.logger.Infof("Promote %s sent to group: %s", template.MediaType, groupJID.String())
+	return nil
+}
+
 // SendManualPromote mengirim promosi manual (untuk testing)
 func (s *AutoPromoteService) SendManualPromote(groupJID string) error {
-	// Ambil template aktif
-	templates, err := s.repository.GetActiveTemplates()
+	// Ambil template aktif sesuai target grup
+	templates, err := s.GetTemplatesForGroup(groupJID)
 	if err != nil {
-		return fmt.Errorf("failed to get templates: %v", err)
+		return err
 	}
 	
 	if len(templates) == 0 {
-		return fmt.Errorf("no active templates available")
+		return fmt.Errorf("no active templates available for this group")
 	}
 	
 	// Kirim promosi
@@ -404,12 +620,12 @@ func (s *AutoPromoteService) getActiveTemplatesWithRetry(maxRetries int) ([]data
 	return nil, lastErr
 }
 
-// sendPromoteToGroupWithRetry mengirim promosi dengan retry mechanism
-func (s *AutoPromoteService) sendPromoteToGroupWithRetry(groupJID string, templates []database.PromoteTemplate, maxRetries int) error {
+// sendTemplateToGroupWithRetry mengirim template yang sama dengan retry mechanism
+func (s *AutoPromoteService) sendTemplateToGroupWithRetry(groupJID string, template database.PromoteTemplate, maxRetries int) error {
 	var lastErr error
 	
 	for i := 0; i < maxRetries; i++ {
-		err := s.sendPromoteToGroup(groupJID, templates)
+		err := s.sendTemplateToGroup(groupJID, template)
 		if err == nil {
 			return nil
 		}
@@ -5,7 +5,6 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
-	"strings"
 	"time"
 
 	"go.mau.fi/whatsmeow"
@@ -31,6 +30,7 @@ type GroupManagerService struct {
 	client     *whatsmeow.Client
 	repository database.Repository
 	logger     *utils.Logger
+	products   ProductSource // Sumber data {{#each PRODUCTS}} (opsional)
 }
 
 // NewGroupManagerService membuat service baru
@@ -138,6 +138,7 @@ func (s *GroupManagerService) EnableAutoPromoteForGroup(groupID int) error {
 	dbGroup.IsActive = true
 	now := time.Now()
 	dbGroup.StartedAt = &now
+	dbGroup.NextPromoteAt = nil // hitung ulang dari jadwal grup
 
 	err = s.repository.UpdateAutoPromoteGroup(dbGroup)
 	if err != nil {
@@ -175,6 +176,7 @@ func (s *GroupManagerService) DisableAutoPromoteForGroup(groupID int) error {
 	// Nonaktifkan auto promote
 	dbGroup.IsActive = false
 	dbGroup.StartedAt = nil
+	dbGroup.NextPromoteAt = nil
 
 	err = s.repository.UpdateAutoPromoteGroup(dbGroup)
 	if err != nil {
@@ -212,14 +214,20 @@ func (s *GroupManagerService) SendTestPromoteToGroup(groupID int) error {
 
 	s.logger.Infof("Sending test promote to group: %s (%s)", groupInfo.Name, groupInfo.JID)
 
-	// Ambil template aktif
+	// Ambil template aktif sesuai target kategori/template grup
 	templates, err := s.repository.GetActiveTemplates()
 	if err != nil {
 		return fmt.Errorf("failed to get templates: %v", err)
 	}
 
+	targets, err := s.repository.GetGroupTemplateTargets(groupInfo.JID)
+	if err != nil {
+		return fmt.Errorf("failed to get group targets: %v", err)
+	}
+	templates = FilterTemplatesForGroup(targets, templates)
+
 	if len(templates) == 0 {
-		return fmt.Errorf("no active templates available")
+		return fmt.Errorf("no active templates available for this group")
 	}
 
 	// Pilih template secara random untuk test (sama seperti auto promote)
@@ -234,8 +242,12 @@ func (s *GroupManagerService) SendTestPromoteToGroup(groupID int) error {
 	// Proses template (replace variables)
 	content := s.processTemplate(template.Content, jid)
 
-	// Kirim pesan promosi natural (tanpa embel-embel test)
-	err = s.sendMessage(jid, content)
+	// Kirim pesan promosi natural (tanpa embel-embel test), termasuk lampiran media
+	if hasPromoteMedia(template, s.logger) {
+		err = sendPromoteMedia(s.client, jid, template, content)
+	} else {
+		err = s.sendMessage(jid, content)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to send test message: %v", err)
 	}
@@ -244,44 +256,14 @@ func (s *GroupManagerService) SendTestPromoteToGroup(groupID int) error {
 	return nil
 }
 
-// processTemplate memproses template dengan mengganti variables
+// processTemplate merender template (variabel, kondisi, loop produk, spintax) untuk grup tujuan
 func (s *GroupManagerService) processTemplate(content string, groupJID types.JID) string {
-	now := time.Now()
-
-	// Replace variables yang tersedia
-	replacements := map[string]string{
-		"{DATE}":     now.Format("2006-01-02"),
-		"{TIME}":     now.Format("15:04"),
-		"{DAY}":      s.getDayName(now.Weekday()),
-		"{MONTH}":    s.getMonthName(now.Month()),
-		"{YEAR}":     fmt.Sprintf("%d", now.Year()),
-		"{GROUP_ID}": groupJID.User,
-	}
-
-	result := content
-	for placeholder, value := range replacements {
-		result = strings.ReplaceAll(result, placeholder, value)
-	}
-
-	return result
+	return renderPromoteTemplate(s.client, s.products, s.logger, content, groupJID)
 }
 
-// getDayName helper function
-func (s *GroupManagerService) getDayName(day time.Weekday) string {
-	days := []string{
-		"Minggu", "Senin", "Selasa", "Rabu",
-		"Kamis", "Jumat", "Sabtu",
-	}
-	return days[day]
-}
-
-// getMonthName helper function
-func (s *GroupManagerService) getMonthName(month time.Month) string {
-	months := []string{
-		"", "Januari", "Februari", "Maret", "April", "Mei", "Juni",
-		"Juli", "Agustus", "September", "Oktober", "November", "Desember",
-	}
-	return months[month]
+// SetProductSource mengatur sumber data produk untuk loop {{#each PRODUCTS}}
+func (s *GroupManagerService) SetProductSource(products ProductSource) {
+	s.products = products
 }
 
 // sendMessage mengirim pesan ke grup
@@ -0,0 +1,262 @@
+// Package services - lampiran media (gambar, video, dokumen) untuk template auto promote
+package services
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/nabilulilalbab/promote/database"
+	"github.com/nabilulilalbab/promote/utils"
+)
+
+// Jenis media template promosi
+const (
+	PromoteMediaImage    = "image"
+	PromoteMediaVideo    = "video"
+	PromoteMediaDocument = "document"
+)
+
+// Batas ukuran media yang diterima WhatsApp
+const (
+	maxPromoteImageSize    = 16 << 20
+	maxPromoteVideoSize    = 64 << 20
+	maxPromoteDocumentSize = 100 << 20
+)
+
+// promoteMediaKinds MIME yang bisa dikirim sebagai gambar/video; selain itu dikirim sebagai dokumen
+var promoteMediaKinds = map[string]string{
+	"image/jpeg": PromoteMediaImage,
+	"image/png":  PromoteMediaImage,
+	"video/mp4":  PromoteMediaVideo,
+	"video/3gpp": PromoteMediaVideo,
+}
+
+// uploadTimestampPrefix prefix "20060102_150405_" yang ditambahkan /api/upload pada nama file
+var uploadTimestampPrefix = regexp.MustCompile(`^\d{8}_\d{6}_`)
+
+// PromoteMedia hasil deteksi lampiran template
+type PromoteMedia struct {
+	Path     string
+	Type     string // image, video, document
+	MimeType string
+	Size     int64
+}
+
+// DefaultPromoteMediaDir folder upload dashboard; lampiran template hanya boleh diambil dari sini
+const DefaultPromoteMediaDir = "media"
+
+// ResolvePromoteMediaPath memastikan path media berada di dalam mediaDir setelah path dan
+// symlink di-resolve, lalu mengembalikan path-nya relatif ke mediaDir (misal media/images/x.jpg).
+// Mencegah template melampirkan file lain di server (/etc/passwd, .env, database sesi).
+func ResolvePromoteMediaPath(mediaDir, path string) (string, error) {
+	root, err := filepath.Abs(mediaDir)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return "", fmt.Errorf("folder media %s tidak tersedia: %v", mediaDir, err)
+	}
+
+	target, err := filepath.Abs(path)
+	if err == nil {
+		target, err = filepath.EvalSymlinks(target)
+	}
+	if err != nil {
+		return "", fmt.Errorf("file media tidak bisa dibuka: %s", path)
+	}
+
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", fmt.Errorf("file media harus berada di folder upload %s/", filepath.ToSlash(mediaDir))
+	}
+	return filepath.Join(mediaDir, rel), nil
+}
+
+// DetectPromoteMedia membaca header file untuk menentukan MIME (fallback ke ekstensi jika
+// hasil sniffing terlalu umum) dan jenis pesan WhatsApp yang dipakai.
+func DetectPromoteMedia(path string) (*PromoteMedia, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("file media tidak bisa dibuka: %v", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("file media tidak bisa dibaca: %v", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("path media adalah folder: %s", path)
+	}
+	if info.Size() == 0 {
+		return nil, fmt.Errorf("file media kosong: %s", path)
+	}
+
+	header := make([]byte, 512)
+	n, err := file.Read(header)
+	if err != nil {
+		return nil, fmt.Errorf("file media tidak bisa dibaca: %v", err)
+	}
+
+	mimeType := http.DetectContentType(header[:n])
+	if isGenericMimeType(mimeType) {
+		if byExtension := mime.TypeByExtension(strings.ToLower(filepath.Ext(path))); byExtension != "" {
+			mimeType = byExtension
+		}
+	}
+
+	media := &PromoteMedia{
+		Path:     path,
+		Type:     PromoteMediaDocument,
+		MimeType: mimeType,
+		Size:     info.Size(),
+	}
+	baseType, _, _ := mime.ParseMediaType(mimeType)
+	if kind, ok := promoteMediaKinds[baseType]; ok {
+		media.Type = kind
+	}
+
+	if limit := promoteMediaLimit(media.Type); media.Size > limit {
+		return nil, fmt.Errorf("file %s terlalu besar (%s, maksimal %s)", media.Type, formatMediaSize(media.Size), formatMediaSize(limit))
+	}
+	return media, nil
+}
+
+// isGenericMimeType hasil sniffing yang tidak cukup spesifik (docx/xlsx terbaca sebagai zip)
+func isGenericMimeType(mimeType string) bool {
+	return mimeType == "application/octet-stream" ||
+		mimeType == "application/zip" ||
+		strings.HasPrefix(mimeType, "text/plain")
+}
+
+// promoteMediaLimit batas ukuran per jenis media
+func promoteMediaLimit(mediaType string) int64 {
+	switch mediaType {
+	case PromoteMediaImage:
+		return maxPromoteImageSize
+	case PromoteMediaVideo:
+		return maxPromoteVideoSize
+	}
+	return maxPromoteDocumentSize
+}
+
+// formatMediaSize ukuran file dalam MB/KB untuk pesan admin
+func formatMediaSize(size int64) string {
+	if size >= 1<<20 {
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	}
+	return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+}
+
+// DescribeTemplateMedia ringkasan lampiran template untuk daftar template
+func DescribeTemplateMedia(template *database.PromoteTemplate) string {
+	if template.MediaPath == "" {
+		return "Tidak ada (teks saja)"
+	}
+	return fmt.Sprintf("%s - %s", template.MediaType, promoteMediaFileName(template.MediaPath))
+}
+
+// promoteMediaFileName nama file tanpa prefix timestamp upload dashboard
+func promoteMediaFileName(path string) string {
+	return uploadTimestampPrefix.ReplaceAllString(filepath.Base(path), "")
+}
+
+// hasPromoteMedia true jika template punya lampiran yang masih ada di disk. File yang hilang
+// tidak menggagalkan jadwal: promosi tetap dikirim sebagai teks dengan peringatan di log.
+func hasPromoteMedia(template database.PromoteTemplate, logger *utils.Logger) bool {
+	if template.MediaPath == "" {
+		return false
+	}
+	if _, err := os.Stat(template.MediaPath); os.IsNotExist(err) {
+		logger.Warningf("Media template %d not found (%s), sending text only", template.ID, template.MediaPath)
+		return false
+	}
+	return true
+}
+
+// sendPromoteMedia mengunggah lampiran template ke WhatsApp lalu mengirimnya dengan caption = konten.
+// MIME dideteksi ulang saat kirim karena file bisa diganti setelah template disimpan.
+func sendPromoteMedia(client *whatsmeow.Client, jid types.JID, template database.PromoteTemplate, caption string) error {
+	media, err := DetectPromoteMedia(template.MediaPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(media.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read media: %v", err)
+	}
+
+	var msg *waProto.Message
+	switch media.Type {
+	case PromoteMediaImage:
+		uploaded, err := client.Upload(context.Background(), data, whatsmeow.MediaImage)
+		if err != nil {
+			return fmt.Errorf("failed to upload image: %v", err)
+		}
+		msg = &waProto.Message{
+			ImageMessage: &waProto.ImageMessage{
+				Caption:       &caption,
+				URL:           &uploaded.URL,
+				DirectPath:    &uploaded.DirectPath,
+				MediaKey:      uploaded.MediaKey,
+				FileEncSHA256: uploaded.FileEncSHA256,
+				FileSHA256:    uploaded.FileSHA256,
+				FileLength:    &uploaded.FileLength,
+				Mimetype:      &media.MimeType,
+			},
+		}
+	case PromoteMediaVideo:
+		uploaded, err := client.Upload(context.Background(), data, whatsmeow.MediaVideo)
+		if err != nil {
+			return fmt.Errorf("failed to upload video: %v", err)
+		}
+		msg = &waProto.Message{
+			VideoMessage: &waProto.VideoMessage{
+				Caption:       &caption,
+				URL:           &uploaded.URL,
+				DirectPath:    &uploaded.DirectPath,
+				MediaKey:      uploaded.MediaKey,
+				FileEncSHA256: uploaded.FileEncSHA256,
+				FileSHA256:    uploaded.FileSHA256,
+				FileLength:    &uploaded.FileLength,
+				Mimetype:      &media.MimeType,
+			},
+		}
+	default:
+		uploaded, err := client.Upload(context.Background(), data, whatsmeow.MediaDocument)
+		if err != nil {
+			return fmt.Errorf("failed to upload document: %v", err)
+		}
+		fileName := promoteMediaFileName(media.Path)
+		msg = &waProto.Message{
+			DocumentMessage: &waProto.DocumentMessage{
+				Caption:       &caption,
+				FileName:      &fileName,
+				Title:         &fileName,
+				URL:           &uploaded.URL,
+				DirectPath:    &uploaded.DirectPath,
+				MediaKey:      uploaded.MediaKey,
+				FileEncSHA256: uploaded.FileEncSHA256,
+				FileSHA256:    uploaded.FileSHA256,
+				FileLength:    &uploaded.FileLength,
+				Mimetype:      &media.MimeType,
+			},
+		}
+	}
+
+	if _, err := client.SendMessage(context.Background(), jid, msg); err != nil {
+		return fmt.Errorf("failed to send %s: %v", media.Type, err)
+	}
+	return nil
+}
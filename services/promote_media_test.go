@@ -0,0 +1,117 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nabilulilalbab/promote/database"
+	"github.com/nabilulilalbab/promote/utils"
+)
+
+// pngHeader cukup untuk dideteksi sebagai image/png
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// setupMediaWorkdir menyiapkan working directory berisi folder upload media dan file sensitif di luarnya
+func setupMediaWorkdir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	files := map[string][]byte{
+		"media/images/20260101_120000_banner.png": pngHeader,
+		".env":                 []byte("SECRET=1\n"),
+		"session.db":           []byte("SQLite format 3\x00"),
+		"media-backup/old.png": pngHeader,
+		"outside/secret.png":   pngHeader,
+	}
+	for name, data := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "outside", "secret.png"), filepath.Join("media", "images", "link.png")); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestResolvePromoteMediaPath(t *testing.T) {
+	dir := setupMediaWorkdir(t)
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr string
+	}{
+		{path: "media/images/20260101_120000_banner.png", want: "media/images/20260101_120000_banner.png"},
+		{path: "./media/images/../images/20260101_120000_banner.png", want: "media/images/20260101_120000_banner.png"},
+		{path: filepath.Join(dir, "media/images/20260101_120000_banner.png"), want: "media/images/20260101_120000_banner.png"},
+		{path: "/etc/passwd", wantErr: "folder upload"},
+		{path: ".env", wantErr: "folder upload"},
+		{path: "session.db", wantErr: "folder upload"},
+		{path: "media/../.env", wantErr: "folder upload"},
+		{path: "media/images/../../session.db", wantErr: "folder upload"},
+		{path: "media-backup/old.png", wantErr: "folder upload"},
+		{path: "media/images/link.png", wantErr: "folder upload"},
+		{path: "media", wantErr: "folder upload"},
+		{path: "media/images/missing.png", wantErr: "tidak bisa dibuka"},
+	}
+
+	for _, tt := range tests {
+		got, err := ResolvePromoteMediaPath(DefaultPromoteMediaDir, tt.path)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ResolvePromoteMediaPath(%q) = %q, %v; want error containing %q", tt.path, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != filepath.FromSlash(tt.want) {
+			t.Errorf("ResolvePromoteMediaPath(%q) = %q, %v; want %q", tt.path, got, err, tt.want)
+		}
+	}
+}
+
+func TestSetTemplateMediaRestrictsToMediaDir(t *testing.T) {
+	setupMediaWorkdir(t)
+
+	db, repo, err := database.InitializeDatabase("promote.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	service := NewTemplateService(repo, utils.NewLogger("TEST", false))
+	template, err := service.CreateTemplate("Promo", "Halo {GROUP_NAME}", "produk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/etc/passwd", ".env", "promote.db", "media/images/link.png"} {
+		if _, err := service.SetTemplateMedia(template.ID, path); err == nil {
+			t.Errorf("SetTemplateMedia(%q) accepted a file outside the media dir", path)
+		}
+	}
+
+	updated, err := service.SetTemplateMedia(template.ID, "media/images/20260101_120000_banner.png")
+	if err != nil {
+		t.Fatalf("SetTemplateMedia() error = %v", err)
+	}
+	if updated.MediaType != PromoteMediaImage || updated.MediaPath != filepath.FromSlash("media/images/20260101_120000_banner.png") {
+		t.Errorf("SetTemplateMedia() = %s (%s)", updated.MediaPath, updated.MediaType)
+	}
+
+	// Folder upload lain lewat SetMediaDir: file di folder default tidak lagi diterima
+	service.SetMediaDir("uploads")
+	if err := os.MkdirAll("uploads", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.SetTemplateMedia(template.ID, "media/images/20260101_120000_banner.png"); err == nil {
+		t.Error("SetTemplateMedia() accepted a file outside the configured media dir")
+	}
+}
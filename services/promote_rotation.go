@@ -0,0 +1,215 @@
+// Package services - strategi rotasi template auto promote per grup
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// Strategi rotasi template
+const (
+	RotationRandom     = "random"     // acak (default)
+	RotationRoundRobin = "roundrobin" // berurutan berdasarkan ID template
+	RotationWeighted   = "weighted"   // acak berbobot (kolom weight)
+	RotationLRU        = "lru"        // template yang paling lama tidak dikirim ke grup ini
+	RotationSequence   = "sequence"   // bergiliran per kategori, misal produk -> testimoni -> diskon
+)
+
+// rotationLabels label strategi untuk ditampilkan ke admin (urutan = urutan bantuan)
+var rotationLabels = []struct {
+	Strategy string
+	Label    string
+}{
+	{RotationRandom, "Acak"},
+	{RotationRoundRobin, "Round-robin"},
+	{RotationWeighted, "Acak berbobot"},
+	{RotationLRU, "Paling lama tidak dikirim"},
+	{RotationSequence, "Urutan kategori"},
+}
+
+// rotationAliases nama alternatif strategi yang diterima dari chat
+var rotationAliases = map[string]string{
+	"":             RotationRandom,
+	"round-robin":  RotationRoundRobin,
+	"rr":           RotationRoundRobin,
+	"weight":       RotationWeighted,
+	"least-recent": RotationLRU,
+	"seq":          RotationSequence,
+	"category":     RotationSequence,
+}
+
+// NormalizeRotationStrategy menyeragamkan nama strategi rotasi, error jika tidak dikenal
+func NormalizeRotationStrategy(strategy string) (string, error) {
+	strategy = strings.ToLower(strings.TrimSpace(strategy))
+	if alias, ok := rotationAliases[strategy]; ok {
+		strategy = alias
+	}
+	for _, item := range rotationLabels {
+		if item.Strategy == strategy {
+			return strategy, nil
+		}
+	}
+	return "", fmt.Errorf("strategi rotasi tidak dikenal: %s (pilihan: %s)", strategy, strings.Join(RotationStrategies(), ", "))
+}
+
+// RotationStrategies daftar strategi rotasi yang tersedia
+func RotationStrategies() []string {
+	strategies := make([]string, 0, len(rotationLabels))
+	for _, item := range rotationLabels {
+		strategies = append(strategies, item.Strategy)
+	}
+	return strategies
+}
+
+// DescribeRotation ringkasan strategi rotasi grup untuk .groupstatus
+func DescribeRotation(group *database.AutoPromoteGroup) string {
+	strategy, err := NormalizeRotationStrategy(group.RotationStrategy)
+	if err != nil {
+		return fmt.Sprintf("⚠️ %s (tidak dikenal, memakai acak)", group.RotationStrategy)
+	}
+
+	label := strategy
+	for _, item := range rotationLabels {
+		if item.Strategy == strategy {
+			label = item.Label
+		}
+	}
+	if strategy == RotationSequence {
+		return fmt.Sprintf("%s (%s)", label, strings.Join(parseCategorySequence(group.CategorySequence), " → "))
+	}
+	return label
+}
+
+// parseCategorySequence memecah urutan kategori ("produk,testimoni", "produk>testimoni", "produk→testimoni")
+func parseCategorySequence(sequence string) []string {
+	var categories []string
+	for _, part := range strings.FieldsFunc(sequence, func(r rune) bool { return r == ',' || r == '>' || r == '→' }) {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			categories = append(categories, part)
+		}
+	}
+	return categories
+}
+
+// rotationIntn sumber angka acak strategi rotasi (test mengganti dengan generator ber-seed)
+var rotationIntn = rand.Intn
+
+// templateSelection hasil pemilihan template beserta cursor rotasi yang disimpan setelah berhasil kirim
+type templateSelection struct {
+	Template database.PromoteTemplate
+	Cursor   int
+}
+
+// selectTemplateForGroup memilih template sesuai strategi rotasi grup.
+// lastSent berisi waktu terakhir tiap template berhasil dikirim ke grup (untuk lru/sequence).
+func selectTemplateForGroup(group *database.AutoPromoteGroup, templates []database.PromoteTemplate, lastSent map[int]time.Time) templateSelection {
+	strategy, err := NormalizeRotationStrategy(group.RotationStrategy)
+	if err != nil {
+		strategy = RotationRandom
+	}
+
+	switch strategy {
+	case RotationRoundRobin:
+		return selectRoundRobin(templates, group.RotationCursor)
+	case RotationWeighted:
+		return templateSelection{Template: selectWeighted(templates), Cursor: group.RotationCursor}
+	case RotationLRU:
+		return templateSelection{Template: selectLeastRecent(templates, lastSent), Cursor: group.RotationCursor}
+	case RotationSequence:
+		return selectSequence(templates, parseCategorySequence(group.CategorySequence), group.RotationCursor, lastSent)
+	}
+	return templateSelection{Template: templates[rotationIntn(len(templates))], Cursor: group.RotationCursor}
+}
+
+// selectRoundRobin template dengan ID terkecil setelah ID terakhir (cursor), kembali ke awal jika habis
+func selectRoundRobin(templates []database.PromoteTemplate, lastID int) templateSelection {
+	sorted := append([]database.PromoteTemplate(nil), templates...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	chosen := sorted[0]
+	for _, template := range sorted {
+		if template.ID > lastID {
+			chosen = template
+			break
+		}
+	}
+	return templateSelection{Template: chosen, Cursor: chosen.ID}
+}
+
+// selectWeighted acak berbobot, weight < 1 dianggap 1
+func selectWeighted(templates []database.PromoteTemplate) database.PromoteTemplate {
+	total := 0
+	for _, template := range templates {
+		total += templateWeight(template)
+	}
+
+	pick := rotationIntn(total)
+	for _, template := range templates {
+		pick -= templateWeight(template)
+		if pick < 0 {
+			return template
+		}
+	}
+	return templates[len(templates)-1]
+}
+
+// templateWeight bobot efektif template
+func templateWeight(template database.PromoteTemplate) int {
+	if template.Weight < 1 {
+		return 1
+	}
+	return template.Weight
+}
+
+// selectLeastRecent template yang belum pernah / paling lama tidak dikirim ke grup (seri diacak)
+func selectLeastRecent(templates []database.PromoteTemplate, lastSent map[int]time.Time) database.PromoteTemplate {
+	var candidates []database.PromoteTemplate
+	var oldest time.Time
+	for _, template := range templates {
+		sentAt := lastSent[template.ID] // zero = belum pernah dikirim
+		switch {
+		case len(candidates) == 0 || sentAt.Before(oldest):
+			candidates = []database.PromoteTemplate{template}
+			oldest = sentAt
+		case sentAt.Equal(oldest):
+			candidates = append(candidates, template)
+		}
+	}
+	return candidates[rotationIntn(len(candidates))]
+}
+
+// selectSequence ambil kategori sesuai cursor (lewati kategori tanpa template aktif), lalu pilih
+// template paling lama tidak dikirim di kategori tersebut. Cursor maju ke kategori berikutnya.
+func selectSequence(templates []database.PromoteTemplate, sequence []string, cursor int, lastSent map[int]time.Time) templateSelection {
+	if len(sequence) == 0 {
+		return templateSelection{Template: selectLeastRecent(templates, lastSent), Cursor: cursor}
+	}
+
+	for offset := 0; offset < len(sequence); offset++ {
+		position := (cursor + offset) % len(sequence)
+		if position < 0 {
+			position += len(sequence)
+		}
+
+		var inCategory []database.PromoteTemplate
+		for _, template := range templates {
+			if strings.EqualFold(template.Category, sequence[position]) {
+				inCategory = append(inCategory, template)
+			}
+		}
+		if len(inCategory) > 0 {
+			return templateSelection{
+				Template: selectLeastRecent(inCategory, lastSent),
+				Cursor:   (position + 1) % len(sequence),
+			}
+		}
+	}
+
+	// Tidak ada kategori urutan yang punya template aktif
+	return templateSelection{Template: selectLeastRecent(templates, lastSent), Cursor: cursor}
+}
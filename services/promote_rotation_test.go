@@ -0,0 +1,186 @@
+package services
+
+import (
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nabilulilalbab/promote/database"
+	"github.com/nabilulilalbab/promote/utils"
+)
+
+// seedRotationRand mengganti sumber acak rotasi dengan generator ber-seed selama test
+func seedRotationRand(t *testing.T, seed int64) {
+	t.Helper()
+	previous := rotationIntn
+	rotationIntn = rand.New(rand.NewSource(seed)).Intn
+	t.Cleanup(func() { rotationIntn = previous })
+}
+
+// rotationTemplates 5 template aktif dengan ID sengaja tidak berurutan
+func rotationTemplates() []database.PromoteTemplate {
+	return []database.PromoteTemplate{
+		{ID: 3, Title: "Produk B", Category: "produk", Weight: 1},
+		{ID: 1, Title: "Produk A", Category: "produk", Weight: 1},
+		{ID: 7, Title: "Testimoni", Category: "Testimoni", Weight: 1},
+		{ID: 4, Title: "Diskon", Category: "diskon", Weight: 1},
+		{ID: 9, Title: "Info", Category: "info", Weight: 1},
+	}
+}
+
+func TestSelectTemplateForGroup(t *testing.T) {
+	base := time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC)
+	// Template 9 belum pernah dikirim; 3 paling lama, lalu 4, 1, 7
+	lastSent := map[int]time.Time{
+		3: base.Add(-5 * time.Hour),
+		4: base.Add(-4 * time.Hour),
+		1: base.Add(-3 * time.Hour),
+		7: base.Add(-1 * time.Hour),
+	}
+	sentExcept9 := map[int]time.Time{3: lastSent[3], 4: lastSent[4], 1: lastSent[1], 7: lastSent[7], 9: base}
+
+	tests := []struct {
+		name       string
+		strategy   string
+		sequence   string
+		cursor     int
+		lastSent   map[int]time.Time
+		templates  []database.PromoteTemplate
+		wantID     int
+		wantCursor int
+	}{
+		{name: "roundrobin from start", strategy: RotationRoundRobin, cursor: 0, wantID: 1, wantCursor: 1},
+		{name: "roundrobin next id", strategy: RotationRoundRobin, cursor: 4, wantID: 7, wantCursor: 7},
+		{name: "roundrobin skips missing id", strategy: "rr", cursor: 5, wantID: 7, wantCursor: 7},
+		{name: "roundrobin wraps after last", strategy: RotationRoundRobin, cursor: 9, wantID: 1, wantCursor: 1},
+		{name: "lru picks never sent", strategy: RotationLRU, cursor: 2, lastSent: lastSent, wantID: 9, wantCursor: 2},
+		{name: "lru picks oldest", strategy: RotationLRU, cursor: 2, lastSent: sentExcept9, wantID: 3, wantCursor: 2},
+		{name: "sequence first category", strategy: RotationSequence, sequence: "produk,testimoni,diskon", cursor: 0, lastSent: lastSent, wantID: 3, wantCursor: 1},
+		{name: "sequence category is case-insensitive", strategy: RotationSequence, sequence: "produk,testimoni,diskon", cursor: 1, lastSent: lastSent, wantID: 7, wantCursor: 2},
+		{name: "sequence wraps to start", strategy: RotationSequence, sequence: "produk,testimoni,diskon", cursor: 2, lastSent: lastSent, wantID: 4, wantCursor: 0},
+		{name: "sequence cursor beyond length", strategy: RotationSequence, sequence: "produk>testimoni", cursor: 5, lastSent: lastSent, wantID: 7, wantCursor: 0},
+		{name: "sequence skips empty category", strategy: RotationSequence, sequence: "produk,promo,diskon", cursor: 1, lastSent: lastSent, wantID: 4, wantCursor: 0},
+		{name: "sequence without matching category", strategy: RotationSequence, sequence: "promo,voucher", cursor: 1, lastSent: sentExcept9, wantID: 3, wantCursor: 1},
+		{
+			name: "weighted single heavy template", strategy: RotationWeighted, cursor: 3,
+			templates: []database.PromoteTemplate{{ID: 1, Weight: 1000000}, {ID: 2, Weight: 0}},
+			wantID:    1, wantCursor: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seedRotationRand(t, 1)
+			templates := tt.templates
+			if templates == nil {
+				templates = rotationTemplates()
+			}
+			group := &database.AutoPromoteGroup{RotationStrategy: tt.strategy, CategorySequence: tt.sequence, RotationCursor: tt.cursor}
+
+			got := selectTemplateForGroup(group, templates, tt.lastSent)
+			if got.Template.ID != tt.wantID || got.Cursor != tt.wantCursor {
+				t.Errorf("selectTemplateForGroup() = template %d cursor %d, want template %d cursor %d",
+					got.Template.ID, got.Cursor, tt.wantID, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestRotationPickOrder(t *testing.T) {
+	base := time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		strategy string
+		sequence string
+		want     []int
+	}{
+		// ID urut 1,3,4,7,9 lalu kembali ke awal
+		{name: "roundrobin", strategy: RotationRoundRobin, want: []int{1, 3, 4, 7, 9, 1, 3}},
+		// Semua belum pernah dikirim: 5 kiriman pertama acak (seed tetap), lalu mengulang dari yang paling lama
+		{name: "lru", strategy: RotationLRU, want: []int{3, 9, 4, 1, 7, 3, 9}},
+		// Kategori bergiliran; dalam kategori produk template 1 dan 3 bergantian
+		{name: "sequence", strategy: RotationSequence, sequence: "produk,testimoni,diskon", want: []int{1, 7, 4, 3, 7, 4, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seedRotationRand(t, 42)
+			group := &database.AutoPromoteGroup{RotationStrategy: tt.strategy, CategorySequence: tt.sequence}
+			lastSent := map[int]time.Time{}
+
+			var got []int
+			for i := range tt.want {
+				selection := selectTemplateForGroup(group, rotationTemplates(), lastSent)
+				got = append(got, selection.Template.ID)
+				// Simulasi kirim berhasil: riwayat dan cursor diperbarui
+				lastSent[selection.Template.ID] = base.Add(time.Duration(i) * time.Hour)
+				group.RotationCursor = selection.Cursor
+			}
+			if !equalInts(got, tt.want) {
+				t.Errorf("picks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectWeightedDistribution(t *testing.T) {
+	seedRotationRand(t, 7)
+	templates := []database.PromoteTemplate{{ID: 1, Weight: 1}, {ID: 2, Weight: 3}, {ID: 3, Weight: 0}}
+
+	counts := map[int]int{}
+	for i := 0; i < 5000; i++ {
+		counts[selectWeighted(templates).ID]++
+	}
+	// Bobot efektif 1:3:1 (weight < 1 dianggap 1)
+	for id, want := range map[int]int{1: 1000, 2: 3000, 3: 1000} {
+		if diff := counts[id] - want; diff < -150 || diff > 150 {
+			t.Errorf("template %d picked %d times, want about %d", id, counts[id], want)
+		}
+	}
+
+	// Seed yang sama menghasilkan urutan yang sama
+	var first, second []int
+	for _, picks := range []*[]int{&first, &second} {
+		seedRotationRand(t, 99)
+		for i := 0; i < 10; i++ {
+			*picks = append(*picks, selectWeighted(templates).ID)
+		}
+	}
+	if !equalInts(first, second) {
+		t.Errorf("weighted picks with same seed differ: %v vs %v", first, second)
+	}
+}
+
+func TestSaveRotationCursor(t *testing.T) {
+	db, repo, err := database.InitializeDatabase(filepath.Join(t.TempDir(), "promote.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	const groupJID = "120363000000000001@g.us"
+	service := NewAutoPromoteService(nil, repo, utils.NewLogger("TEST", false))
+	if _, err := service.SetGroupRotation(groupJID, "roundrobin", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cursor maju per kiriman, tersimpan di database, dan kembali ke awal setelah ID terakhir
+	for _, want := range []int{1, 3, 4, 7, 9, 1} {
+		group, err := repo.GetAutoPromoteGroup(groupJID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		selection := service.selectTemplateForGroup(group, rotationTemplates())
+		service.saveRotationCursor(group, selection)
+
+		stored, err := repo.GetAutoPromoteGroup(groupJID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if selection.Template.ID != want || stored.RotationCursor != want {
+			t.Fatalf("picked %d with stored cursor %d, want %d", selection.Template.ID, stored.RotationCursor, want)
+		}
+	}
+}
@@ -0,0 +1,486 @@
+// Package services - jadwal auto promote per grup: cron, jendela jam kirim, hari libur, dan zona waktu
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // zona waktu tetap tersedia walau server tanpa tzdata
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// DefaultPromoteTimezone zona waktu jadwal jika grup tidak mengatur timezone (WIB)
+const DefaultPromoteTimezone = "Asia/Jakarta"
+
+// timezoneAliases singkatan zona waktu Indonesia -> nama IANA
+var timezoneAliases = map[string]string{
+	"WIB":  "Asia/Jakarta",
+	"WITA": "Asia/Makassar",
+	"WIT":  "Asia/Jayapura",
+}
+
+// weekdayNames nama hari (Inggris & Indonesia) -> time.Weekday
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	"minggu": time.Sunday, "senin": time.Monday, "selasa": time.Tuesday, "rabu": time.Wednesday,
+	"kamis": time.Thursday, "jumat": time.Friday, "sabtu": time.Saturday,
+}
+
+// weekdayKeys nama pendek hari untuk disimpan di quiet_days (index = time.Weekday)
+var weekdayKeys = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// monthNames nama bulan untuk field cron
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// cronDescriptors singkatan cron yang umum dipakai
+var cronDescriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// scheduleSearchHorizon batas pencarian waktu kirim berikutnya
+const scheduleSearchHorizon = 366 * 24 * time.Hour
+
+// PromoteSchedule jadwal efektif satu grup auto promote
+type PromoteSchedule struct {
+	cron        *cronSchedule // nil = pakai interval
+	interval    time.Duration
+	windowStart int // menit sejak 00:00
+	windowEnd   int // menit sejak 00:00 (eksklusif), boleh lebih kecil dari start (melewati tengah malam)
+	hasWindow   bool
+	quietDays   [7]bool
+	location    *time.Location
+}
+
+// NewPromoteSchedule membangun jadwal dari kolom grup. interval dipakai jika grup tidak punya cron.
+func NewPromoteSchedule(group *database.AutoPromoteGroup, interval time.Duration) (*PromoteSchedule, error) {
+	schedule := &PromoteSchedule{interval: interval}
+
+	location, err := LoadPromoteTimezone(group.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	schedule.location = location
+
+	if strings.TrimSpace(group.ScheduleCron) != "" {
+		cron, err := parseCronSchedule(group.ScheduleCron)
+		if err != nil {
+			return nil, err
+		}
+		schedule.cron = cron
+	} else if interval <= 0 {
+		return nil, fmt.Errorf("interval harus lebih dari 0")
+	}
+
+	if group.WindowStart != "" || group.WindowEnd != "" {
+		if schedule.windowStart, err = parseClock(group.WindowStart); err != nil {
+			return nil, fmt.Errorf("jam mulai window tidak valid: %v", err)
+		}
+		if schedule.windowEnd, err = parseClock(group.WindowEnd); err != nil {
+			return nil, fmt.Errorf("jam akhir window tidak valid: %v", err)
+		}
+		if schedule.windowStart == schedule.windowEnd {
+			return nil, fmt.Errorf("jam mulai dan akhir window tidak boleh sama")
+		}
+		schedule.hasWindow = true
+	}
+
+	days, err := ParseQuietDays(group.QuietDays)
+	if err != nil {
+		return nil, err
+	}
+	quietCount := 0
+	for _, day := range days {
+		if !schedule.quietDays[day] {
+			schedule.quietDays[day] = true
+			quietCount++
+		}
+	}
+	if quietCount == 7 {
+		return nil, fmt.Errorf("semua hari menjadi hari libur, promosi tidak akan pernah terkirim")
+	}
+
+	return schedule, nil
+}
+
+// LoadPromoteTimezone memuat zona waktu jadwal (kosong = Asia/Jakarta, menerima WIB/WITA/WIT)
+func LoadPromoteTimezone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = DefaultPromoteTimezone
+	}
+	if alias, ok := timezoneAliases[strings.ToUpper(name)]; ok {
+		name = alias
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("timezone tidak dikenal: %s", name)
+	}
+	return location, nil
+}
+
+// ParseQuietDays memecah daftar hari libur ("sat,sun" / "sabtu,minggu" / "6,0")
+func ParseQuietDays(value string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		if day, ok := weekdayNames[part]; ok {
+			days = append(days, day)
+			continue
+		}
+		if len(part) > 3 {
+			if day, ok := weekdayNames[part[:3]]; ok {
+				days = append(days, day)
+				continue
+			}
+		}
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 || number > 7 {
+			return nil, fmt.Errorf("hari tidak dikenal: %s", part)
+		}
+		days = append(days, time.Weekday(number%7))
+	}
+	return days, nil
+}
+
+// FormatQuietDays menyimpan hari libur dalam bentuk kanonik ("sat,sun")
+func FormatQuietDays(days []time.Weekday) string {
+	var seen [7]bool
+	for _, day := range days {
+		seen[day] = true
+	}
+	var keys []string
+	for day, quiet := range seen {
+		if quiet {
+			keys = append(keys, weekdayKeys[day])
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+// parseClock membaca jam "HH:MM" menjadi menit sejak 00:00 ("24:00" = akhir hari)
+func parseClock(value string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("format jam harus HH:MM: %q", value)
+	}
+	hour, errHour := strconv.Atoi(parts[0])
+	minute, errMinute := strconv.Atoi(parts[1])
+	if errHour != nil || errMinute != nil || hour < 0 || hour > 24 || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("jam tidak valid: %q", value)
+	}
+	return hour*60 + minute, nil
+}
+
+// Location zona waktu jadwal
+func (p *PromoteSchedule) Location() *time.Location {
+	return p.location
+}
+
+// Allowed cek apakah promosi boleh dikirim pada waktu t (bukan hari libur dan di dalam window)
+func (p *PromoteSchedule) Allowed(t time.Time) bool {
+	t = t.In(p.location)
+	if p.quietDays[t.Weekday()] {
+		return false
+	}
+	return p.inWindow(t)
+}
+
+// inWindow cek jam t di dalam window kirim
+func (p *PromoteSchedule) inWindow(t time.Time) bool {
+	if !p.hasWindow {
+		return true
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if p.windowStart < p.windowEnd {
+		return minute >= p.windowStart && minute < p.windowEnd
+	}
+	// Window melewati tengah malam (misal 22:00-06:00)
+	return minute >= p.windowStart || minute < p.windowEnd
+}
+
+// NextAllowed waktu pertama >= t yang boleh dipakai kirim promosi (zero jika tidak ada)
+func (p *PromoteSchedule) NextAllowed(t time.Time) time.Time {
+	t = t.In(p.location)
+	// Maksimal 7 hari libur + 7 kali lompat ke awal window
+	for i := 0; i < 16; i++ {
+		if p.Allowed(t) {
+			return t
+		}
+		if p.quietDays[t.Weekday()] {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, p.location)
+			continue
+		}
+		t = p.nextWindowStart(t)
+	}
+	return time.Time{}
+}
+
+// nextWindowStart awal window berikutnya setelah t
+func (p *PromoteSchedule) nextWindowStart(t time.Time) time.Time {
+	start := time.Date(t.Year(), t.Month(), t.Day(), p.windowStart/60, p.windowStart%60, 0, 0, p.location)
+	if !start.After(t) {
+		start = time.Date(t.Year(), t.Month(), t.Day()+1, p.windowStart/60, p.windowStart%60, 0, 0, p.location)
+	}
+	return start
+}
+
+// Next waktu kirim berikutnya setelah after: cron berikutnya yang lolos window/hari libur,
+// atau after+interval yang digeser ke waktu diizinkan terdekat. Zero jika tidak ditemukan.
+func (p *PromoteSchedule) Next(after time.Time) time.Time {
+	if p.cron == nil {
+		return p.NextAllowed(after.Add(p.interval))
+	}
+
+	limit := after.Add(scheduleSearchHorizon)
+	t := after.In(p.location)
+	for t.Before(limit) {
+		candidate := p.cron.next(t, limit)
+		if candidate.IsZero() {
+			return time.Time{}
+		}
+		if p.Allowed(candidate) {
+			return candidate
+		}
+		allowed := p.NextAllowed(candidate)
+		if allowed.IsZero() {
+			return time.Time{}
+		}
+		// Cari cron berikutnya mulai dari waktu diizinkan (cron.next eksklusif, mundur 1 menit)
+		t = allowed.Add(-time.Minute)
+	}
+	return time.Time{}
+}
+
+// Due menentukan waktu kirim grup: next_promote_at tersimpan, atau dihitung dari promosi
+// terakhir / waktu aktivasi. Waktu yang sudah lewat digeser ke waktu diizinkan terdekat dari now.
+func (p *PromoteSchedule) Due(group *database.AutoPromoteGroup, now time.Time) time.Time {
+	var due time.Time
+	switch {
+	case group.NextPromoteAt != nil:
+		due = *group.NextPromoteAt
+	case group.LastPromoteAt != nil:
+		due = p.Next(*group.LastPromoteAt)
+	case p.cron != nil && group.StartedAt != nil:
+		due = p.Next(*group.StartedAt)
+	default:
+		// Grup baru tanpa cron langsung dikirim (jika di dalam window)
+		due = now
+	}
+
+	if due.IsZero() || due.After(now) {
+		return due
+	}
+	return p.NextAllowed(now)
+}
+
+// Describe ringkasan jadwal untuk ditampilkan ke admin
+func (p *PromoteSchedule) Describe() string {
+	var parts []string
+	if p.cron != nil {
+		parts = append(parts, "cron "+p.cron.expr)
+	} else {
+		parts = append(parts, fmt.Sprintf("setiap %v", p.interval))
+	}
+	if p.hasWindow {
+		parts = append(parts, fmt.Sprintf("jam %02d:%02d-%02d:%02d", p.windowStart/60, p.windowStart%60, p.windowEnd/60, p.windowEnd%60))
+	}
+	var quiet []time.Weekday
+	for day, isQuiet := range p.quietDays {
+		if isQuiet {
+			quiet = append(quiet, time.Weekday(day))
+		}
+	}
+	if len(quiet) > 0 {
+		parts = append(parts, "libur "+FormatQuietDays(quiet))
+	}
+	parts = append(parts, p.location.String())
+	return strings.Join(parts, ", ")
+}
+
+// cronSchedule ekspresi cron 5 field: menit jam tanggal bulan hari
+type cronSchedule struct {
+	expr              string
+	minutes           [60]bool
+	hours             [24]bool
+	days              [32]bool
+	months            [13]bool
+	weekdays          [7]bool
+	dayRestricted     bool
+	weekdayRestricted bool
+}
+
+// parseCronSchedule mem-parse cron standar (*, daftar, range, step, nama bulan/hari, @daily dst)
+func parseCronSchedule(expr string) (*cronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	spec := expr
+	if descriptor, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron harus 5 field (menit jam tanggal bulan hari): %q", expr)
+	}
+
+	cron := &cronSchedule{expr: expr}
+	if err := parseCronField(fields[0], 0, 59, nil, cron.minutes[:]); err != nil {
+		return nil, fmt.Errorf("cron menit: %v", err)
+	}
+	if err := parseCronField(fields[1], 0, 23, nil, cron.hours[:]); err != nil {
+		return nil, fmt.Errorf("cron jam: %v", err)
+	}
+	if err := parseCronField(fields[2], 1, 31, nil, cron.days[:]); err != nil {
+		return nil, fmt.Errorf("cron tanggal: %v", err)
+	}
+	if err := parseCronField(fields[3], 1, 12, monthNames, cron.months[:]); err != nil {
+		return nil, fmt.Errorf("cron bulan: %v", err)
+	}
+
+	weekdays, err := parseCronWeekdays(fields[4])
+	if err != nil {
+		return nil, fmt.Errorf("cron hari: %v", err)
+	}
+	cron.weekdays = weekdays
+
+	cron.dayRestricted = !strings.HasPrefix(fields[2], "*")
+	cron.weekdayRestricted = !strings.HasPrefix(fields[4], "*")
+	return cron, nil
+}
+
+// parseCronWeekdays mem-parse field hari (0-7, 0 dan 7 = Minggu). "*" hanya mencakup 0-6 agar
+// step seperti "*/2" tidak menghitung Minggu dua kali; angka 7 dari range/step digabung ke 0
+// setelah semua nilai diekspansi.
+func parseCronWeekdays(field string) ([7]bool, error) {
+	parts := strings.Split(field, ",")
+	for i, part := range parts {
+		if strings.HasPrefix(part, "*") {
+			parts[i] = "0-6" + part[1:]
+		}
+	}
+
+	var bits [8]bool
+	var weekdays [7]bool
+	if err := parseCronField(strings.Join(parts, ","), 0, 7, weekdayCronNames(), bits[:]); err != nil {
+		return weekdays, err
+	}
+	copy(weekdays[:], bits[:7])
+	if bits[7] {
+		weekdays[time.Sunday] = true
+	}
+	return weekdays, nil
+}
+
+// weekdayCronNames nama hari 3 huruf untuk field cron
+func weekdayCronNames() map[string]int {
+	names := make(map[string]int, len(weekdayKeys))
+	for day, key := range weekdayKeys {
+		names[key] = day
+	}
+	return names
+}
+
+// parseCronField mengisi bits untuk satu field cron
+func parseCronField(field string, min, max int, names map[string]int, bits []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		if part == "" {
+			return fmt.Errorf("nilai kosong pada %q", field)
+		}
+
+		rangePart, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			rangePart = part[:idx]
+			value, err := strconv.Atoi(part[idx+1:])
+			if err != nil || value <= 0 {
+				return fmt.Errorf("step tidak valid: %q", part)
+			}
+			step = value
+		}
+
+		start, end := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = parseCronValue(bounds[0], min, max, names); err != nil {
+				return err
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = parseCronValue(bounds[1], min, max, names); err != nil {
+					return err
+				}
+			} else if step > 1 {
+				end = max // "5/15" = mulai 5 sampai akhir
+			}
+			if end < start {
+				return fmt.Errorf("range terbalik: %q", part)
+			}
+		}
+
+		for value := start; value <= end; value += step {
+			bits[value] = true
+		}
+	}
+	return nil
+}
+
+// parseCronValue membaca angka atau nama (jan, mon, ...) dalam batas min-max
+func parseCronValue(value string, min, max int, names map[string]int) (int, error) {
+	if number, ok := names[strings.ToLower(value)]; ok {
+		return number, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < min || number > max {
+		return 0, fmt.Errorf("nilai %q di luar %d-%d", value, min, max)
+	}
+	return number, nil
+}
+
+// matchDay aturan cron: jika tanggal dan hari sama-sama dibatasi, cukup salah satu yang cocok
+func (c *cronSchedule) matchDay(t time.Time) bool {
+	dayMatch := c.days[t.Day()]
+	weekdayMatch := c.weekdays[t.Weekday()]
+	if c.dayRestricted && c.weekdayRestricted {
+		return dayMatch || weekdayMatch
+	}
+	return dayMatch && weekdayMatch
+}
+
+// next waktu cron pertama setelah t (presisi menit) sebelum limit, zero jika tidak ada
+func (c *cronSchedule) next(t time.Time, limit time.Time) time.Time {
+	location := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		if !c.months[t.Month()] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location)
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location)
+			continue
+		}
+		if !c.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, location)
+			continue
+		}
+		if !c.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// setBits daftar index bernilai true, untuk membandingkan hasil parse field cron
+func setBits(bits []bool) []int {
+	var values []int
+	for value, set := range bits {
+		if set {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		min     int
+		max     int
+		names   map[string]int
+		want    []int
+		wantErr bool
+	}{
+		{"wildcard", "*", 0, 5, nil, []int{0, 1, 2, 3, 4, 5}, false},
+		{"single", "7", 0, 59, nil, []int{7}, false},
+		{"list", "1,15,30", 0, 59, nil, []int{1, 15, 30}, false},
+		{"range", "9-12", 0, 23, nil, []int{9, 10, 11, 12}, false},
+		{"wildcard step", "*/15", 0, 59, nil, []int{0, 15, 30, 45}, false},
+		{"range step", "8-18/4", 0, 23, nil, []int{8, 12, 16}, false},
+		{"start step", "5/20", 0, 59, nil, []int{5, 25, 45}, false},
+		{"month names", "jan,MAR-apr", 1, 12, monthNames, []int{1, 3, 4}, false},
+		{"out of range", "60", 0, 59, nil, nil, true},
+		{"below min", "0", 1, 31, nil, nil, true},
+		{"reversed range", "10-5", 0, 23, nil, nil, true},
+		{"zero step", "*/0", 0, 59, nil, nil, true},
+		{"empty value", "1,,2", 0, 59, nil, nil, true},
+		{"unknown name", "foo", 1, 12, monthNames, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bits := make([]bool, tt.max+1)
+			err := parseCronField(tt.field, tt.min, tt.max, tt.names, bits)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseCronField(%q) expected error", tt.field)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCronField(%q) error = %v", tt.field, err)
+			}
+			if got := setBits(bits); !equalInts(got, tt.want) {
+				t.Errorf("parseCronField(%q) = %v, want %v", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronWeekdays(t *testing.T) {
+	tests := []struct {
+		field string
+		want  []int
+	}{
+		{"*", []int{0, 1, 2, 3, 4, 5, 6}},
+		{"*/2", []int{0, 2, 4, 6}},
+		{"*/3", []int{0, 3, 6}},
+		{"7", []int{0}},
+		{"0,7", []int{0}},
+		{"5-7", []int{0, 5, 6}},
+		{"1-7/2", []int{0, 1, 3, 5}},
+		{"mon-fri", []int{1, 2, 3, 4, 5}},
+		{"sun,sat", []int{0, 6}},
+	}
+
+	for _, tt := range tests {
+		weekdays, err := parseCronWeekdays(tt.field)
+		if err != nil {
+			t.Errorf("parseCronWeekdays(%q) error = %v", tt.field, err)
+			continue
+		}
+		if got := setBits(weekdays[:]); !equalInts(got, tt.want) {
+			t.Errorf("parseCronWeekdays(%q) = %v, want %v", tt.field, got, tt.want)
+		}
+	}
+
+	if _, err := parseCronWeekdays("8"); err == nil {
+		t.Error("parseCronWeekdays(\"8\") expected error")
+	}
+}
+
+func TestParseCronSchedule(t *testing.T) {
+	valid := []string{"0 9 * * *", "@daily", "@Weekly", "*/30 8-17 * * mon-fri", "0 12 1,15 * *", "0 0 * jan-jun 0"}
+	for _, expr := range valid {
+		if _, err := parseCronSchedule(expr); err != nil {
+			t.Errorf("parseCronSchedule(%q) error = %v", expr, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"0 9 * *":       "5 field",
+		"@yearly":       "5 field",
+		"60 * * * *":    "cron menit",
+		"0 24 * * *":    "cron jam",
+		"0 0 32 * *":    "cron tanggal",
+		"0 0 * 13 *":    "cron bulan",
+		"0 0 * * 8":     "cron hari",
+		"0 0 * * */0":   "cron hari",
+		"0 0 * * fri-x": "cron hari",
+	}
+	for expr, want := range invalid {
+		_, err := parseCronSchedule(expr)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseCronSchedule(%q) error = %v, want %q", expr, err, want)
+		}
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	jakarta, err := LoadPromoteTimezone("WIB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Rabu, 14 Oktober 2026 10:17 WIB
+	from := time.Date(2026, time.October, 14, 10, 17, 30, 0, jakarta)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, time.October, 14, 10, 30, 0, 0, jakarta)},
+		{"0 9 * * *", time.Date(2026, time.October, 15, 9, 0, 0, 0, jakarta)},
+		{"0 9 * * 7", time.Date(2026, time.October, 18, 9, 0, 0, 0, jakarta)},
+		{"0 9 * * */2", time.Date(2026, time.October, 15, 9, 0, 0, 0, jakarta)},
+		{"0 0 1 * *", time.Date(2026, time.November, 1, 0, 0, 0, 0, jakarta)},
+		{"0 0 29 feb *", time.Date(2028, time.February, 29, 0, 0, 0, 0, jakarta)},
+		// Tanggal dan hari sama-sama dibatasi: cukup salah satu yang cocok (tanggal 20 atau Senin)
+		{"0 8 20 * mon", time.Date(2026, time.October, 19, 8, 0, 0, 0, jakarta)},
+	}
+
+	for _, tt := range tests {
+		cron, err := parseCronSchedule(tt.expr)
+		if err != nil {
+			t.Fatalf("parseCronSchedule(%q) error = %v", tt.expr, err)
+		}
+		if got := cron.next(from, from.Add(4*366*24*time.Hour)); !got.Equal(tt.want) {
+			t.Errorf("next(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	// Waktu di luar batas pencarian menghasilkan zero time
+	cron, _ := parseCronSchedule("0 0 29 feb *")
+	if got := cron.next(from, from.Add(scheduleSearchHorizon)); !got.IsZero() {
+		t.Errorf("next beyond limit = %v, want zero", got)
+	}
+}
+
+func TestPromoteScheduleNextSkipsQuietDays(t *testing.T) {
+	group := &database.AutoPromoteGroup{ScheduleCron: "0 9 * * *", QuietDays: "sat,minggu", Timezone: "WIB"}
+	schedule, err := NewPromoteSchedule(group, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Jumat 16 Oktober 2026 10:00 WIB -> Sabtu & Minggu libur -> Senin 09:00
+	from := time.Date(2026, time.October, 16, 10, 0, 0, 0, schedule.Location())
+	want := time.Date(2026, time.October, 19, 9, 0, 0, 0, schedule.Location())
+	if got := schedule.Next(from); !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
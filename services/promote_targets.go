@@ -0,0 +1,233 @@
+// Package services - target template per grup auto promote (kategori dan template tertentu)
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// allTemplatesKeywords kata kunci .settemplates untuk kembali ke semua template
+var allTemplatesKeywords = map[string]bool{"all": true, "semua": true, "off": true, "*": true}
+
+// PromoteGroupTargeting grup auto promote beserta target templatenya (untuk dashboard)
+type PromoteGroupTargeting struct {
+	Group       database.AutoPromoteGroup `json:"group"`
+	Categories  []string                  `json:"categories"`
+	TemplateIDs []int                     `json:"template_ids"`
+	Schedule    string                    `json:"schedule"`
+	Rotation    string                    `json:"rotation"`
+}
+
+// ParseTemplateTargets memecah spesifikasi target "vpn,ssh,#12" menjadi kategori dan ID template.
+// Angka (dengan atau tanpa #) dianggap ID template; "all"/"semua" berarti semua template.
+func ParseTemplateTargets(spec string) ([]string, []int, error) {
+	var categories []string
+	var templateIDs []int
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		if allTemplatesKeywords[part] {
+			return nil, nil, nil
+		}
+
+		if id, err := strconv.Atoi(strings.TrimPrefix(part, "#")); err == nil {
+			if id <= 0 {
+				return nil, nil, fmt.Errorf("ID template tidak valid: %s", part)
+			}
+			templateIDs = append(templateIDs, id)
+			continue
+		}
+		if strings.HasPrefix(part, "#") {
+			return nil, nil, fmt.Errorf("ID template tidak valid: %s", part)
+		}
+		categories = append(categories, part)
+	}
+
+	return categories, templateIDs, nil
+}
+
+// FilterTemplatesForGroup template yang boleh dikirim ke grup: cocok kategori atau ID target.
+// Tanpa target, semua template dikembalikan.
+func FilterTemplatesForGroup(targets []database.AutoPromoteGroupTarget, templates []database.PromoteTemplate) []database.PromoteTemplate {
+	if len(targets) == 0 {
+		return templates
+	}
+
+	categories := make(map[string]bool)
+	templateIDs := make(map[int]bool)
+	for _, target := range targets {
+		if target.TemplateID > 0 {
+			templateIDs[target.TemplateID] = true
+		} else if target.Category != "" {
+			categories[strings.ToLower(target.Category)] = true
+		}
+	}
+
+	var filtered []database.PromoteTemplate
+	for _, template := range templates {
+		if templateIDs[template.ID] || categories[strings.ToLower(template.Category)] {
+			filtered = append(filtered, template)
+		}
+	}
+	return filtered
+}
+
+// DescribeTemplateTargets ringkasan target template grup untuk .groupstatus
+func DescribeTemplateTargets(targets []database.AutoPromoteGroupTarget) string {
+	if len(targets) == 0 {
+		return "Semua template"
+	}
+
+	var parts []string
+	for _, target := range targets {
+		if target.TemplateID > 0 {
+			parts = append(parts, fmt.Sprintf("#%d", target.TemplateID))
+		} else {
+			parts = append(parts, target.Category)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// splitTemplateTargets memisahkan target menjadi daftar kategori dan ID template
+func splitTemplateTargets(targets []database.AutoPromoteGroupTarget) ([]string, []int) {
+	categories := []string{}
+	templateIDs := []int{}
+	for _, target := range targets {
+		if target.TemplateID > 0 {
+			templateIDs = append(templateIDs, target.TemplateID)
+		} else if target.Category != "" {
+			categories = append(categories, target.Category)
+		}
+	}
+	return categories, templateIDs
+}
+
+// GetGroupTemplateTargets target template grup (kosong = semua template)
+func (s *AutoPromoteService) GetGroupTemplateTargets(groupJID string) ([]database.AutoPromoteGroupTarget, error) {
+	return s.repository.GetGroupTemplateTargets(groupJID)
+}
+
+// GetAllTemplates semua template promosi (termasuk nonaktif) untuk pilihan target
+func (s *AutoPromoteService) GetAllTemplates() ([]database.PromoteTemplate, error) {
+	return s.repository.GetAllTemplates()
+}
+
+// GetTemplatesForGroup template aktif yang boleh dikirim ke grup
+func (s *AutoPromoteService) GetTemplatesForGroup(groupJID string) ([]database.PromoteTemplate, error) {
+	templates, err := s.repository.GetActiveTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get templates: %v", err)
+	}
+	targets, err := s.repository.GetGroupTemplateTargets(groupJID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group targets: %v", err)
+	}
+	return FilterTemplatesForGroup(targets, templates), nil
+}
+
+// SetGroupTemplates mengganti target template grup. Kategori boleh belum punya template,
+// tetapi ID template harus ada. Kategori dan ID kosong = semua template.
+func (s *AutoPromoteService) SetGroupTemplates(groupJID string, categories []string, templateIDs []int) ([]database.AutoPromoteGroupTarget, error) {
+	group, err := s.repository.GetAutoPromoteGroup(groupJID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %v", err)
+	}
+	if group == nil {
+		if _, err := s.repository.CreateAutoPromoteGroup(groupJID); err != nil {
+			return nil, fmt.Errorf("failed to create group: %v", err)
+		}
+	}
+
+	targets := []database.AutoPromoteGroupTarget{}
+	seenCategories := make(map[string]bool)
+	for _, category := range categories {
+		category = strings.ToLower(strings.TrimSpace(category))
+		if category == "" || seenCategories[category] {
+			continue
+		}
+		seenCategories[category] = true
+		targets = append(targets, database.AutoPromoteGroupTarget{Category: category})
+	}
+
+	seenIDs := make(map[int]bool)
+	for _, id := range templateIDs {
+		if seenIDs[id] {
+			continue
+		}
+		seenIDs[id] = true
+
+		template, err := s.repository.GetTemplateByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get template %d: %v", id, err)
+		}
+		if template == nil {
+			return nil, fmt.Errorf("template dengan ID %d tidak ditemukan", id)
+		}
+		targets = append(targets, database.AutoPromoteGroupTarget{TemplateID: id})
+	}
+
+	if err := s.repository.SetGroupTemplateTargets(groupJID, targets); err != nil {
+		return nil, fmt.Errorf("failed to save group targets: %v", err)
+	}
+
+	s.logger.Infof("Template targets updated for group %s: %s", groupJID, DescribeTemplateTargets(targets))
+	return targets, nil
+}
+
+// ListGroupTargeting semua grup auto promote beserta target template, jadwal, dan rotasinya
+func (s *AutoPromoteService) ListGroupTargeting() ([]PromoteGroupTargeting, error) {
+	groups, err := s.repository.GetAllAutoPromoteGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %v", err)
+	}
+	targets, err := s.repository.GetAllGroupTemplateTargets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group targets: %v", err)
+	}
+
+	targetsByGroup := make(map[string][]database.AutoPromoteGroupTarget)
+	for _, target := range targets {
+		targetsByGroup[target.GroupJID] = append(targetsByGroup[target.GroupJID], target)
+	}
+
+	result := make([]PromoteGroupTargeting, 0, len(groups))
+	for _, group := range groups {
+		categories, templateIDs := splitTemplateTargets(targetsByGroup[group.GroupJID])
+		item := PromoteGroupTargeting{
+			Group:       group,
+			Categories:  categories,
+			TemplateIDs: templateIDs,
+			Rotation:    DescribeRotation(&group),
+		}
+		if schedule, err := NewPromoteSchedule(&group, s.interval); err != nil {
+			item.Schedule = "⚠️ " + err.Error()
+		} else {
+			item.Schedule = schedule.Describe()
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// TemplateCategories kategori unik dari daftar template (urut abjad)
+func TemplateCategories(templates []database.PromoteTemplate) []string {
+	seen := make(map[string]bool)
+	categories := []string{}
+	for _, template := range templates {
+		category := strings.ToLower(template.Category)
+		if category != "" && !seen[category] {
+			seen[category] = true
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
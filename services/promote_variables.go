@@ -0,0 +1,101 @@
+// Package services - variabel dan data produk untuk template promosi (template engine utils)
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/nabilulilalbab/promote/utils"
+)
+
+// PromoteProductsList nama daftar produk untuk {{#each PRODUCTS}}
+const PromoteProductsList = "PRODUCTS"
+
+// PromoteTemplateVariables variabel yang tersedia di template promosi
+var PromoteTemplateVariables = []string{
+	"DATE", "TIME", "DAY", "MONTH", "YEAR",
+	"GROUP_ID", "GROUP_NAME", "MEMBER_COUNT", "PRODUCT_COUNT",
+}
+
+// PromoteProductFields field produk di dalam {{#each PRODUCTS}}
+var PromoteProductFields = []string{"NAME", "SHORT_NAME", "CODE", "PRICE", "PRICE_INT", "DESCRIPTION", "DAILY_LIMIT", "NO_LOGIN"}
+
+// ProductSource penyedia data produk untuk loop {{#each PRODUCTS}}
+type ProductSource interface {
+	PromoteProducts() ([]map[string]string, error)
+}
+
+// samplePromoteProducts data produk contoh untuk preview template
+var samplePromoteProducts = []map[string]string{
+	{"NAME": "Paket Contoh 10GB", "SHORT_NAME": "Contoh 10GB", "CODE": "CTH10", "PRICE": "Rp 10K", "PRICE_INT": "10000", "DESCRIPTION": "Produk contoh untuk preview", "DAILY_LIMIT": "false", "NO_LOGIN": "true"},
+	{"NAME": "Paket Contoh 25GB", "SHORT_NAME": "Contoh 25GB", "CODE": "CTH25", "PRICE": "Rp 20K", "PRICE_INT": "20000", "DESCRIPTION": "Produk contoh untuk preview", "DAILY_LIMIT": "true", "NO_LOGIN": "false"},
+}
+
+// ValidatePromoteTemplateSyntax cek sintaks template promosi dan variabel {{...}} yang tidak dikenal
+func ValidatePromoteTemplateSyntax(content string) error {
+	tmpl, err := utils.ParseTemplate(content)
+	if err != nil {
+		return fmt.Errorf("sintaks template tidak valid: %v", err)
+	}
+
+	unknown := tmpl.UnknownVariables(PromoteTemplateVariables, map[string][]string{PromoteProductsList: PromoteProductFields})
+	if len(unknown) > 0 {
+		return fmt.Errorf("variabel tidak dikenal: %s (tersedia: %s)", strings.Join(unknown, ", "), strings.Join(PromoteTemplateVariables, ", "))
+	}
+	return nil
+}
+
+// promoteTemplateData variabel waktu dan grup; GROUP_NAME/MEMBER_COUNT/PRODUCTS diisi pemanggil
+func promoteTemplateData(now time.Time, groupJID types.JID) utils.TemplateData {
+	return utils.TemplateData{
+		Vars: map[string]string{
+			"DATE":          now.Format("2006-01-02"),
+			"TIME":          now.Format("15:04"),
+			"DAY":           getDayName(now.Weekday()),
+			"MONTH":         getMonthName(now.Month()),
+			"YEAR":          fmt.Sprintf("%d", now.Year()),
+			"GROUP_ID":      groupJID.User,
+			"GROUP_NAME":    "",
+			"MEMBER_COUNT":  "",
+			"PRODUCT_COUNT": "0",
+		},
+		Lists: map[string][]map[string]string{PromoteProductsList: nil},
+	}
+}
+
+// renderPromoteTemplate merender konten template untuk grup tujuan. Info grup dan produk hanya
+// diambil jika template memakainya. Template dengan sintaks rusak dikirim apa adanya.
+func renderPromoteTemplate(client *whatsmeow.Client, products ProductSource, logger *utils.Logger, content string, groupJID types.JID) string {
+	tmpl, err := utils.ParseTemplate(content)
+	if err != nil {
+		logger.Warningf("Invalid template syntax, sending raw content: %v", err)
+		return content
+	}
+
+	data := promoteTemplateData(time.Now(), groupJID)
+
+	if client != nil && tmpl.Uses("GROUP_NAME", "MEMBER_COUNT") {
+		if info, err := client.GetGroupInfo(groupJID); err != nil {
+			logger.Warningf("Failed to get group info for template %s: %v", groupJID.String(), err)
+		} else {
+			data.Vars["GROUP_NAME"] = info.Name
+			data.Vars["MEMBER_COUNT"] = strconv.Itoa(len(info.Participants))
+		}
+	}
+
+	if products != nil && tmpl.Uses(PromoteProductsList, "PRODUCT_COUNT") {
+		if rows, err := products.PromoteProducts(); err != nil {
+			logger.Warningf("Failed to get products for template: %v", err)
+		} else {
+			data.Lists[PromoteProductsList] = rows
+			data.Vars["PRODUCT_COUNT"] = strconv.Itoa(len(rows))
+		}
+	}
+
+	return tmpl.Render(data)
+}
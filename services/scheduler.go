@@ -12,18 +12,25 @@ import (
 type SchedulerService struct {
 	ticker    *time.Ticker
 	done      chan bool
+	wake      chan struct{}
 	task      func()
 	logger    *utils.Logger
 	isRunning bool
+	nextRunAt time.Time    // hanya untuk mode StartDynamic
+	nextMutex sync.RWMutex // terpisah dari mutex agar Stop tidak deadlock dengan goroutine
 	mutex     sync.RWMutex
 }
 
+// minDynamicWait jeda minimum antar eksekusi mode dinamis (cegah loop rapat jika jadwal tidak maju)
+const minDynamicWait = 30 * time.Second
+
 // NewSchedulerService membuat scheduler baru
 func NewSchedulerService(task func(), logger *utils.Logger) *SchedulerService {
 	return &SchedulerService{
 		task:      task,
 		logger:    logger,
 		done:      make(chan bool),
+		wake:      make(chan struct{}, 1),
 		isRunning: false,
 	}
 }
@@ -59,6 +66,77 @@ func (s *SchedulerService) Start(interval time.Duration) {
 	s.logger.Success("Scheduler started successfully")
 }
 
+// StartDynamic memulai scheduler yang tidur sampai waktu yang dihitung nextRun.
+// nextRun dipanggil ulang setelah setiap eksekusi atau Wake; waktu nol berarti tidak ada
+// jadwal, scheduler menunggu maxWait lalu menghitung ulang. maxWait juga membatasi lama tidur
+// agar perubahan jadwal dari luar tetap terbaca.
+func (s *SchedulerService) StartDynamic(nextRun func(now time.Time) time.Time, maxWait time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.isRunning {
+		s.logger.Warning("Scheduler already running")
+		return
+	}
+
+	s.logger.Infof("Starting dynamic scheduler (max wait: %v)", maxWait)
+	s.isRunning = true
+
+	go func() {
+		for {
+			now := time.Now()
+			wait := maxWait
+			if next := nextRun(now); !next.IsZero() && next.Sub(now) < maxWait {
+				wait = next.Sub(now)
+			}
+			if wait < minDynamicWait {
+				wait = minDynamicWait
+			}
+
+			s.setNextRunAt(now.Add(wait))
+			s.logger.Debugf("Scheduler sleeping for %v", wait)
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+				s.logger.Debug("Scheduler due - executing task")
+				s.executeTask()
+			case <-s.wake:
+				timer.Stop()
+				s.logger.Debug("Scheduler woken up - recomputing next run")
+			case <-s.done:
+				timer.Stop()
+				s.logger.Info("Scheduler stopped")
+				return
+			}
+		}
+	}()
+
+	s.logger.Success("Scheduler started successfully")
+}
+
+// Wake meminta scheduler dinamis menghitung ulang waktu eksekusi berikutnya (misal jadwal grup berubah)
+func (s *SchedulerService) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// NextRunAt waktu eksekusi berikutnya pada mode dinamis (zero jika tidak berjalan)
+func (s *SchedulerService) NextRunAt() time.Time {
+	s.nextMutex.RLock()
+	defer s.nextMutex.RUnlock()
+	return s.nextRunAt
+}
+
+// setNextRunAt mencatat waktu eksekusi berikutnya mode dinamis
+func (s *SchedulerService) setNextRunAt(next time.Time) {
+	s.nextMutex.Lock()
+	defer s.nextMutex.Unlock()
+	s.nextRunAt = next
+}
+
 // Stop menghentikan scheduler
 func (s *SchedulerService) Stop() {
 	s.mutex.Lock()
@@ -71,9 +149,12 @@ func (s *SchedulerService) Stop() {
 
 	s.logger.Info("Stopping scheduler...")
 	
-	s.ticker.Stop()
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
 	s.done <- true
 	s.isRunning = false
+	s.setNextRunAt(time.Time{})
 
 	s.logger.Success("Scheduler stopped successfully")
 }
@@ -116,6 +197,9 @@ func (s *SchedulerService) GetStatus() map[string]interface{} {
 		"is_running": s.isRunning,
 		"has_ticker": s.ticker != nil,
 	}
+	if next := s.NextRunAt(); !next.IsZero() {
+		status["next_run_at"] = next
+	}
 
 	return status
 }
\ No newline at end of file
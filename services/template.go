@@ -6,6 +6,8 @@ import (
 	"strings"
 	"time"
 
+	"go.mau.fi/whatsmeow/types"
+
 	"github.com/nabilulilalbab/promote/database"
 	"github.com/nabilulilalbab/promote/utils"
 )
@@ -14,6 +16,7 @@ import (
 type TemplateService struct {
 	repository database.Repository
 	logger     *utils.Logger
+	mediaDir   string // folder upload yang boleh dipakai sebagai lampiran template
 }
 
 // NewTemplateService membuat service baru
@@ -21,6 +24,14 @@ func NewTemplateService(repo database.Repository, logger *utils.Logger) *Templat
 	return &TemplateService{
 		repository: repo,
 		logger:     logger,
+		mediaDir:   DefaultPromoteMediaDir,
+	}
+}
+
+// SetMediaDir mengatur folder upload media (harus sama dengan folder upload dashboard)
+func (s *TemplateService) SetMediaDir(dir string) {
+	if dir != "" {
+		s.mediaDir = dir
 	}
 }
 
@@ -120,6 +131,70 @@ func (s *TemplateService) UpdateTemplate(id int, title, content, category string
 	return nil
 }
 
+// SetTemplateWeight mengatur bobot template untuk rotasi weighted
+func (s *TemplateService) SetTemplateWeight(id, weight int) (*database.PromoteTemplate, error) {
+	if weight < 1 || weight > 100 {
+		return nil, fmt.Errorf("bobot harus antara 1 dan 100")
+	}
+
+	template, err := s.repository.GetTemplateByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if template == nil {
+		return nil, fmt.Errorf("template dengan ID %d tidak ditemukan", id)
+	}
+
+	template.Weight = weight
+	if err := s.repository.UpdateTemplate(template); err != nil {
+		s.logger.Errorf("Failed to set template %d weight: %v", id, err)
+		return nil, fmt.Errorf("gagal mengubah bobot template: %v", err)
+	}
+
+	s.logger.Successf("Template weight updated: %s (ID: %d) -> %d", template.Title, template.ID, weight)
+	return template, nil
+}
+
+// SetTemplateMedia memasang lampiran media template (path kosong = hapus lampiran).
+// File harus berada di folder upload media; jenis media (image/video/document) dideteksi dari isi file.
+func (s *TemplateService) SetTemplateMedia(id int, mediaPath string) (*database.PromoteTemplate, error) {
+	template, err := s.repository.GetTemplateByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if template == nil {
+		return nil, fmt.Errorf("template dengan ID %d tidak ditemukan", id)
+	}
+
+	mediaPath = strings.TrimSpace(mediaPath)
+	if mediaPath == "" {
+		template.MediaPath = ""
+		template.MediaType = ""
+	} else {
+		resolved, err := ResolvePromoteMediaPath(s.mediaDir, mediaPath)
+		if err != nil {
+			s.logger.Warningf("Rejected media path for template %d: %s", id, mediaPath)
+			return nil, err
+		}
+		media, err := DetectPromoteMedia(resolved)
+		if err != nil {
+			return nil, err
+		}
+		template.MediaPath = media.Path
+		template.MediaType = media.Type
+	}
+
+	if err := s.repository.UpdateTemplate(template); err != nil {
+		s.logger.Errorf("Failed to set template %d media: %v", id, err)
+		return nil, fmt.Errorf("gagal mengubah media template: %v", err)
+	}
+
+	s.logger.Successf("Template media updated: %s (ID: %d) -> %s", template.Title, template.ID, DescribeTemplateMedia(template))
+	return template, nil
+}
+
 // DeleteTemplate menghapus template
 func (s *TemplateService) DeleteTemplate(id int) error {
 	// Cek apakah template ada
@@ -234,6 +309,7 @@ func (s *TemplateService) PreviewTemplate(templateID int) (string, error) {
 🏷️ *Judul:* %s
 📂 *Kategori:* %s
 📈 *Status:* %s
+📎 *Media:* %s
 
 ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
 	          *KONTEN PREVIEW*
@@ -245,10 +321,11 @@ func (s *TemplateService) PreviewTemplate(templateID int) (string, error) {
 	          *INFORMASI*
 ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
 
-💡 Variabel dinamis seperti *{DATE}* dan *{TIME}* akan diganti saat promosi dikirim.`,
+💡 Variabel dinamis seperti *{DATE}*, *{{GROUP_NAME}}*, loop *{{#each PRODUCTS}}* dan spintax *{Halo|Hai}* diproses saat promosi dikirim. Preview memakai data grup dan produk contoh.`,
 		template.Title,
 		template.Category,
 		getStatusText(template.IsActive),
+		DescribeTemplateMedia(template),
 		preview), nil
 }
 
@@ -282,26 +359,21 @@ func (s *TemplateService) validateTemplate(title, content, category string) erro
 		return fmt.Errorf("kategori template maksimal 50 karakter")
 	}
 
-	return nil
+	return ValidatePromoteTemplateSyntax(content)
 }
 
-// processTemplateForPreview memproses template untuk preview
+// processTemplateForPreview merender template dengan data grup dan produk contoh
 func (s *TemplateService) processTemplateForPreview(content string) string {
-	now := time.Now()
-
-	replacements := map[string]string{
-		"{DATE}":  now.Format("2006-01-02"),
-		"{TIME}":  now.Format("15:04"),
-		"{DAY}":   getDayName(now.Weekday()),
-		"{MONTH}": getMonthName(now.Month()),
-		"{YEAR}":  fmt.Sprintf("%d", now.Year()),
-	}
+	data := promoteTemplateData(time.Now(), types.JID{User: "120363000000000000", Server: types.GroupServer})
+	data.Vars["GROUP_NAME"] = "Grup Contoh"
+	data.Vars["MEMBER_COUNT"] = "128"
+	data.Vars["PRODUCT_COUNT"] = fmt.Sprintf("%d", len(samplePromoteProducts))
+	data.Lists[PromoteProductsList] = samplePromoteProducts
 
-	result := content
-	for placeholder, value := range replacements {
-		result = strings.ReplaceAll(result, placeholder, value)
+	result, err := utils.RenderTemplate(content, data)
+	if err != nil {
+		return fmt.Sprintf("⚠️ Sintaks template tidak valid: %v\n\n%s", err, content)
 	}
-
 	return result
 }
 
@@ -0,0 +1,161 @@
+// Package services - batch conversion untuk banyak XRay link dan subscription base64
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// maxBatchLinks batas jumlah link dalam satu batch agar bot tidak kebanjiran
+const maxBatchLinks = 50
+
+// xrayLinkPrefixes prefix protocol yang dikenali sebagai XRay link
+var xrayLinkPrefixes = []string{"vmess://", "vless://", "trojan://", "ss://"}
+
+// ExtractXRayLinks mengambil semua XRay link dari teks (dipisah spasi/baris baru),
+// termasuk link di dalam subscription base64
+func ExtractXRayLinks(input string) []string {
+	var links []string
+
+	for _, token := range strings.Fields(input) {
+		if isXRayLink(token) {
+			links = append(links, token)
+			continue
+		}
+
+		// Coba decode sebagai subscription base64
+		decoded, err := decodeBase64String(token)
+		if err != nil || !strings.Contains(decoded, "://") {
+			continue
+		}
+		for _, line := range strings.Fields(decoded) {
+			if isXRayLink(line) {
+				links = append(links, line)
+			}
+		}
+	}
+
+	return links
+}
+
+// isXRayLink cek apakah token diawali prefix protocol yang didukung
+func isXRayLink(token string) bool {
+	for _, prefix := range xrayLinkPrefixes {
+		if strings.HasPrefix(token, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProcessBatchConversion mengkonversi banyak link sekaligus melalui ProcessConversion
+// dan menghasilkan gabungan Clash proxies serta subscription base64
+func (s *XRayConverterService) ProcessBatchConversion(converterName string, links []string, userJID, groupJID string) (*database.XRayBatchResult, error) {
+	if len(links) == 0 {
+		return nil, fmt.Errorf("no XRay links found")
+	}
+	if len(links) > maxBatchLinks {
+		return nil, fmt.Errorf("too many links: %d (maksimal %d per batch)", len(links), maxBatchLinks)
+	}
+
+	converter, err := s.repository.GetXRayConverter(converterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get converter: %v", err)
+	}
+	if converter == nil {
+		return nil, fmt.Errorf("converter not found: %s", converterName)
+	}
+
+	batch := &database.XRayBatchResult{}
+	usedNames := make(proxyNameSet)
+	var results []*database.ModifiedXRayConfig
+
+	for i, link := range links {
+		item := database.XRayBatchItem{
+			Index:        i + 1,
+			OriginalLink: link,
+		}
+
+		result, err := s.ProcessConversion(converterName, link, userJID, groupJID)
+		if err != nil {
+			item.Error = err.Error()
+			batch.FailedCount++
+			batch.Items = append(batch.Items, item)
+			continue
+		}
+
+		// Pastikan nama proxy unik di dalam satu profile Clash
+		s.ensureUniqueProxyName(usedNames, result)
+
+		item.Result = result
+		batch.SuccessCount++
+		batch.Items = append(batch.Items, item)
+		results = append(results, result)
+	}
+
+	if len(results) > 0 {
+		batch.ClashProxies = combineClashProxies(results)
+		if template := profileTemplateOf(results); template != nil {
+			profile, err := BuildClashProfile(results, template)
+			if err != nil {
+				s.logger.Warningf("Failed to build batch Mihomo profile: %v", err)
+			}
+			batch.ClashProfile = profile
+		}
+		batch.Subscription = combineSubscription(results)
+	}
+
+	return batch, nil
+}
+
+// proxyNameSet menghitung pemakaian nama proxy di dalam satu profile
+type proxyNameSet map[string]int
+
+// ensureUniqueProxyName mengganti nama proxy yang bentrok lalu render ulang YAML-nya
+func (s *XRayConverterService) ensureUniqueProxyName(names proxyNameSet, result *database.ModifiedXRayConfig) {
+	names[result.ProxyName]++
+	count := names[result.ProxyName]
+	if count == 1 {
+		return
+	}
+
+	result.ProxyName = fmt.Sprintf("%s-%d", result.ProxyName, count)
+	names[result.ProxyName]++
+	yamlConfig, err := s.generateYAMLConfig(result.DetectedConfig, result.ModifiedConfig.RawConfig, result.ProxyName)
+	if err == nil {
+		result.YAMLConfig = yamlConfig
+	}
+}
+
+// combineClashProxies menggabungkan proxy semua hasil menjadi satu daftar "proxies:"
+func combineClashProxies(results []*database.ModifiedXRayConfig) string {
+	proxies, err := parseClashProxies(results)
+	if err == nil {
+		if content, err := marshalClashYAML(clashProxyList{Proxies: proxies}); err == nil {
+			return content
+		}
+	}
+
+	// Fallback: gabungkan fragment YAML apa adanya
+	var builder strings.Builder
+	builder.WriteString("proxies:\n")
+	for _, result := range results {
+		if result.YAMLConfig == "" {
+			continue
+		}
+		builder.WriteString(strings.TrimPrefix(result.YAMLConfig, "proxies:\n"))
+	}
+	return builder.String()
+}
+
+// combineSubscription menggabungkan semua link hasil modifikasi menjadi subscription base64
+func combineSubscription(results []*database.ModifiedXRayConfig) string {
+	links := make([]string, 0, len(results))
+	for _, result := range results {
+		links = append(links, result.ModifiedLink)
+	}
+	return base64.StdEncoding.EncodeToString([]byte(strings.Join(links, "\n")))
+}
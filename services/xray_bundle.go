@@ -0,0 +1,339 @@
+// Package services - export/import XRay converter sebagai bundle JSON bertanda tangan (HMAC)
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// Mode penanganan converter bundle yang namanya sudah ada
+const (
+	BundleConflictSkip      = "skip"
+	BundleConflictOverwrite = "overwrite"
+	BundleConflictRename    = "rename"
+)
+
+// Hasil import per converter
+const (
+	BundleActionCreate    = "create"
+	BundleActionOverwrite = "overwrite"
+	BundleActionRename    = "rename"
+	BundleActionSkip      = "skip"
+	BundleActionInvalid   = "invalid"
+)
+
+// bundleModifyTypes modify type yang diterima tabel xray_converters
+var bundleModifyTypes = map[string]bool{
+	"wildcard": true,
+	"sni":      true,
+	"ws":       true,
+	"grpc":     true,
+	"custom":   true,
+}
+
+// XRayBundleImportItem hasil import satu converter dari bundle
+type XRayBundleImportItem struct {
+	CommandName string                              `json:"command_name"`          // nama di bundle
+	ImportedAs  string                              `json:"imported_as,omitempty"` // nama tersimpan (berbeda jika rename)
+	Action      string                              `json:"action"`                // create/overwrite/rename/skip/invalid
+	Diff        []database.XRayConverterFieldChange `json:"diff,omitempty"`        // perubahan field jika overwrite
+	Error       string                              `json:"error,omitempty"`
+}
+
+// XRayBundleImportResult ringkasan import bundle (dry-run hanya preview, tanpa menulis ke database)
+type XRayBundleImportResult struct {
+	DryRun      bool                   `json:"dry_run"`
+	Mode        string                 `json:"mode"`
+	Signed      bool                   `json:"signed"` // signature bundle terverifikasi
+	ExportedBy  string                 `json:"exported_by"`
+	ExportedAt  string                 `json:"exported_at"`
+	Items       []XRayBundleImportItem `json:"items"`
+	Created     int                    `json:"created"`
+	Overwritten int                    `json:"overwritten"`
+	Renamed     int                    `json:"renamed"`
+	Skipped     int                    `json:"skipped"`
+	Invalid     int                    `json:"invalid"`
+}
+
+// SetBundleSecret mengatur secret HMAC untuk menandatangani dan memverifikasi bundle converter.
+// Secret kosong: bundle diekspor tanpa signature dan signature tidak diperiksa saat import.
+func (s *XRayConverterService) SetBundleSecret(secret string) {
+	if secret == "" {
+		s.bundleSecret = nil
+		return
+	}
+	s.bundleSecret = []byte(secret)
+}
+
+// ValidateConflictMode cek mode konflik import (kosong = skip)
+func ValidateConflictMode(mode string) error {
+	switch mode {
+	case "", BundleConflictSkip, BundleConflictOverwrite, BundleConflictRename:
+		return nil
+	}
+	return fmt.Errorf("invalid conflict mode %q (use skip, overwrite or rename)", mode)
+}
+
+// ExportConverters menyusun bundle dari converter yang dipilih (kosong = semua converter)
+func (s *XRayConverterService) ExportConverters(names []string, exportedBy string) (*database.XRayConverterBundle, error) {
+	var converters []database.XRayConverter
+	if len(names) == 0 {
+		all, err := s.repository.GetAllXRayConverters()
+		if err != nil {
+			return nil, err
+		}
+		converters = all
+	} else {
+		for _, name := range names {
+			name = strings.TrimPrefix(strings.TrimSpace(name), ".")
+			converter, err := s.repository.GetXRayConverter(name)
+			if err != nil {
+				return nil, err
+			}
+			if converter == nil {
+				return nil, fmt.Errorf("converter %s not found", name)
+			}
+			converters = append(converters, *converter)
+		}
+	}
+
+	bundle := &database.XRayConverterBundle{
+		Format:     database.XRayBundleFormat,
+		Version:    database.XRayBundleVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		ExportedBy: exportedBy,
+		Converters: make([]map[string]interface{}, 0, len(converters)),
+	}
+	for i := range converters {
+		entry, err := database.BundleConverterEntry(&converters[i])
+		if err != nil {
+			return nil, err
+		}
+		bundle.Converters = append(bundle.Converters, entry)
+	}
+
+	if len(s.bundleSecret) > 0 {
+		signature, err := s.bundleSignature(bundle)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Signature = signature
+	}
+
+	return bundle, nil
+}
+
+// EncodeConverterBundle serialisasi bundle ke JSON yang mudah dibaca
+func EncodeConverterBundle(bundle *database.XRayConverterBundle) ([]byte, error) {
+	return json.MarshalIndent(bundle, "", "  ")
+}
+
+// ParseConverterBundle membaca bundle JSON lalu memeriksa format, versi, dan signature.
+// Jika secret bundle diatur, hanya bundle dengan signature yang valid yang diterima.
+func (s *XRayConverterService) ParseConverterBundle(data []byte) (*database.XRayConverterBundle, error) {
+	var bundle database.XRayConverterBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid bundle JSON: %v", err)
+	}
+
+	if bundle.Format != database.XRayBundleFormat {
+		return nil, fmt.Errorf("unknown bundle format %q", bundle.Format)
+	}
+	if bundle.Version < 1 || bundle.Version > database.XRayBundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d (supported: %d)", bundle.Version, database.XRayBundleVersion)
+	}
+
+	if len(s.bundleSecret) > 0 {
+		if bundle.Signature == "" {
+			return nil, fmt.Errorf("bundle is not signed")
+		}
+		expected, err := s.bundleSignature(&bundle)
+		if err != nil {
+			return nil, err
+		}
+		if !hmac.Equal([]byte(expected), []byte(strings.ToLower(bundle.Signature))) {
+			return nil, fmt.Errorf("bundle signature mismatch")
+		}
+	}
+
+	return &bundle, nil
+}
+
+// bundleSignature HMAC-SHA256 atas JSON bundle tanpa field signature
+func (s *XRayConverterService) bundleSignature(bundle *database.XRayConverterBundle) (string, error) {
+	unsigned := *bundle
+	unsigned.Signature = ""
+
+	payload, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, s.bundleSecret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// ImportConverters menyimpan converter dari bundle. Converter yang namanya sudah ada ditangani sesuai
+// mode (skip/overwrite/rename). dryRun hanya menyusun preview tanpa menulis ke database.
+func (s *XRayConverterService) ImportConverters(bundle *database.XRayConverterBundle, mode string, dryRun bool, importedBy string) (*XRayBundleImportResult, error) {
+	if mode == "" {
+		mode = BundleConflictSkip
+	}
+	if err := ValidateConflictMode(mode); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repository.GetAllXRayConverters()
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]*database.XRayConverter, len(existing))
+	for i := range existing {
+		current[existing[i].CommandName] = &existing[i]
+	}
+
+	result := &XRayBundleImportResult{
+		DryRun:     dryRun,
+		Mode:       mode,
+		Signed:     len(s.bundleSecret) > 0 && bundle.Signature != "",
+		ExportedBy: bundle.ExportedBy,
+		ExportedAt: bundle.ExportedAt,
+	}
+
+	for _, entry := range bundle.Converters {
+		item := XRayBundleImportItem{}
+		if name, ok := entry["command_name"].(string); ok {
+			item.CommandName = name
+		}
+
+		converter, err := database.DecodeBundleConverter(entry)
+		if err == nil {
+			err = validateBundleConverter(converter)
+		}
+		if err != nil {
+			item.Action = BundleActionInvalid
+			item.Error = err.Error()
+			result.Invalid++
+			result.Items = append(result.Items, item)
+			continue
+		}
+
+		converter.CreatedBy = importedBy
+		converter.UpdatedBy = importedBy
+		item.ImportedAs = converter.CommandName
+
+		old, exists := current[converter.CommandName]
+		switch {
+		case !exists:
+			item.Action = BundleActionCreate
+		case mode == BundleConflictOverwrite:
+			item.Action = BundleActionOverwrite
+			item.Diff, err = database.DiffXRayConverters(old, converter)
+		case mode == BundleConflictRename:
+			item.Action = BundleActionRename
+			converter.CommandName = uniqueConverterName(converter.CommandName, current)
+			item.ImportedAs = converter.CommandName
+		default:
+			item.Action = BundleActionSkip
+		}
+
+		if err == nil && !dryRun {
+			switch item.Action {
+			case BundleActionCreate, BundleActionRename:
+				err = s.repository.CreateXRayConverter(converter)
+			case BundleActionOverwrite:
+				err = s.repository.UpdateXRayConverter(converter)
+			}
+		}
+		if err != nil {
+			item.Action = BundleActionInvalid
+			item.Error = err.Error()
+			result.Invalid++
+			result.Items = append(result.Items, item)
+			continue
+		}
+
+		switch item.Action {
+		case BundleActionCreate:
+			result.Created++
+		case BundleActionOverwrite:
+			result.Overwritten++
+		case BundleActionRename:
+			result.Renamed++
+		case BundleActionSkip:
+			result.Skipped++
+		}
+		if item.Action != BundleActionSkip {
+			current[converter.CommandName] = converter
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	if !dryRun {
+		s.logger.Infof("XRay converter bundle imported by %s: %d created, %d overwritten, %d renamed, %d skipped, %d invalid",
+			importedBy, result.Created, result.Overwritten, result.Renamed, result.Skipped, result.Invalid)
+	}
+
+	return result, nil
+}
+
+// reservedConverterNames command bot yang dicek sebelum/bersama converter, tidak boleh jadi nama converter
+var reservedConverterNames = map[string]bool{
+	"convert":          true,
+	"converters":       true,
+	"exportconverters": true,
+	"importconverters": true,
+	"testconvert":      true,
+}
+
+// validateConverterCommandName nama converter dipakai sebagai command ".<nama>": tidak boleh kosong,
+// berisi spasi, diawali "." atau bentrok dengan command bawaan bot
+func validateConverterCommandName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("command_name is required")
+	case strings.IndexFunc(name, unicode.IsSpace) >= 0:
+		return fmt.Errorf("command_name %q must not contain whitespace", name)
+	case strings.HasPrefix(name, "."):
+		return fmt.Errorf("command_name %q must not start with \".\"", name)
+	case reservedConverterNames[strings.ToLower(name)]:
+		return fmt.Errorf("command_name %q is a built-in command", name)
+	}
+	return nil
+}
+
+// validateBundleConverter memastikan converter dari bundle bisa disimpan dan dijalankan
+func validateBundleConverter(converter *database.XRayConverter) error {
+	if err := validateConverterCommandName(converter.CommandName); err != nil {
+		return err
+	}
+	if converter.DisplayName == "" || converter.BugHost == "" {
+		return fmt.Errorf("display_name and bug_host are required")
+	}
+	if !bundleModifyTypes[converter.ModifyType] {
+		return fmt.Errorf("invalid modify_type %q", converter.ModifyType)
+	}
+	if err := ValidateConverterRules(converter.Rules); err != nil {
+		return err
+	}
+	return ValidateDeliveryMode(converter.DeliveryMode)
+}
+
+// uniqueConverterName mencari nama converter yang belum dipakai: nama_2, nama_3, dst
+func uniqueConverterName(name string, taken map[string]*database.XRayConverter) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s_%d", name, i)
+		if _, exists := taken[candidate]; !exists {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nabilulilalbab/promote/database"
+	"github.com/nabilulilalbab/promote/utils"
+)
+
+// newBundleTestService service dengan database kosong berisi satu converter "alpha"
+func newBundleTestService(t *testing.T) (*XRayConverterService, database.Repository) {
+	t.Helper()
+
+	db, repo, err := database.InitializeLearningDatabase(filepath.Join(t.TempDir(), "bundle.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	alpha := &database.XRayConverter{CommandName: "alpha", DisplayName: "Alpha", BugHost: "old.example.com", ModifyType: "wildcard", IsActive: true}
+	if err := repo.CreateXRayConverter(alpha); err != nil {
+		t.Fatal(err)
+	}
+	return NewXRayConverterService(repo, utils.NewLogger("TEST", false)), repo
+}
+
+// bundleEntry entry bundle minimal yang valid
+func bundleEntry(name, bugHost string) map[string]interface{} {
+	return map[string]interface{}{
+		"command_name": name,
+		"display_name": strings.ToUpper(name),
+		"bug_host":     bugHost,
+		"modify_type":  "wildcard",
+		"is_active":    true,
+	}
+}
+
+func TestConverterBundleSignature(t *testing.T) {
+	tests := []struct {
+		name         string
+		exportSecret string
+		importSecret string
+		tamper       func(bundle *database.XRayConverterBundle)
+		wantErr      string
+	}{
+		{name: "signed bundle verifies", exportSecret: "s3cret", importSecret: "s3cret"},
+		{name: "unsigned bundle without secret", exportSecret: "", importSecret: ""},
+		{name: "signed bundle without import secret", exportSecret: "s3cret", importSecret: ""},
+		{
+			name: "tampered converter field", exportSecret: "s3cret", importSecret: "s3cret",
+			tamper:  func(b *database.XRayConverterBundle) { b.Converters[0]["bug_host"] = "evil.example.com" },
+			wantErr: "signature mismatch",
+		},
+		{
+			name: "added converter", exportSecret: "s3cret", importSecret: "s3cret",
+			tamper: func(b *database.XRayConverterBundle) {
+				b.Converters = append(b.Converters, bundleEntry("extra", "x.example.com"))
+			},
+			wantErr: "signature mismatch",
+		},
+		{
+			name: "tampered exporter", exportSecret: "s3cret", importSecret: "s3cret",
+			tamper:  func(b *database.XRayConverterBundle) { b.ExportedBy = "someone-else" },
+			wantErr: "signature mismatch",
+		},
+		{name: "wrong secret", exportSecret: "s3cret", importSecret: "other", wantErr: "signature mismatch"},
+		{name: "unsigned bundle with import secret", exportSecret: "", importSecret: "s3cret", wantErr: "not signed"},
+		{
+			name: "unknown format", exportSecret: "", importSecret: "",
+			tamper:  func(b *database.XRayConverterBundle) { b.Format = "other-bot" },
+			wantErr: "unknown bundle format",
+		},
+		{
+			name: "future version", exportSecret: "", importSecret: "",
+			tamper:  func(b *database.XRayConverterBundle) { b.Version = database.XRayBundleVersion + 1 },
+			wantErr: "unsupported bundle version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newBundleTestService(t)
+			service.SetBundleSecret(tt.exportSecret)
+
+			bundle, err := service.ExportConverters(nil, "6281")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (bundle.Signature != "") != (tt.exportSecret != "") {
+				t.Fatalf("signature = %q with export secret %q", bundle.Signature, tt.exportSecret)
+			}
+			data, err := EncodeConverterBundle(bundle)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Ubah bundle setelah ditandatangani, seperti file yang diedit di tengah jalan
+			if tt.tamper != nil {
+				var decoded database.XRayConverterBundle
+				if err := json.Unmarshal(data, &decoded); err != nil {
+					t.Fatal(err)
+				}
+				tt.tamper(&decoded)
+				if data, err = json.Marshal(decoded); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			service.SetBundleSecret(tt.importSecret)
+			parsed, err := service.ParseConverterBundle(data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseConverterBundle() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseConverterBundle() error = %v", err)
+			}
+			if len(parsed.Converters) != 1 || parsed.Converters[0]["command_name"] != "alpha" {
+				t.Errorf("parsed converters = %v", parsed.Converters)
+			}
+		})
+	}
+}
+
+func TestImportConverters(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		dryRun      bool
+		wantActions map[string]string // nama di bundle -> action
+		wantAs      map[string]string // nama di bundle -> nama tersimpan
+		wantBugHost map[string]string // nama di database -> bug_host ("" = tidak ada)
+	}{
+		{
+			name:        "skip",
+			mode:        BundleConflictSkip,
+			wantActions: map[string]string{"alpha": BundleActionSkip, "beta": BundleActionCreate},
+			wantBugHost: map[string]string{"alpha": "old.example.com", "beta": "beta.example.com"},
+		},
+		{
+			name:        "default mode is skip",
+			mode:        "",
+			wantActions: map[string]string{"alpha": BundleActionSkip, "beta": BundleActionCreate},
+			wantBugHost: map[string]string{"alpha": "old.example.com", "beta": "beta.example.com"},
+		},
+		{
+			name:        "overwrite",
+			mode:        BundleConflictOverwrite,
+			wantActions: map[string]string{"alpha": BundleActionOverwrite, "beta": BundleActionCreate},
+			wantBugHost: map[string]string{"alpha": "new.example.com", "beta": "beta.example.com"},
+		},
+		{
+			name:        "rename",
+			mode:        BundleConflictRename,
+			wantActions: map[string]string{"alpha": BundleActionRename, "beta": BundleActionCreate},
+			wantAs:      map[string]string{"alpha": "alpha_2", "beta": "beta"},
+			wantBugHost: map[string]string{"alpha": "old.example.com", "alpha_2": "new.example.com", "beta": "beta.example.com"},
+		},
+		{
+			name:        "dry run overwrite writes nothing",
+			mode:        BundleConflictOverwrite,
+			dryRun:      true,
+			wantActions: map[string]string{"alpha": BundleActionOverwrite, "beta": BundleActionCreate},
+			wantBugHost: map[string]string{"alpha": "old.example.com", "beta": ""},
+		},
+		{
+			name:        "dry run rename writes nothing",
+			mode:        BundleConflictRename,
+			dryRun:      true,
+			wantActions: map[string]string{"alpha": BundleActionRename, "beta": BundleActionCreate},
+			wantAs:      map[string]string{"alpha": "alpha_2"},
+			wantBugHost: map[string]string{"alpha": "old.example.com", "alpha_2": "", "beta": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := newBundleTestService(t)
+			bundle := &database.XRayConverterBundle{
+				Format:     database.XRayBundleFormat,
+				Version:    database.XRayBundleVersion,
+				Converters: []map[string]interface{}{bundleEntry("alpha", "new.example.com"), bundleEntry("beta", "beta.example.com")},
+			}
+
+			result, err := service.ImportConverters(bundle, tt.mode, tt.dryRun, "6281")
+			if err != nil {
+				t.Fatalf("ImportConverters() error = %v", err)
+			}
+			if result.DryRun != tt.dryRun || result.Invalid != 0 || len(result.Items) != 2 {
+				t.Fatalf("result = %+v", result)
+			}
+
+			for _, item := range result.Items {
+				if item.Action != tt.wantActions[item.CommandName] {
+					t.Errorf("%s: action = %s, want %s", item.CommandName, item.Action, tt.wantActions[item.CommandName])
+				}
+				if want, ok := tt.wantAs[item.CommandName]; ok && item.ImportedAs != want {
+					t.Errorf("%s: imported as %s, want %s", item.CommandName, item.ImportedAs, want)
+				}
+				if item.Action == BundleActionOverwrite && len(item.Diff) == 0 {
+					t.Errorf("%s: overwrite without diff", item.CommandName)
+				}
+			}
+
+			for name, bugHost := range tt.wantBugHost {
+				converter, err := repo.GetXRayConverter(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				switch {
+				case bugHost == "" && converter != nil:
+					t.Errorf("%s: stored although it should not exist", name)
+				case bugHost != "" && converter == nil:
+					t.Errorf("%s: not stored", name)
+				case bugHost != "" && converter.BugHost != bugHost:
+					t.Errorf("%s: bug_host = %s, want %s", name, converter.BugHost, bugHost)
+				}
+			}
+		})
+	}
+}
+
+func TestImportConvertersRejectsInvalidEntries(t *testing.T) {
+	invalidModify := bundleEntry("badtype", "x.example.com")
+	invalidModify["modify_type"] = "teleport"
+
+	tests := []struct {
+		name    string
+		entry   map[string]interface{}
+		wantErr string
+	}{
+		{"empty name", bundleEntry("", "x.example.com"), "command_name"},
+		{"whitespace in name", bundleEntry("bad name", "x.example.com"), "whitespace"},
+		{"leading dot", bundleEntry(".dotted", "x.example.com"), "must not start"},
+		{"menu command", bundleEntry("convert", "x.example.com"), "built-in"},
+		{"menu list command", bundleEntry("Converters", "x.example.com"), "built-in"},
+		{"bundle command", bundleEntry("importconverters", "x.example.com"), "built-in"},
+		{"missing bug host", bundleEntry("nobug", ""), "bug_host"},
+		{"unknown modify type", invalidModify, "modify_type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := newBundleTestService(t)
+			bundle := &database.XRayConverterBundle{Format: database.XRayBundleFormat, Version: database.XRayBundleVersion,
+				Converters: []map[string]interface{}{tt.entry}}
+
+			// Mode rename tetap tidak boleh menyimpan nama yang tidak valid
+			result, err := service.ImportConverters(bundle, BundleConflictRename, false, "6281")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Invalid != 1 || result.Items[0].Action != BundleActionInvalid || !strings.Contains(result.Items[0].Error, tt.wantErr) {
+				t.Fatalf("items = %+v, want invalid with %q", result.Items, tt.wantErr)
+			}
+
+			converters, err := repo.GetAllXRayConverters()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(converters) != 1 {
+				t.Errorf("converters after rejected import = %d, want 1", len(converters))
+			}
+		})
+	}
+}
@@ -0,0 +1,391 @@
+// Package services - struktur Clash/Mihomo dan serialisasi YAML (marshal + validasi)
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// clashProxy satu entry "proxies:" Clash/Mihomo (urutan field = urutan output YAML)
+type clashProxy struct {
+	Name              string            `yaml:"name"`
+	Type              string            `yaml:"type"`
+	Server            string            `yaml:"server"`
+	Port              int               `yaml:"port"`
+	UUID              string            `yaml:"uuid,omitempty"`
+	AlterID           *int              `yaml:"alterId,omitempty"`
+	Password          string            `yaml:"password,omitempty"`
+	Cipher            string            `yaml:"cipher,omitempty"`
+	Flow              string            `yaml:"flow,omitempty"`
+	UDP               bool              `yaml:"udp"`
+	Network           string            `yaml:"network,omitempty"`
+	TLS               *bool             `yaml:"tls,omitempty"`
+	ServerName        string            `yaml:"servername,omitempty"` // vmess/vless
+	SNI               string            `yaml:"sni,omitempty"`        // trojan
+	SkipCertVerify    bool              `yaml:"skip-cert-verify,omitempty"`
+	ClientFingerprint string            `yaml:"client-fingerprint,omitempty"`
+	RealityOpts       *clashRealityOpts `yaml:"reality-opts,omitempty"`
+	WSOpts            *clashHTTPOpts    `yaml:"ws-opts,omitempty"`
+	HTTPUpgradeOpts   *clashHTTPOpts    `yaml:"httpupgrade-opts,omitempty"`
+	H2Opts            *clashH2Opts      `yaml:"h2-opts,omitempty"`
+	HTTPOpts          *clashTCPHTTPOpts `yaml:"http-opts,omitempty"`
+	XHTTPOpts         *clashXHTTPOpts   `yaml:"xhttp-opts,omitempty"`
+	GrpcOpts          *clashGrpcOpts    `yaml:"grpc-opts,omitempty"`
+	Plugin            string            `yaml:"plugin,omitempty"`
+	PluginOpts        *clashPluginOpts  `yaml:"plugin-opts,omitempty"`
+}
+
+// clashRealityOpts opsi REALITY untuk vless
+type clashRealityOpts struct {
+	PublicKey string `yaml:"public-key"`
+	ShortID   string `yaml:"short-id,omitempty"`
+}
+
+// clashHTTPOpts opsi ws-opts / httpupgrade-opts
+type clashHTTPOpts struct {
+	Path    string            `yaml:"path,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// clashH2Opts opsi h2-opts
+type clashH2Opts struct {
+	Host []string `yaml:"host,omitempty"`
+	Path string   `yaml:"path,omitempty"`
+}
+
+// clashTCPHTTPOpts opsi http-opts (tcp dengan header type http)
+type clashTCPHTTPOpts struct {
+	Method  string              `yaml:"method,omitempty"`
+	Path    []string            `yaml:"path,omitempty"`
+	Headers map[string][]string `yaml:"headers,omitempty"`
+}
+
+// clashXHTTPOpts opsi xhttp-opts (vless)
+type clashXHTTPOpts struct {
+	Path string `yaml:"path,omitempty"`
+	Host string `yaml:"host,omitempty"`
+	Mode string `yaml:"mode,omitempty"`
+}
+
+// clashGrpcOpts opsi grpc-opts
+type clashGrpcOpts struct {
+	ServiceName string `yaml:"grpc-service-name"`
+}
+
+// clashPluginOpts plugin-opts untuk Shadowsocks (v2ray-plugin / obfs)
+type clashPluginOpts struct {
+	Mode           string `yaml:"mode,omitempty"`
+	Host           string `yaml:"host,omitempty"`
+	Path           string `yaml:"path,omitempty"`
+	TLS            bool   `yaml:"tls,omitempty"`
+	SkipCertVerify bool   `yaml:"skip-cert-verify,omitempty"`
+}
+
+// clashProxyGroup satu entry "proxy-groups:"
+type clashProxyGroup struct {
+	Name      string   `yaml:"name"`
+	Type      string   `yaml:"type"`
+	Strategy  string   `yaml:"strategy,omitempty"`
+	URL       string   `yaml:"url,omitempty"`
+	Interval  int      `yaml:"interval,omitempty"`
+	Tolerance int      `yaml:"tolerance,omitempty"`
+	Proxies   []string `yaml:"proxies"`
+}
+
+// clashDNS section "dns:" profile Mihomo
+type clashDNS struct {
+	Enable            bool     `yaml:"enable"`
+	Listen            string   `yaml:"listen"`
+	IPv6              bool     `yaml:"ipv6"`
+	EnhancedMode      string   `yaml:"enhanced-mode"`
+	FakeIPRange       string   `yaml:"fake-ip-range,omitempty"`
+	FakeIPFilter      []string `yaml:"fake-ip-filter,omitempty"`
+	DefaultNameserver []string `yaml:"default-nameserver"`
+	Nameserver        []string `yaml:"nameserver"`
+}
+
+// clashProxyList dokumen YAML yang hanya berisi "proxies:"
+type clashProxyList struct {
+	Proxies []*clashProxy `yaml:"proxies"`
+}
+
+// clashProfile profile Mihomo/OpenClash lengkap
+type clashProfile struct {
+	MixedPort     int               `yaml:"mixed-port"`
+	AllowLAN      bool              `yaml:"allow-lan"`
+	Mode          string            `yaml:"mode"`
+	LogLevel      string            `yaml:"log-level"`
+	UnifiedDelay  bool              `yaml:"unified-delay"`
+	DNS           *clashDNS         `yaml:"dns,omitempty"`
+	Proxies       []*clashProxy     `yaml:"proxies"`
+	ProxyGroups   []clashProxyGroup `yaml:"proxy-groups,omitempty"`
+	RuleProviders *yaml.Node        `yaml:"rule-providers,omitempty"`
+	Rules         []string          `yaml:"rules,omitempty"`
+}
+
+// buildClashProxy membuat entry proxy Clash dari config hasil modifikasi.
+// Transport yang tidak ada di Clash/Mihomo (kcp, quic) menghasilkan *UnsupportedTransportError.
+func buildClashProxy(detected *database.DetectedXRayConfig, modifiedConfig map[string]interface{}, proxyName string) (*clashProxy, error) {
+	proxy := &clashProxy{
+		Name: proxyName,
+		Type: detected.Protocol,
+		UDP:  true,
+	}
+
+	// Server and port
+	proxy.Server = getString(modifiedConfig, "add")
+	if proxy.Server == "" {
+		proxy.Server = getString(modifiedConfig, "server")
+	}
+	proxy.Port = detected.Port
+	if port, err := strconv.Atoi(getString(modifiedConfig, "port")); err == nil && port > 0 {
+		proxy.Port = port
+	}
+
+	// Protocol specific configs
+	switch detected.Protocol {
+	case "vmess":
+		alterID := detected.AlterID
+		proxy.UUID = getString(modifiedConfig, "id")
+		if proxy.UUID == "" {
+			proxy.UUID = getString(modifiedConfig, "uuid") // VMESS URL format
+		}
+		proxy.AlterID = &alterID
+		proxy.Cipher = "auto"
+	case "vless":
+		proxy.UUID = getString(modifiedConfig, "uuid")
+		proxy.Flow = detected.Flow
+	case "trojan":
+		proxy.Password = getString(modifiedConfig, "uuid")
+	case "shadowsocks":
+		// Shadowsocks memakai plugin-opts, bukan network/tls seperti protokol lain
+		proxy.Type = "ss"
+		proxy.Password = getString(modifiedConfig, "uuid")
+		proxy.Cipher = getString(modifiedConfig, "cipher")
+		proxy.Plugin, proxy.PluginOpts = clashShadowsocksPlugin(detected, modifiedConfig)
+		return proxy, nil
+	}
+
+	proxy.Network = detected.Network
+
+	// TLS config
+	tls := detected.TLS
+	proxy.TLS = &tls
+	if detected.TLS {
+		sni := getString(modifiedConfig, "sni")
+		if detected.Protocol == "trojan" {
+			proxy.SNI = sni
+		} else {
+			proxy.ServerName = sni
+		}
+
+		proxy.ClientFingerprint = detected.Fingerprint
+		if detected.Security == "reality" {
+			// REALITY wajib memakai uTLS fingerprint di Clash Meta
+			if proxy.ClientFingerprint == "" {
+				proxy.ClientFingerprint = "chrome"
+			}
+			proxy.RealityOpts = &clashRealityOpts{
+				PublicKey: detected.PublicKey,
+				ShortID:   detected.ShortID,
+			}
+		} else {
+			proxy.SkipCertVerify = true
+		}
+	}
+
+	// Network specific options
+	switch detected.Network {
+	case "ws":
+		proxy.WSOpts = clashHTTPOptions(detected, modifiedConfig)
+	case "httpupgrade":
+		proxy.HTTPUpgradeOpts = clashHTTPOptions(detected, modifiedConfig)
+	case "grpc":
+		serviceName := getString(modifiedConfig, "serviceName")
+		if serviceName == "" {
+			serviceName = getString(modifiedConfig, "path") // VMESS JSON menyimpan service name di path
+		}
+		if serviceName == "" {
+			serviceName = "grpc-service"
+		}
+		proxy.GrpcOpts = &clashGrpcOpts{ServiceName: serviceName}
+	case "h2":
+		proxy.H2Opts = &clashH2Opts{
+			Host: splitHosts(getString(modifiedConfig, "host")),
+			Path: modifiedPath(detected, modifiedConfig),
+		}
+	case "xhttp":
+		if detected.Protocol != "vless" {
+			return nil, &UnsupportedTransportError{Client: "Clash/Mihomo", Network: "xhttp (" + detected.Protocol + ")"}
+		}
+		proxy.XHTTPOpts = &clashXHTTPOpts{
+			Path: modifiedPath(detected, modifiedConfig),
+			Host: getString(modifiedConfig, "host"),
+			Mode: detected.Mode,
+		}
+	case "tcp":
+		if detected.HeaderType == "http" {
+			// Clash menyebut tcp + HTTP header obfuscation sebagai network "http"
+			proxy.Network = "http"
+			proxy.HTTPOpts = clashTCPHTTPOptions(detected, modifiedConfig)
+		}
+	case "kcp", "quic":
+		return nil, &UnsupportedTransportError{Client: "Clash/Mihomo", Network: detected.Network}
+	}
+
+	return proxy, nil
+}
+
+// clashTCPHTTPOptions method, path & Host header untuk http-opts
+func clashTCPHTTPOptions(detected *database.DetectedXRayConfig, modifiedConfig map[string]interface{}) *clashTCPHTTPOpts {
+	opts := &clashTCPHTTPOpts{Method: "GET", Path: splitHosts(modifiedPath(detected, modifiedConfig))}
+	if len(opts.Path) == 0 {
+		opts.Path = []string{"/"}
+	}
+	if hosts := splitHosts(getString(modifiedConfig, "host")); len(hosts) > 0 {
+		opts.Headers = map[string][]string{"Host": hosts}
+	}
+	return opts
+}
+
+// clashHTTPOptions path & header Host untuk ws-opts/httpupgrade-opts
+func clashHTTPOptions(detected *database.DetectedXRayConfig, modifiedConfig map[string]interface{}) *clashHTTPOpts {
+	opts := &clashHTTPOpts{Path: modifiedPath(detected, modifiedConfig)}
+	if host := getString(modifiedConfig, "host"); host != "" {
+		opts.Headers = map[string]string{"Host": host}
+	}
+	return opts
+}
+
+// clashShadowsocksPlugin plugin & plugin-opts Clash untuk Shadowsocks
+func clashShadowsocksPlugin(detected *database.DetectedXRayConfig, modifiedConfig map[string]interface{}) (string, *clashPluginOpts) {
+	switch detected.Plugin {
+	case "v2ray-plugin":
+		mode, _ := pluginOptValue(detected.PluginOpts, "mode")
+		if mode == "" {
+			mode = "websocket"
+		}
+		return "v2ray-plugin", &clashPluginOpts{
+			Mode:           mode,
+			Host:           getString(modifiedConfig, "host"),
+			Path:           modifiedPath(detected, modifiedConfig),
+			TLS:            detected.TLS,
+			SkipCertVerify: detected.TLS,
+		}
+	case "obfs-local", "simple-obfs":
+		mode, _ := pluginOptValue(detected.PluginOpts, "obfs")
+		return "obfs", &clashPluginOpts{
+			Mode: mode,
+			Host: getString(modifiedConfig, "host"),
+		}
+	}
+	return "", nil
+}
+
+// marshalClashYAML serialisasi YAML dengan indentasi 2 spasi seperti config Clash pada umumnya
+func marshalClashYAML(value interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ValidateClashYAML parsing ulang YAML hasil generate dan cek field wajib proxy,
+// nama proxy unik, dan proxy-groups hanya merujuk proxy/group yang ada
+func ValidateClashYAML(content string) error {
+	var doc struct {
+		Proxies     []map[string]interface{} `yaml:"proxies"`
+		ProxyGroups []struct {
+			Name    string   `yaml:"name"`
+			Proxies []string `yaml:"proxies"`
+		} `yaml:"proxy-groups"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return fmt.Errorf("invalid YAML: %v", err)
+	}
+	if len(doc.Proxies) == 0 {
+		return fmt.Errorf("invalid YAML: no proxies")
+	}
+
+	names := make(map[string]bool)
+	for i, proxy := range doc.Proxies {
+		name, _ := proxy["name"].(string)
+		if name == "" {
+			return fmt.Errorf("invalid YAML: proxy #%d has no name", i+1)
+		}
+		if names[name] {
+			return fmt.Errorf("invalid YAML: duplicate proxy name %q", name)
+		}
+		names[name] = true
+
+		for _, key := range []string{"type", "server"} {
+			if value, _ := proxy[key].(string); value == "" {
+				return fmt.Errorf("invalid YAML: proxy %q missing %s", name, key)
+			}
+		}
+		if port, ok := proxy["port"].(int); !ok || port <= 0 || port > 65535 {
+			return fmt.Errorf("invalid YAML: proxy %q has invalid port", name)
+		}
+	}
+
+	for _, group := range doc.ProxyGroups {
+		names[group.Name] = true
+	}
+	for _, group := range doc.ProxyGroups {
+		for _, member := range group.Proxies {
+			if !names[member] && member != "DIRECT" && member != "REJECT" {
+				return fmt.Errorf("invalid YAML: group %q references unknown proxy %q", group.Name, member)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseClashProxies membaca kembali "proxies:" dari YAMLConfig setiap hasil konversi
+func parseClashProxies(results []*database.ModifiedXRayConfig) ([]*clashProxy, error) {
+	var proxies []*clashProxy
+	for _, result := range results {
+		if result.YAMLConfig == "" {
+			continue // transport tidak didukung Clash (lihat YAMLError)
+		}
+		var list clashProxyList
+		if err := yaml.Unmarshal([]byte(result.YAMLConfig), &list); err != nil {
+			return nil, fmt.Errorf("invalid YAML for %s: %v", result.ProxyName, err)
+		}
+		proxies = append(proxies, list.Proxies...)
+	}
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("no Clash-compatible proxies")
+	}
+	return proxies, nil
+}
+
+// parseRuleProviders parsing YAML rule-providers template (isi di bawah key "rule-providers:")
+func parseRuleProviders(ruleProviders string) (*yaml.Node, error) {
+	if strings.TrimSpace(ruleProviders) == "" {
+		return nil, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(ruleProviders), &doc); err != nil {
+		return nil, fmt.Errorf("invalid rule-providers YAML: %v", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid rule-providers YAML: expected mapping of provider names")
+	}
+
+	return doc.Content[0], nil
+}
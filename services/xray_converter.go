@@ -2,10 +2,15 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -17,14 +22,46 @@ import (
 type XRayConverterService struct {
 	repository database.Repository
 	logger     *utils.Logger
+	
+	// Registry output formatter (sing-box, xray, surge, dll)
+	formatters     map[string]XRayOutputFormatter
+	formatterOrder []string
+	
+	// Resolver untuk placeholder {bug_ip}
+	resolver HostResolver
+	
+	// Prober untuk cek koneksi hasil konversi
+	prober *XRayProber
+	
+	// Secret HMAC untuk fingerprint kredensial di log (kosong = fingerprint nonaktif)
+	fingerprintSecret []byte
+	
+	// Secret HMAC untuk signature bundle export/import converter (kosong = tanpa signature)
+	bundleSecret []byte
 }
 
 // NewXRayConverterService membuat service baru untuk XRay converter
 func NewXRayConverterService(repo database.Repository, logger *utils.Logger) *XRayConverterService {
-	return &XRayConverterService{
+	service := &XRayConverterService{
 		repository: repo,
 		logger:     logger,
+		formatters: make(map[string]XRayOutputFormatter),
+		resolver:   NewCachingResolver(SystemResolver{}, defaultResolverCacheTTL),
+		prober:     NewXRayProber(defaultProbeTimeout),
 	}
+	service.registerDefaultFormatters()
+	
+	return service
+}
+
+// SetResolver mengganti resolver DNS yang dipakai untuk placeholder {bug_ip}
+func (s *XRayConverterService) SetResolver(resolver HostResolver) {
+	s.resolver = resolver
+}
+
+// SetProber mengganti prober koneksi (misal untuk timeout lain atau dialer khusus)
+func (s *XRayConverterService) SetProber(prober *XRayProber) {
+	s.prober = prober
 }
 
 // DetectXRayConfig mendeteksi dan parse konfigurasi dari XRay link
@@ -100,6 +137,12 @@ func (s *XRayConverterService) parseVMESS(vmessLink string) (*database.DetectedX
 	}
 	if v, ok := vmessConfig["tls"].(string); ok {
 		config.TLS = (v == "tls")
+		if config.TLS {
+			config.Security = "tls"
+		}
+	}
+	if v, ok := vmessConfig["fp"].(string); ok {
+		config.Fingerprint = v
 	}
 	if v, ok := vmessConfig["sni"].(string); ok {
 		config.SNI = v
@@ -121,11 +164,29 @@ func (s *XRayConverterService) parseVMESS(vmessLink string) (*database.DetectedX
 		config.Remarks = v
 	}
 	
-	// gRPC service name extraction
-	if config.Network == "grpc" {
+	if v, ok := vmessConfig["type"].(string); ok {
+		config.HeaderType = normalizeHeaderType(v)
+	}
+	
+	// Transport specific fields: VMESS JSON memakai host/path untuk nilai lain di kcp/quic
+	config.Network = normalizeNetwork(config.Network)
+	switch config.Network {
+	case "grpc":
+		// gRPC service name extraction
 		if v, ok := vmessConfig["path"].(string); ok {
 			config.ServiceName = v
 		}
+	case "kcp":
+		config.Seed = config.Path
+		config.Host, config.Path = "", ""
+	case "quic":
+		config.QUICSecurity = config.Host
+		config.QUICKey = config.Path
+		config.Host, config.Path = "", ""
+	case "xhttp":
+		if v, ok := vmessConfig["mode"].(string); ok {
+			config.Mode = v
+		}
 	}
 	
 	return config, nil
@@ -143,13 +204,7 @@ func (s *XRayConverterService) parseTrojan(trojanLink string) (*database.Detecte
 	return s.parseURLFormat(trojanLink, "trojan")
 }
 
-// parseShadowsocks parsing Shadowsocks link
-func (s *XRayConverterService) parseShadowsocks(ssLink string) (*database.DetectedXRayConfig, error) {
-	// SS format: ss://base64(method:password)@server:port#remarks
-	return s.parseURLFormat(ssLink, "shadowsocks")
-}
-
-// parseURLFormat parsing URL format (VLESS, Trojan, Shadowsocks)
+// parseURLFormat parsing URL format (VLESS, Trojan, VMESS URL)
 func (s *XRayConverterService) parseURLFormat(linkURL, protocol string) (*database.DetectedXRayConfig, error) {
 	// Parse URL
 	parsedURL, err := url.Parse(linkURL)
@@ -181,14 +236,23 @@ func (s *XRayConverterService) parseURLFormat(linkURL, protocol string) (*databa
 	queryParams := parsedURL.Query()
 	
 	// Network type
-	config.Network = queryParams.Get("type")
-	if config.Network == "" {
-		config.Network = "tcp" // Default
-	}
+	config.Network = normalizeNetwork(queryParams.Get("type")) // Default tcp
 	
-	// TLS settings
+	// TLS settings (reality juga berjalan di atas TLS)
 	security := queryParams.Get("security")
-	config.TLS = (security == "tls")
+	config.Security = security
+	config.TLS = (security == "tls" || security == "reality")
+	
+	// XTLS flow & uTLS fingerprint
+	config.Flow = queryParams.Get("flow")
+	config.Fingerprint = queryParams.Get("fp")
+	
+	// REALITY settings
+	if security == "reality" {
+		config.PublicKey = queryParams.Get("pbk")
+		config.ShortID = queryParams.Get("sid")
+		config.SpiderX = queryParams.Get("spx")
+	}
 	
 	// SNI
 	config.SNI = queryParams.Get("sni")
@@ -196,16 +260,19 @@ func (s *XRayConverterService) parseURLFormat(linkURL, protocol string) (*databa
 		config.SNI = queryParams.Get("host")
 	}
 	
-	// Host
+	// Host (kcp/quic tidak punya Host header)
 	config.Host = queryParams.Get("host")
-	if config.Host == "" {
+	if config.Host == "" && transportUsesHostPath(config.Network) {
 		config.Host = config.Server
 	}
 	
-	// Path (for WebSocket)
+	// Path (for WebSocket/HTTPUpgrade/H2/XHTTP)
 	config.Path = queryParams.Get("path")
-	if config.Path == "" && (config.Network == "ws" || config.Network == "httpupgrade") {
-		config.Path = parsedURL.Path
+	if config.Path == "" {
+		switch config.Network {
+		case "ws", "httpupgrade", "h2", "xhttp":
+			config.Path = parsedURL.Path
+		}
 	}
 	// Handle multiple levels of URL encoding (e.g., %252F -> %2F -> /vless)
 	if config.Path != "" {
@@ -227,11 +294,18 @@ func (s *XRayConverterService) parseURLFormat(linkURL, protocol string) (*databa
 		config.ServiceName = queryParams.Get("service")
 	}
 	
-	// KCP header type
+	// Header type (tcp http obfuscation, kcp/quic packet header)
 	config.HeaderType = queryParams.Get("headerType")
 	if config.HeaderType == "" {
 		config.HeaderType = queryParams.Get("header")
 	}
+	config.HeaderType = normalizeHeaderType(config.HeaderType)
+	
+	// KCP seed, QUIC security/key, XHTTP mode
+	config.Seed = queryParams.Get("seed")
+	config.QUICSecurity = queryParams.Get("quicSecurity")
+	config.QUICKey = queryParams.Get("key")
+	config.Mode = queryParams.Get("mode")
 	
 	// Build raw config for reconstruction
 	rawConfig := map[string]interface{}{
@@ -249,6 +323,20 @@ func (s *XRayConverterService) parseURLFormat(linkURL, protocol string) (*databa
 		rawConfig["sni"] = config.SNI
 	}
 	
+	if config.Flow != "" {
+		rawConfig["flow"] = config.Flow
+	}
+	
+	if config.Fingerprint != "" {
+		rawConfig["fp"] = config.Fingerprint
+	}
+	
+	if config.Security == "reality" {
+		rawConfig["pbk"] = config.PublicKey
+		rawConfig["sid"] = config.ShortID
+		rawConfig["spx"] = config.SpiderX
+	}
+	
 	if config.Host != "" {
 		rawConfig["host"] = config.Host
 	}
@@ -289,63 +377,51 @@ func (s *XRayConverterService) ModifyXRayConfig(detected *database.DetectedXRayC
 		DetectedConfig: detected,
 		ModifyType:     converter.ModifyType,
 		BugHost:        converter.BugHost,
+		ProxyName:      fmt.Sprintf("%s-%s-%d", converter.DisplayName, detected.Protocol, detected.Port),
 	}
 	
-	// Process templates with fallback to legacy modify types
-	result.ModifiedServer = s.processTemplate(converter.ServerTemplate, converter, detected)
-	result.ModifiedHost = s.processTemplate(converter.HostTemplate, converter, detected)
-	result.ModifiedSNI = s.processTemplate(converter.SNITemplate, converter, detected)
-	
-	// Fallback to legacy modify types if templates are empty
-	if converter.ServerTemplate == "" && converter.HostTemplate == "" && converter.SNITemplate == "" {
-		switch converter.ModifyType {
-		case "wildcard":
-			result.ModifiedServer = converter.BugHost
-			result.ModifiedHost = fmt.Sprintf("%s.%s", converter.BugHost, detected.Server)
-			result.ModifiedSNI = fmt.Sprintf("%s.%s", converter.BugHost, detected.Server)
-		case "sni":
-			result.ModifiedServer = detected.Server
-			result.ModifiedHost = detected.Host
-			result.ModifiedSNI = converter.BugHost
-		case "ws", "grpc":
-			result.ModifiedServer = converter.BugHost
-			result.ModifiedHost = detected.Host
-			result.ModifiedSNI = detected.SNI
-		}
+	// Jalankan rule engine (template/modify type bawaan + rules converter)
+	values := s.templateValues(converter, detected)
+	fields := newRuleFieldValues(detected)
+	if err := applyConverterRules(fields, converterRules(converter), detected, values); err != nil {
+		return nil, fmt.Errorf("failed to apply converter rules: %v", err)
 	}
+	result.ModifiedServer = fields[RuleFieldServer]
+	result.ModifiedHost = fields[RuleFieldHost]
+	result.ModifiedSNI = fields[RuleFieldSNI]
 	
 	// Update config based on protocol and format
+	original := newRuleFieldValues(detected)
 	if detected.Protocol == "vmess" && isVMESSJSONFormat(detected.RawConfig) {
 		// VMESS JSON format
 		modifiedConfig["add"] = result.ModifiedServer
-		modifiedConfig["host"] = result.ModifiedHost
+		if transportUsesHostPath(detected.Network) {
+			modifiedConfig["host"] = result.ModifiedHost
+		}
 		if detected.TLS {
 			modifiedConfig["sni"] = result.ModifiedSNI
 		}
+		setChangedField(modifiedConfig, "ps", fields, original, RuleFieldRemarks)
+		if detected.Network == "grpc" {
+			// gRPC service name disimpan di field path pada VMESS JSON
+			setChangedField(modifiedConfig, "path", fields, original, RuleFieldServiceName)
+		} else if transportUsesHostPath(detected.Network) {
+			setChangedField(modifiedConfig, "path", fields, original, RuleFieldPath)
+		}
 	} else {
-		// URL format (VLESS, Trojan, VMESS URL)
+		// URL format (VLESS, Trojan, VMESS URL, Shadowsocks)
 		modifiedConfig["server"] = result.ModifiedServer
-		modifiedConfig["host"] = result.ModifiedHost
+		if transportUsesHostPath(detected.Network) {
+			modifiedConfig["host"] = result.ModifiedHost
+		}
 		if detected.TLS {
 			modifiedConfig["sni"] = result.ModifiedSNI
 		}
+		setChangedField(modifiedConfig, "remarks", fields, original, RuleFieldRemarks)
+		setChangedField(modifiedConfig, "path", fields, original, RuleFieldPath)
+		setChangedField(modifiedConfig, "serviceName", fields, original, RuleFieldServiceName)
 	}
-	
-	// Apply path template only for specific modify types that need it
-	// For wildcard and sni, keep original path unless specifically needed
-	if converter.PathTemplate != "" && (detected.Network == "ws" || detected.Network == "httpupgrade" || detected.Network == "h2") {
-		// Only apply path template for "ws" and "grpc" modify types
-		// For "wildcard" and "sni", keep original path to preserve user's configuration
-		if converter.ModifyType == "ws" || converter.ModifyType == "grpc" {
-			modifiedConfig["path"] = converter.PathTemplate
-		}
-		// For wildcard and sni, path stays original (already in modifiedConfig)
-	}
-	
-	// Apply port override if provided
-	if converter.PortOverride != nil {
-		modifiedConfig["port"] = strconv.Itoa(*converter.PortOverride)
-	}
+	setChangedField(modifiedConfig, "port", fields, original, RuleFieldPort)
 	
 	// Generate new link based on protocol and format
 	switch detected.Protocol {
@@ -365,7 +441,13 @@ func (s *XRayConverterService) ModifyXRayConfig(detected *database.DetectedXRayC
 			}
 			result.ModifiedLink = newLink
 		}
-	case "vless", "trojan", "shadowsocks":
+	case "shadowsocks":
+		newLink, err := s.generateShadowsocksLink(modifiedConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate shadowsocks link: %v", err)
+		}
+		result.ModifiedLink = newLink
+	case "vless", "trojan":
 		newLink, err := s.generateURLFormatLink(modifiedConfig, detected.Protocol)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate %s link: %v", detected.Protocol, err)
@@ -375,16 +457,88 @@ func (s *XRayConverterService) ModifyXRayConfig(detected *database.DetectedXRayC
 		return nil, fmt.Errorf("link generation for %s not implemented yet", detected.Protocol)
 	}
 	
-	// Generate YAML config
-	yamlConfig, err := s.generateYAMLConfig(detected, modifiedConfig, converter)
-	if err != nil {
+	// Generate YAML config (transport yang tidak didukung Clash tidak menggagalkan konversi link)
+	yamlConfig, err := s.generateYAMLConfig(detected, modifiedConfig, result.ProxyName)
+	var unsupported *UnsupportedTransportError
+	if errors.As(err, &unsupported) {
+		result.YAMLError = unsupported.Error()
+	} else if err != nil {
 		return nil, fmt.Errorf("failed to generate YAML config: %v", err)
 	}
 	result.YAMLConfig = yamlConfig
 	
+	// Snapshot config hasil modifikasi untuk output formatter
+	result.ModifiedConfig = buildModifiedDetectedConfig(detected, modifiedConfig)
+	
 	return result, nil
 }
 
+// setChangedField menulis field hasil rule ke modified config hanya jika nilainya berubah
+func setChangedField(modifiedConfig map[string]interface{}, key string, fields, original ruleFieldValues, field string) {
+	if fields[field] != original[field] {
+		modifiedConfig[key] = fields[field]
+	}
+}
+
+// buildModifiedDetectedConfig menyalin DetectedXRayConfig dengan nilai dari modified config
+func buildModifiedDetectedConfig(detected *database.DetectedXRayConfig, modifiedConfig map[string]interface{}) *database.DetectedXRayConfig {
+	modified := *detected
+	modified.RawConfig = modifiedConfig
+	
+	if server := getString(modifiedConfig, "add"); server != "" {
+		modified.Server = server
+	} else if server := getString(modifiedConfig, "server"); server != "" {
+		modified.Server = server
+	}
+	if port, err := strconv.Atoi(getString(modifiedConfig, "port")); err == nil && port > 0 {
+		modified.Port = port
+	}
+	// VMESS JSON kcp/quic: host/path berisi seed/security/key, bukan Host header
+	usesHostPath := transportUsesHostPath(detected.Network) || !isVMESSJSONFormat(modifiedConfig)
+	if _, ok := modifiedConfig["host"]; ok && usesHostPath {
+		modified.Host = getString(modifiedConfig, "host")
+	}
+	if detected.TLS {
+		if sni := getString(modifiedConfig, "sni"); sni != "" {
+			modified.SNI = sni
+		}
+	}
+	if _, ok := modifiedConfig["path"]; ok && usesHostPath {
+		modified.Path = modifiedPath(detected, modifiedConfig)
+	}
+	if _, ok := modifiedConfig["serviceName"]; ok {
+		modified.ServiceName = getString(modifiedConfig, "serviceName")
+	} else if detected.Network == "grpc" && isVMESSJSONFormat(modifiedConfig) {
+		// gRPC service name disimpan di field path pada VMESS JSON
+		modified.ServiceName = getString(modifiedConfig, "path")
+	}
+	
+	// Shadowsocks: host/path tersimpan di opsi plugin, v2ray-plugin memakai host sebagai SNI
+	if detected.Protocol == "shadowsocks" {
+		if plugin := buildShadowsocksPlugin(modifiedConfig); plugin != "" {
+			modified.Plugin, modified.PluginOpts = splitShadowsocksPlugin(plugin)
+		} else {
+			modified.Host = modified.Server
+		}
+		if modified.Plugin == "v2ray-plugin" && modified.TLS {
+			modified.SNI = modified.Host
+		}
+	}
+	
+	return &modified
+}
+
+// modifiedPath path hasil modifikasi dalam bentuk ter-decode. Path yang tidak diubah rule masih
+// memakai encoding asli link (misal %252F) agar link hasil generate sama, sedangkan output
+// client (Clash, sing-box, dll) butuh path yang sudah di-decode seperti hasil deteksi.
+func modifiedPath(detected *database.DetectedXRayConfig, modifiedConfig map[string]interface{}) string {
+	path := getString(modifiedConfig, "path")
+	if path == getString(detected.RawConfig, "path") && transportUsesHostPath(detected.Network) {
+		return detected.Path
+	}
+	return path
+}
+
 // generateVMESSLink generate VMESS link dari modified config
 func (s *XRayConverterService) generateVMESSLink(config map[string]interface{}) (string, error) {
 	// Convert to JSON
@@ -399,6 +553,33 @@ func (s *XRayConverterService) generateVMESSLink(config map[string]interface{})
 	return "vmess://" + base64Data, nil
 }
 
+// linkPathEscaper escape karakter path yang akan merusak query string atau fragment link
+var linkPathEscaper = strings.NewReplacer("%", "%25", "#", "%23", "&", "%26", "?", "%3F", " ", "%20", "+", "%2B")
+
+// escapeLinkPath escape path untuk query link: karakter pemutus query/fragment dan karakter kontrol
+// (path hasil decode bertingkat bisa berisi %00 dll yang membuat link tidak bisa di-parse ulang)
+func escapeLinkPath(path string) string {
+	path = linkPathEscaper.Replace(path)
+	if strings.IndexFunc(path, isControlRune) < 0 {
+		return path
+	}
+
+	var builder strings.Builder
+	for _, r := range path {
+		if isControlRune(r) {
+			builder.WriteString(fmt.Sprintf("%%%02X", r))
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// isControlRune karakter kontrol ASCII yang ditolak net/url
+func isControlRune(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
 // generateURLFormatLink generate URL format link (VLESS, Trojan, Shadowsocks)
 func (s *XRayConverterService) generateURLFormatLink(config map[string]interface{}, protocol string) (string, error) {
 	// Extract values from config
@@ -412,7 +593,8 @@ func (s *XRayConverterService) generateURLFormatLink(config map[string]interface
 	}
 	
 	// Build URL
-	linkURL := fmt.Sprintf("%s://%s@%s:%s", protocol, uuid, server, port)
+	// (userinfo di-escape agar password berisi @/#/? tidak memutus link, IPv6 diberi kurung siku)
+	linkURL := fmt.Sprintf("%s://%s@%s", protocol, url.User(uuid).String(), net.JoinHostPort(server, port))
 	
 	// Build query parameters
 	params := url.Values{}
@@ -422,14 +604,17 @@ func (s *XRayConverterService) generateURLFormatLink(config map[string]interface
 		params.Set("type", network)
 	}
 	
-	// Add security
-	if security := getString(config, "security"); security != "" {
+	// Add security (tls/reality), fallback ke tls jika hanya flag tls yang ada
+	security := getString(config, "security")
+	if security == "" && getBool(config, "tls") {
+		security = "tls"
+	}
+	if security != "" {
 		params.Set("security", security)
 	}
 	
 	// Add TLS-related parameters
-	if getBool(config, "tls") || getString(config, "security") == "tls" {
-		params.Set("security", "tls")
+	if security == "tls" || security == "reality" {
 		if sni := getString(config, "sni"); sni != "" {
 			params.Set("sni", sni)
 		}
@@ -451,6 +636,14 @@ func (s *XRayConverterService) generateURLFormatLink(config map[string]interface
 		params.Set("serviceName", serviceName)
 	}
 	
+	// Add XTLS flow, fingerprint dan REALITY parameters apa adanya
+	// (sid boleh berisi "0" sehingga tidak boleh ikut filter di bawah)
+	for _, key := range []string{"flow", "fp", "pbk", "sid", "spx"} {
+		if value := getString(config, key); value != "" {
+			params.Set(key, value)
+		}
+	}
+	
 	// Add other parameters from original config
 	for key, value := range config {
 		keyStr := fmt.Sprintf("%v", key)
@@ -458,7 +651,8 @@ func (s *XRayConverterService) generateURLFormatLink(config map[string]interface
 		
 		// Skip already handled parameters
 		switch keyStr {
-		case "protocol", "server", "port", "uuid", "remarks", "network", "security", "tls", "sni", "host", "path", "serviceName":
+		case "protocol", "server", "port", "uuid", "remarks", "network", "security", "tls", "sni", "host", "path", "serviceName",
+			"flow", "fp", "pbk", "sid", "spx":
 			continue
 		}
 		
@@ -468,14 +662,16 @@ func (s *XRayConverterService) generateURLFormatLink(config map[string]interface
 		}
 	}
 	
-	// Add query parameters to URL manually to avoid double encoding
+	// Add query parameters to URL manually to avoid double encoding.
+	// Urutan tetap: parameter transport utama dulu, sisanya urut abjad
 	if len(params) > 0 {
 		queryParts := make([]string, 0, len(params))
-		for key, values := range params {
-			for _, value := range values {
-				// Special handling for path to avoid re-encoding
+		for _, key := range linkQueryKeys(params) {
+			for _, value := range params[key] {
+				// Special handling for path to avoid re-encoding ("/" tetap terbaca),
+				// hanya karakter yang memutus query/fragment yang di-escape
 				if key == "path" {
-					queryParts = append(queryParts, fmt.Sprintf("%s=%s", key, value))
+					queryParts = append(queryParts, fmt.Sprintf("%s=%s", key, escapeLinkPath(value)))
 				} else {
 					queryParts = append(queryParts, fmt.Sprintf("%s=%s", key, url.QueryEscape(value)))
 				}
@@ -486,12 +682,36 @@ func (s *XRayConverterService) generateURLFormatLink(config map[string]interface
 	
 	// Add remarks as fragment
 	if remarks != "" {
-		linkURL += "#" + url.QueryEscape(remarks)
+		linkURL += "#" + (&url.URL{Fragment: remarks}).EscapedFragment()
 	}
 	
 	return linkURL, nil
 }
 
+// linkQueryOrder urutan parameter utama pada share link hasil generate
+var linkQueryOrder = []string{"type", "security", "sni", "host", "path", "serviceName", "flow", "fp", "pbk", "sid", "spx"}
+
+// linkQueryKeys key query dalam urutan tetap: linkQueryOrder lalu key lain urut abjad
+func linkQueryKeys(params url.Values) []string {
+	keys := make([]string, 0, len(params))
+	known := make(map[string]bool, len(linkQueryOrder))
+	for _, key := range linkQueryOrder {
+		known[key] = true
+		if _, ok := params[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+
+	var others []string
+	for key := range params {
+		if !known[key] {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	return append(keys, others...)
+}
+
 // Helper functions for type conversion
 func getString(config map[string]interface{}, key string) string {
 	if val, ok := config[key]; ok {
@@ -512,138 +732,133 @@ func getBool(config map[string]interface{}, key string) bool {
 	return false
 }
 
-// processTemplate memproses template dengan placeholder
-func (s *XRayConverterService) processTemplate(template string, converter *database.XRayConverter, detected *database.DetectedXRayConfig) string {
+// templatePlaceholders placeholder yang tersedia di server/host/sni/path template:
+//   - {bug_host}         : domain bug host
+//   - {bug_ip}           : IP bug host hasil resolve (fallback ke domain jika gagal)
+//   - {original_server}  : server dari link asli
+//   - {original_host}    : host dari link asli
+//   - {original_sni}     : SNI dari link asli
+//   - {original_port}    : port dari link asli
+//   - {original_path}    : path dari link asli
+//   - {uuid}             : UUID / password dari link asli
+//   - {remarks}          : nama / remarks link asli
+//   - {random_subdomain} : label acak 8 karakter (sama untuk satu konversi)
+var templatePlaceholders = []string{
+	"{bug_host}", "{bug_ip}", "{original_server}", "{original_host}", "{original_sni}",
+	"{original_port}", "{original_path}", "{uuid}", "{remarks}", "{random_subdomain}",
+}
+
+// templateValues menyiapkan nilai placeholder untuk satu konversi.
+// {bug_ip} hanya di-resolve jika dipakai oleh salah satu template.
+func (s *XRayConverterService) templateValues(converter *database.XRayConverter, detected *database.DetectedXRayConfig) map[string]string {
+	values := map[string]string{
+		"{bug_host}":         converter.BugHost,
+		"{bug_ip}":           converter.BugHost,
+		"{original_server}":  detected.Server,
+		"{original_host}":    detected.Host,
+		"{original_sni}":     detected.SNI,
+		"{original_port}":    strconv.Itoa(detected.Port),
+		"{original_path}":    detected.Path,
+		"{uuid}":             detected.UUID,
+		"{remarks}":          detected.Remarks,
+		"{random_subdomain}": randomSubdomain(),
+	}
+	
+	templates := converter.ServerTemplate + converter.HostTemplate + converter.SNITemplate + converter.PathTemplate
+	for _, rule := range converter.Rules {
+		templates += rule.Value
+	}
+	if strings.Contains(templates, "{bug_ip}") && converter.BugHost != "" && s.resolver != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), resolverTimeout)
+		defer cancel()
+		
+		if ip, err := s.resolver.ResolveIP(ctx, converter.BugHost); err == nil {
+			values["{bug_ip}"] = ip
+		} else if s.logger != nil {
+			s.logger.Warningf("Failed to resolve bug host %s, using domain for {bug_ip}: %v", converter.BugHost, err)
+		}
+	}
+	
+	return values
+}
+
+// processTemplate mengganti semua placeholder di template dengan nilainya
+func processTemplate(template string, values map[string]string) string {
 	if template == "" {
 		return ""
 	}
 	
-	// For now, bug_ip same as bug_host (DNS resolution can be added later)
-	bugIP := converter.BugHost
-	
-	// Available placeholders:
-	// {bug_host} - Bug host domain
-	// {bug_ip} - Bug host IP (same as domain for now, can be enhanced)
-	// {original_server} - Original server from XRay link
-	// {original_host} - Original host from XRay link
-	// {original_sni} - Original SNI from XRay link
-	
 	result := template
-	result = strings.ReplaceAll(result, "{bug_host}", converter.BugHost)
-	result = strings.ReplaceAll(result, "{bug_ip}", bugIP)
-	result = strings.ReplaceAll(result, "{original_server}", detected.Server)
-	result = strings.ReplaceAll(result, "{original_host}", detected.Host)
-	result = strings.ReplaceAll(result, "{original_sni}", detected.SNI)
+	for _, placeholder := range templatePlaceholders {
+		result = strings.ReplaceAll(result, placeholder, values[placeholder])
+	}
 	
 	return result
 }
 
+// randomSubdomain membuat label DNS acak (huruf kecil + angka) sepanjang 8 karakter
+func randomSubdomain() string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "sub"
+	}
+	for i, b := range buf {
+		buf[i] = alphabet[int(b)%len(alphabet)]
+	}
+	
+	return string(buf)
+}
+
 // isVMESSJSONFormat check if VMESS config is JSON format (has "add" field)
 func isVMESSJSONFormat(config map[string]interface{}) bool {
 	_, hasAdd := config["add"]
 	return hasAdd
 }
 
-// generateYAMLConfig generate YAML config untuk Clash/OpenClash
-func (s *XRayConverterService) generateYAMLConfig(detected *database.DetectedXRayConfig, modifiedConfig map[string]interface{}, converter *database.XRayConverter) (string, error) {
-	var yamlBuilder strings.Builder
-	
-	// Proxy name
-	proxyName := fmt.Sprintf("%s-%s-%d", converter.DisplayName, detected.Protocol, detected.Port)
-	
-	yamlBuilder.WriteString("proxies:\n")
-	yamlBuilder.WriteString(fmt.Sprintf("  - name: \"%s\"\n", proxyName))
-	yamlBuilder.WriteString(fmt.Sprintf("    type: %s\n", detected.Protocol))
-	yamlBuilder.WriteString("    udp: true\n")
-	
-	// Server and port
-	if server, ok := modifiedConfig["add"].(string); ok {
-		yamlBuilder.WriteString(fmt.Sprintf("    server: %s\n", server))
-	} else if server, ok := modifiedConfig["server"].(string); ok {
-		yamlBuilder.WriteString(fmt.Sprintf("    server: %s\n", server))
-	}
-	
-	port := detected.Port
-	if converter.PortOverride != nil {
-		port = *converter.PortOverride
-	}
-	yamlBuilder.WriteString(fmt.Sprintf("    port: %d\n", port))
-	
-	// Protocol specific configs
-	switch detected.Protocol {
-	case "vmess":
-		if uuid, ok := modifiedConfig["id"].(string); ok {
-			yamlBuilder.WriteString(fmt.Sprintf("    uuid: %s\n", uuid))
-		}
-		yamlBuilder.WriteString(fmt.Sprintf("    alterId: %d\n", detected.AlterID))
-		yamlBuilder.WriteString("    cipher: auto\n")
-	case "vless":
-		if uuid, ok := modifiedConfig["uuid"].(string); ok {
-			yamlBuilder.WriteString(fmt.Sprintf("    uuid: %s\n", uuid))
-		}
-	case "trojan":
-		if password, ok := modifiedConfig["uuid"].(string); ok {
-			yamlBuilder.WriteString(fmt.Sprintf("    password: %s\n", password))
-		}
-	case "shadowsocks":
-		if password, ok := modifiedConfig["uuid"].(string); ok {
-			yamlBuilder.WriteString(fmt.Sprintf("    password: %s\n", password))
-		}
-		if cipher, ok := modifiedConfig["cipher"].(string); ok && cipher != "" {
-			yamlBuilder.WriteString(fmt.Sprintf("    cipher: %s\n", cipher))
-		}
+// generateYAMLConfig generate YAML config untuk Clash/OpenClash (typed struct + YAML encoder)
+func (s *XRayConverterService) generateYAMLConfig(detected *database.DetectedXRayConfig, modifiedConfig map[string]interface{}, proxyName string) (string, error) {
+	proxy, err := buildClashProxy(detected, modifiedConfig, proxyName)
+	if err != nil {
+		return "", err
 	}
 	
-	// Network config
-	yamlBuilder.WriteString(fmt.Sprintf("    network: %s\n", detected.Network))
-	
-	// TLS config
-	if detected.TLS {
-		yamlBuilder.WriteString("    tls: true\n")
-		if sni, ok := modifiedConfig["sni"].(string); ok && sni != "" {
-			yamlBuilder.WriteString(fmt.Sprintf("    servername: %s\n", sni))
-		}
-		yamlBuilder.WriteString("    skip-cert-verify: true\n")
-	} else {
-		yamlBuilder.WriteString("    tls: false\n")
+	yamlConfig, err := marshalClashYAML(clashProxyList{Proxies: []*clashProxy{proxy}})
+	if err != nil {
+		return "", err
 	}
 	
-	// Network specific options
-	switch detected.Network {
-	case "ws":
-		yamlBuilder.WriteString("    ws-opts:\n")
-		if path, ok := modifiedConfig["path"].(string); ok && path != "" {
-			yamlBuilder.WriteString(fmt.Sprintf("      path: %s\n", path))
-		}
-		if host, ok := modifiedConfig["host"].(string); ok && host != "" {
-			yamlBuilder.WriteString("      headers:\n")
-			yamlBuilder.WriteString(fmt.Sprintf("        Host: %s\n", host))
-		}
-		
-	case "grpc":
-		yamlBuilder.WriteString("    grpc-opts:\n")
-		serviceName := "grpc-service"
-		if path, ok := modifiedConfig["path"].(string); ok && path != "" {
-			serviceName = path
-		}
-		yamlBuilder.WriteString(fmt.Sprintf("      grpc-service-name: \"%s\"\n", serviceName))
-		
-	case "httpupgrade":
-		yamlBuilder.WriteString("    httpupgrade-opts:\n")
-		if path, ok := modifiedConfig["path"].(string); ok && path != "" {
-			yamlBuilder.WriteString(fmt.Sprintf("      path: %s\n", path))
-		}
-		if host, ok := modifiedConfig["host"].(string); ok && host != "" {
-			yamlBuilder.WriteString("      headers:\n")
-			yamlBuilder.WriteString(fmt.Sprintf("        Host: %s\n", host))
-		}
+	// Pastikan YAML bisa dibaca ulang sebelum dikirim ke user
+	if err := ValidateClashYAML(yamlConfig); err != nil {
+		return "", err
 	}
 	
-	return yamlBuilder.String(), nil
+	return yamlConfig, nil
 }
 
-// ProcessConversion memproses conversion lengkap dari XRay link
+// ProcessConversion memproses conversion lengkap dari XRay link dengan format output default converter
 func (s *XRayConverterService) ProcessConversion(converterName, xrayLink, userJID, groupJID string) (*database.ModifiedXRayConfig, error) {
+	return s.ProcessConversionWithFormats(converterName, xrayLink, userJID, groupJID, nil)
+}
+
+// ProcessConversionWithFormats memproses conversion dan merender format output yang diminta.
+// Jika formats kosong, dipakai OutputFormats milik converter.
+func (s *XRayConverterService) ProcessConversionWithFormats(converterName, xrayLink, userJID, groupJID string, formats []string) (*database.ModifiedXRayConfig, error) {
+	return s.ProcessConversionWithOptions(converterName, xrayLink, userJID, groupJID, XRayConversionOptions{Formats: formats})
+}
+
+// XRayConversionOptions opsi tambahan untuk satu konversi
+type XRayConversionOptions struct {
+	Formats []string // format output, kosong = OutputFormats converter
+	Probe   bool     // probe koneksi walaupun converter tidak mengaktifkan ProbeEnabled
+}
+
+// ProcessConversionWithOptions memproses conversion, merender format output, dan menjalankan
+// probe koneksi jika diminta atau diaktifkan di converter
+func (s *XRayConverterService) ProcessConversionWithOptions(converterName, xrayLink, userJID, groupJID string, options XRayConversionOptions) (*database.ModifiedXRayConfig, error) {
+	formats := options.Formats
+	
 	// Get converter config
 	converter, err := s.repository.GetXRayConverter(converterName)
 	if err != nil {
@@ -658,6 +873,19 @@ func (s *XRayConverterService) ProcessConversion(converterName, xrayLink, userJI
 		return nil, fmt.Errorf("converter is inactive: %s", converterName)
 	}
 	
+	// Cek kuota user/grup/global sebelum konversi
+	if err := s.checkConversionQuota(converter, userJID, groupJID); err != nil {
+		return nil, err
+	}
+	
+	// Validasi format output sebelum konversi
+	if len(formats) == 0 {
+		formats = ParseFormatList(converter.OutputFormats)
+	}
+	if err := s.ValidateFormats(formats); err != nil {
+		return nil, err
+	}
+	
 	// Detect XRay config
 	detected, err := s.DetectXRayConfig(xrayLink)
 	if err != nil {
@@ -667,6 +895,7 @@ func (s *XRayConverterService) ProcessConversion(converterName, xrayLink, userJI
 			ConverterName:    converterName,
 			UserJID:          userJID,
 			GroupJID:         groupJID,
+			ConverterVersionID: converter.VersionID,
 			OriginalProtocol: "unknown",
 			OriginalNetwork:  "",
 			OriginalServer:   "",
@@ -674,7 +903,7 @@ func (s *XRayConverterService) ProcessConversion(converterName, xrayLink, userJI
 			Success:          false,
 			ErrorMessage:     &errMsg,
 		}
-		s.repository.LogXRayConversion(logEntry)
+		s.logConversion(logEntry, nil)
 		
 		return nil, fmt.Errorf("failed to detect XRay config: %v", err)
 	}
@@ -688,6 +917,7 @@ func (s *XRayConverterService) ProcessConversion(converterName, xrayLink, userJI
 			ConverterName:    converterName,
 			UserJID:          userJID,
 			GroupJID:         groupJID,
+			ConverterVersionID: converter.VersionID,
 			OriginalProtocol: detected.Protocol,
 			OriginalNetwork:  detected.Network,
 			OriginalServer:   detected.Server,
@@ -695,23 +925,71 @@ func (s *XRayConverterService) ProcessConversion(converterName, xrayLink, userJI
 			Success:          false,
 			ErrorMessage:     &errMsg,
 		}
-		s.repository.LogXRayConversion(logEntry)
+		s.logConversion(logEntry, detected)
 		
 		return nil, fmt.Errorf("failed to modify XRay config: %v", err)
 	}
 	
+	// Profile Mihomo lengkap jika converter memakai profile template
+	s.attachRuleTemplate(result, converter)
+	if result.RuleTemplate != nil && result.YAMLConfig != "" {
+		profile, err := BuildClashProfile([]*database.ModifiedXRayConfig{result}, result.RuleTemplate)
+		if err != nil {
+			// Log conversion failure (YAML profile tidak valid)
+			errMsg := err.Error()
+			logEntry := &database.XRayConversionLog{
+				ConverterName:    converterName,
+				UserJID:          userJID,
+				GroupJID:         groupJID,
+				ConverterVersionID: converter.VersionID,
+				OriginalProtocol: detected.Protocol,
+				OriginalNetwork:  detected.Network,
+				OriginalServer:   detected.Server,
+				ModifiedServer:   result.ModifiedServer,
+				Success:          false,
+				ErrorMessage:     &errMsg,
+			}
+			s.logConversion(logEntry, detected)
+			
+			return nil, fmt.Errorf("failed to generate Mihomo profile: %v", err)
+		}
+		result.ProfileConfig = profile
+	}
+	
+	// Render output tambahan (sing-box, surge, dll)
+	if len(formats) > 0 {
+		if err := s.RenderOutputs(result, formats); err != nil {
+			return nil, err
+		}
+	}
+	
+	// Probe koneksi ke server hasil modifikasi
+	if options.Probe || converter.ProbeEnabled {
+		result.Probe = s.prober.Probe(context.Background(), result.ModifiedConfig)
+	}
+	
 	// Log successful conversion
 	logEntry := &database.XRayConversionLog{
 		ConverterName:    converterName,
 		UserJID:          userJID,
 		GroupJID:         groupJID,
+		ConverterVersionID: converter.VersionID,
 		OriginalProtocol: detected.Protocol,
 		OriginalNetwork:  detected.Network,
 		OriginalServer:   detected.Server,
 		ModifiedServer:   result.ModifiedServer,
 		Success:          true,
 	}
-	s.repository.LogXRayConversion(logEntry)
+	if result.Probe != nil {
+		logEntry.ProbeLatencyMs = result.Probe.LatencyMs
+		if result.Probe.Success {
+			logEntry.ProbeStatus = "ok"
+		} else {
+			logEntry.ProbeStatus = "failed"
+			logEntry.ProbeError = fmt.Sprintf("%s: %s", result.Probe.Stage, result.Probe.Error)
+		}
+	}
+	s.logConversion(logEntry, detected)
 	
 	// Increment usage count
 	s.repository.IncrementConverterUsage(converterName)
@@ -741,9 +1019,19 @@ func (s *XRayConverterService) UpdateConverter(converter *database.XRayConverter
 	return s.repository.UpdateXRayConverter(converter)
 }
 
-// DeleteConverter hapus converter
-func (s *XRayConverterService) DeleteConverter(commandName string) error {
-	return s.repository.DeleteXRayConverter(commandName)
+// DeleteConverter hapus converter (snapshot terakhir tetap tersimpan di riwayat versi)
+func (s *XRayConverterService) DeleteConverter(commandName, deletedBy string) error {
+	return s.repository.DeleteXRayConverter(commandName, deletedBy)
+}
+
+// GetConverterHistory riwayat versi converter, terbaru lebih dulu
+func (s *XRayConverterService) GetConverterHistory(commandName string) ([]database.XRayConverterVersion, error) {
+	return s.repository.GetXRayConverterVersions(commandName)
+}
+
+// RollbackConverter mengembalikan converter ke snapshot versi tertentu
+func (s *XRayConverterService) RollbackConverter(versionID int, changedBy string) (*database.XRayConverter, error) {
+	return s.repository.RollbackXRayConverter(versionID, changedBy)
 }
 
 // GetConversionStats mendapatkan statistik conversion
@@ -0,0 +1,87 @@
+// Package services - mode pengiriman hasil konversi XRay (grup, DM, atau DM + ack di grup)
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mode pengiriman hasil konversi
+const (
+	DeliveryModeGroup = "group"  // balas di grup (perilaku lama)
+	DeliveryModeDM    = "dm"     // kirim hanya ke chat pribadi user
+	DeliveryModeDMAck = "dm_ack" // kirim ke chat pribadi + konfirmasi singkat di grup
+)
+
+// deliveryModes daftar mode yang valid
+var deliveryModes = []string{DeliveryModeGroup, DeliveryModeDM, DeliveryModeDMAck}
+
+// XRayDelivery cara mengirim hasil konversi untuk satu converter di satu grup
+type XRayDelivery struct {
+	Mode           string
+	YAMLAsDocument bool
+}
+
+// Private true jika hasil harus dikirim ke chat pribadi user
+func (d XRayDelivery) Private() bool {
+	return d.Mode == DeliveryModeDM || d.Mode == DeliveryModeDMAck
+}
+
+// ValidateDeliveryMode memastikan mode pengiriman dikenali (kosong = ikut default)
+func ValidateDeliveryMode(mode string) error {
+	if mode == "" {
+		return nil
+	}
+	for _, known := range deliveryModes {
+		if mode == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown delivery mode %q (tersedia: %s)", mode, strings.Join(deliveryModes, ", "))
+}
+
+// ResolveDelivery menentukan mode pengiriman: setting converter lebih diutamakan,
+// lalu setting grup, default balas di grup
+func (s *XRayConverterService) ResolveDelivery(converterName, groupJID string) XRayDelivery {
+	delivery := XRayDelivery{Mode: DeliveryModeGroup}
+
+	converter, err := s.repository.GetXRayConverter(converterName)
+	if err != nil {
+		s.logger.Warningf("Failed to get converter %s for delivery: %v", converterName, err)
+	}
+	if converter != nil {
+		delivery.YAMLAsDocument = converter.YAMLAsDocument
+		if converter.DeliveryMode != "" {
+			delivery.Mode = converter.DeliveryMode
+			return delivery
+		}
+	}
+
+	group, err := s.repository.GetLearningGroup(groupJID)
+	if err != nil {
+		s.logger.Warningf("Failed to get group %s for delivery: %v", groupJID, err)
+	}
+	if group != nil && group.XRayDeliveryMode != "" {
+		delivery.Mode = group.XRayDeliveryMode
+	}
+
+	return delivery
+}
+
+// SetGroupDeliveryMode mengatur mode pengiriman default hasil konversi di grup
+func (s *XRayConverterService) SetGroupDeliveryMode(groupJID, mode string) error {
+	if err := ValidateDeliveryMode(mode); err != nil {
+		return err
+	}
+
+	group, err := s.repository.GetLearningGroup(groupJID)
+	if err != nil {
+		return err
+	}
+	if group == nil {
+		return fmt.Errorf("grup belum terdaftar: %s", groupJID)
+	}
+
+	group.XRayDeliveryMode = mode
+	return s.repository.UpdateLearningGroup(group)
+}
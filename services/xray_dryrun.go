@@ -0,0 +1,174 @@
+// Package services - dry-run converter: diff field asli vs hasil, peringatan, dan preview pesan chat
+package services
+
+import (
+	"fmt"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// dryRunFields field yang dibandingkan pada dry-run (urutan = urutan tampilan)
+var dryRunFields = []string{
+	RuleFieldServer, RuleFieldHost, RuleFieldSNI, RuleFieldPath, RuleFieldPort, RuleFieldServiceName,
+}
+
+// XRayFieldDiff nilai satu field sebelum dan sesudah konversi
+type XRayFieldDiff struct {
+	Field    string `json:"field"`
+	Original string `json:"original"`
+	Modified string `json:"modified"`
+	Changed  bool   `json:"changed"`
+}
+
+// XRayDryRunResult hasil dry-run converter. Tidak ada log, kuota, atau usage yang tercatat.
+type XRayDryRunResult struct {
+	ConverterName string                       `json:"converter_name"`
+	DisplayName   string                       `json:"display_name"`
+	Result        *database.ModifiedXRayConfig `json:"result"`
+	Diff          []XRayFieldDiff              `json:"diff"`
+	Warnings      []string                     `json:"warnings"`
+	Messages      []XRayChatMessage            `json:"messages"` // pesan persis seperti yang diterima user
+}
+
+// ChangedFields jumlah field yang berubah
+func (r *XRayDryRunResult) ChangedFields() int {
+	changed := 0
+	for _, diff := range r.Diff {
+		if diff.Changed {
+			changed++
+		}
+	}
+	return changed
+}
+
+// DryRunConversion menjalankan converter terhadap link tanpa mencatat log, kuota, maupun usage.
+// Converter nonaktif tetap bisa dicoba (dengan peringatan). groupJID dipakai untuk menentukan
+// mode pengiriman pada preview pesan (boleh kosong).
+func (s *XRayConverterService) DryRunConversion(converterName, xrayLink, groupJID string) (*XRayDryRunResult, error) {
+	converter, err := s.repository.GetXRayConverter(converterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get converter: %v", err)
+	}
+	if converter == nil {
+		return nil, fmt.Errorf("converter not found: %s", converterName)
+	}
+
+	formats := ParseFormatList(converter.OutputFormats)
+	if err := s.ValidateFormats(formats); err != nil {
+		return nil, err
+	}
+
+	detected, err := s.DetectXRayConfig(xrayLink)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect XRay config: %v", err)
+	}
+
+	result, err := s.ModifyXRayConfig(detected, converter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to modify XRay config: %v", err)
+	}
+
+	dryRun := &XRayDryRunResult{
+		ConverterName: converter.CommandName,
+		DisplayName:   s.ConverterDisplayName(converter.CommandName),
+		Result:        result,
+		Diff:          diffConversionFields(detected, result.ModifiedConfig),
+	}
+
+	s.attachRuleTemplate(result, converter)
+	if result.RuleTemplate != nil && result.YAMLConfig != "" {
+		profile, err := BuildClashProfile([]*database.ModifiedXRayConfig{result}, result.RuleTemplate)
+		if err != nil {
+			dryRun.Warnings = append(dryRun.Warnings, fmt.Sprintf("Profile Mihomo gagal dibuat, user akan menerima error: %v", err))
+		}
+		result.ProfileConfig = profile
+	}
+
+	if len(formats) > 0 {
+		if err := s.RenderOutputs(result, formats); err != nil {
+			return nil, err
+		}
+	}
+
+	dryRun.Warnings = append(dryRun.Warnings, dryRunWarnings(converter, detected, result, dryRun)...)
+	dryRun.Messages = BuildConversionMessages(result, dryRun.DisplayName, converter.CommandName, s.ResolveDelivery(converter.CommandName, groupJID))
+
+	return dryRun, nil
+}
+
+// diffConversionFields membandingkan field config asli dengan config hasil modifikasi
+func diffConversionFields(original, modified *database.DetectedXRayConfig) []XRayFieldDiff {
+	before := newRuleFieldValues(original)
+	after := newRuleFieldValues(modified)
+
+	diffs := make([]XRayFieldDiff, 0, len(dryRunFields))
+	for _, field := range dryRunFields {
+		diffs = append(diffs, XRayFieldDiff{
+			Field:    field,
+			Original: before[field],
+			Modified: after[field],
+			Changed:  before[field] != after[field],
+		})
+	}
+	return diffs
+}
+
+// dryRunWarnings mendeteksi hasil konversi yang mencurigakan (modify type tidak berpengaruh, dll)
+func dryRunWarnings(converter *database.XRayConverter, detected *database.DetectedXRayConfig, result *database.ModifiedXRayConfig, dryRun *XRayDryRunResult) []string {
+	var warnings []string
+	changed := make(map[string]bool)
+	for _, diff := range dryRun.Diff {
+		changed[diff.Field] = diff.Changed
+	}
+
+	if !converter.IsActive {
+		warnings = append(warnings, "Converter nonaktif: user belum bisa memakai converter ini")
+	}
+	if dryRun.ChangedFields() == 0 {
+		warnings = append(warnings, "Tidak ada field yang berubah: link hasil sama dengan link asli")
+	}
+
+	switch converter.ModifyType {
+	case "sni":
+		if !detected.TLS {
+			warnings = append(warnings, "Link tanpa TLS: modify type sni tidak berpengaruh")
+		} else if !changed[RuleFieldSNI] {
+			warnings = append(warnings, "SNI tidak berubah padahal modify type sni")
+		}
+	case "wildcard":
+		if !changed[RuleFieldServer] {
+			warnings = append(warnings, "Server tidak berubah padahal modify type wildcard")
+		}
+		if transportUsesHostPath(detected.Network) && !changed[RuleFieldHost] {
+			warnings = append(warnings, "Host tidak berubah padahal modify type wildcard")
+		}
+		if detected.TLS && !changed[RuleFieldSNI] {
+			warnings = append(warnings, "SNI tidak berubah padahal modify type wildcard")
+		}
+	case "ws":
+		if detected.Network != "ws" && detected.Network != "httpupgrade" {
+			warnings = append(warnings, fmt.Sprintf("Modify type ws dipakai pada network %s", detected.Network))
+		}
+	case "grpc":
+		if detected.Network != "grpc" {
+			warnings = append(warnings, fmt.Sprintf("Modify type grpc dipakai pada network %s", detected.Network))
+		}
+	}
+
+	if detected.TLS && result.ModifiedConfig.SNI == "" {
+		warnings = append(warnings, "SNI kosong pada link TLS")
+	}
+	if detected.Security == "reality" && changed[RuleFieldSNI] {
+		warnings = append(warnings, "SNI REALITY diubah: server hanya menerima serverName yang terdaftar, koneksi kemungkinan gagal")
+	}
+	if result.YAMLError != "" {
+		warnings = append(warnings, "Clash/OpenClash: "+result.YAMLError)
+	}
+	for _, output := range result.Outputs {
+		if output.Error != "" {
+			warnings = append(warnings, fmt.Sprintf("Output %s: %s", output.Label, output.Error))
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,650 @@
+// Package services - output formatter untuk hasil konversi XRay (sing-box, Xray JSON, v2rayN, Surge, QuantumultX)
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// XRayOutputFormatter merender hasil modifikasi ke format konfigurasi client tertentu
+type XRayOutputFormatter interface {
+	// Name nama format yang dipakai di --format dan kolom output_formats
+	Name() string
+	// Label nama format yang ditampilkan ke user
+	Label() string
+	// Format merender hasil modifikasi, error jika config tidak didukung format ini
+	Format(result *database.ModifiedXRayConfig) (string, error)
+}
+
+// formatterFunc adapter sederhana agar formatter bisa ditulis sebagai fungsi
+type formatterFunc struct {
+	name   string
+	label  string
+	format func(result *database.ModifiedXRayConfig) (string, error)
+}
+
+func (f formatterFunc) Name() string  { return f.name }
+func (f formatterFunc) Label() string { return f.label }
+func (f formatterFunc) Format(result *database.ModifiedXRayConfig) (string, error) {
+	return f.format(result)
+}
+
+// formatAliases nama lain yang diterima dari user
+var formatAliases = map[string]string{
+	"sing-box":    "singbox",
+	"nekobox":     "singbox",
+	"xray-json":   "xray",
+	"xrayjson":    "xray",
+	"v2ray":       "xray",
+	"profile":     "mihomo",
+	"full":        "mihomo",
+	"openclash":   "clash",
+	"yaml":        "clash",
+	"qx":          "quanx",
+	"quantumult":  "quanx",
+	"quantumultx": "quanx",
+}
+
+// registerDefaultFormatters mendaftarkan formatter bawaan
+func (s *XRayConverterService) registerDefaultFormatters() {
+	s.RegisterFormatter(formatterFunc{"link", "Share Link", formatShareLink})
+	s.RegisterFormatter(formatterFunc{"clash", "Clash/OpenClash YAML", formatClashYAML})
+	s.RegisterFormatter(formatterFunc{"mihomo", "Mihomo/OpenClash Profile", formatMihomoProfile})
+	s.RegisterFormatter(formatterFunc{"singbox", "sing-box / NekoBox Outbound", formatSingBox})
+	s.RegisterFormatter(formatterFunc{"xray", "Xray Client config.json", formatXrayClientJSON})
+	s.RegisterFormatter(formatterFunc{"v2rayn", "v2rayN JSON", formatV2rayNJSON})
+	s.RegisterFormatter(formatterFunc{"surge", "Surge", formatSurge})
+	s.RegisterFormatter(formatterFunc{"quanx", "QuantumultX", formatQuantumultX})
+}
+
+// RegisterFormatter mendaftarkan (atau mengganti) output formatter
+func (s *XRayConverterService) RegisterFormatter(formatter XRayOutputFormatter) {
+	name := formatter.Name()
+	if _, exists := s.formatters[name]; !exists {
+		s.formatterOrder = append(s.formatterOrder, name)
+	}
+	s.formatters[name] = formatter
+}
+
+// GetFormatter mendapatkan formatter berdasarkan nama atau alias
+func (s *XRayConverterService) GetFormatter(name string) (XRayOutputFormatter, bool) {
+	formatter, ok := s.formatters[normalizeFormatName(name)]
+	return formatter, ok
+}
+
+// GetFormatterNames daftar nama formatter sesuai urutan pendaftaran
+func (s *XRayConverterService) GetFormatterNames() []string {
+	names := make([]string, len(s.formatterOrder))
+	copy(names, s.formatterOrder)
+	return names
+}
+
+// ValidateFormats memastikan semua format yang diminta terdaftar
+func (s *XRayConverterService) ValidateFormats(formats []string) error {
+	for _, name := range formats {
+		if _, ok := s.GetFormatter(name); !ok {
+			return fmt.Errorf("unknown output format: %s (tersedia: %s)", name, strings.Join(s.formatterOrder, ", "))
+		}
+	}
+	return nil
+}
+
+// RenderOutputs merender hasil konversi ke format-format yang diminta
+func (s *XRayConverterService) RenderOutputs(result *database.ModifiedXRayConfig, formats []string) error {
+	var outputs []database.XRayFormattedOutput
+
+	for _, name := range formats {
+		formatter, ok := s.GetFormatter(name)
+		if !ok {
+			return fmt.Errorf("unknown output format: %s", name)
+		}
+
+		output := database.XRayFormattedOutput{
+			Format: formatter.Name(),
+			Label:  formatter.Label(),
+		}
+		content, err := formatter.Format(result)
+		if err != nil {
+			output.Error = err.Error()
+		} else {
+			output.Content = content
+		}
+		outputs = append(outputs, output)
+	}
+
+	result.Outputs = outputs
+	return nil
+}
+
+// ParseFormatList parsing daftar format dari string "singbox,surge" atau "singbox surge"
+func ParseFormatList(formats string) []string {
+	var result []string
+	seen := make(map[string]bool)
+
+	fields := strings.FieldsFunc(formats, func(r rune) bool {
+		return r == ',' || r == ' ' || r == ';'
+	})
+	for _, field := range fields {
+		name := normalizeFormatName(field)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+
+	return result
+}
+
+// normalizeFormatName lowercase dan resolve alias format
+func normalizeFormatName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := formatAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// === BUILT-IN FORMATTERS ===
+
+func formatShareLink(result *database.ModifiedXRayConfig) (string, error) {
+	return result.ModifiedLink, nil
+}
+
+func formatClashYAML(result *database.ModifiedXRayConfig) (string, error) {
+	if result.YAMLConfig == "" && result.YAMLError != "" {
+		return "", fmt.Errorf("%s", result.YAMLError)
+	}
+	return result.YAMLConfig, nil
+}
+
+func formatMihomoProfile(result *database.ModifiedXRayConfig) (string, error) {
+	if result.ProfileConfig != "" {
+		return result.ProfileConfig, nil
+	}
+	return BuildClashProfile([]*database.ModifiedXRayConfig{result}, result.RuleTemplate)
+}
+
+func formatSingBox(result *database.ModifiedXRayConfig) (string, error) {
+	outbound, err := singBoxOutbound(result.ModifiedConfig, result.ProxyName)
+	if err != nil {
+		return "", err
+	}
+	return marshalIndentJSON(outbound)
+}
+
+func formatXrayClientJSON(result *database.ModifiedXRayConfig) (string, error) {
+	outbound, err := xrayOutbound(result.ModifiedConfig, "proxy")
+	if err != nil {
+		return "", err
+	}
+
+	clientConfig := map[string]interface{}{
+		"log": map[string]interface{}{"loglevel": "warning"},
+		"inbounds": []interface{}{
+			map[string]interface{}{
+				"tag":      "socks",
+				"listen":   "127.0.0.1",
+				"port":     10808,
+				"protocol": "socks",
+				"settings": map[string]interface{}{"udp": true},
+				"sniffing": map[string]interface{}{"enabled": true, "destOverride": []string{"http", "tls"}},
+			},
+			map[string]interface{}{
+				"tag":      "http",
+				"listen":   "127.0.0.1",
+				"port":     10809,
+				"protocol": "http",
+			},
+		},
+		"outbounds": []interface{}{
+			outbound,
+			map[string]interface{}{"tag": "direct", "protocol": "freedom"},
+			map[string]interface{}{"tag": "block", "protocol": "blackhole"},
+		},
+		"routing": map[string]interface{}{
+			"domainStrategy": "AsIs",
+			"rules": []interface{}{
+				map[string]interface{}{"type": "field", "ip": []string{"geoip:private"}, "outboundTag": "direct"},
+			},
+		},
+	}
+
+	return marshalIndentJSON(clientConfig)
+}
+
+func formatV2rayNJSON(result *database.ModifiedXRayConfig) (string, error) {
+	c := result.ModifiedConfig
+	if c.Protocol != "vmess" {
+		return "", fmt.Errorf("v2rayN JSON hanya untuk vmess, gunakan share link untuk %s", c.Protocol)
+	}
+
+	headerType := c.HeaderType
+	if headerType == "" {
+		headerType = "none"
+	}
+	host, path := c.Host, c.Path
+	switch c.Network {
+	case "grpc":
+		if c.ServiceName != "" {
+			path = c.ServiceName
+		}
+	case "kcp":
+		path = c.Seed
+	case "quic":
+		host, path = c.QUICSecurity, c.QUICKey
+	}
+	tls := ""
+	if c.TLS {
+		tls = "tls"
+	}
+
+	v2rayN := map[string]interface{}{
+		"v":    "2",
+		"ps":   result.ProxyName,
+		"add":  c.Server,
+		"port": strconv.Itoa(c.Port),
+		"id":   c.UUID,
+		"aid":  strconv.Itoa(c.AlterID),
+		"scy":  "auto",
+		"net":  c.Network,
+		"type": headerType,
+		"host": host,
+		"path": path,
+		"tls":  tls,
+		"sni":  c.SNI,
+		"fp":   c.Fingerprint,
+	}
+
+	return marshalIndentJSON(v2rayN)
+}
+
+func formatSurge(result *database.ModifiedXRayConfig) (string, error) {
+	c := result.ModifiedConfig
+	if c.Network != "tcp" && c.Network != "ws" {
+		return "", fmt.Errorf("surge tidak mendukung network %s", c.Network)
+	}
+
+	parts := []string{}
+	switch c.Protocol {
+	case "vmess":
+		parts = append(parts, "vmess", c.Server, strconv.Itoa(c.Port), "username="+c.UUID)
+		if c.AlterID == 0 {
+			parts = append(parts, "vmess-aead=true")
+		}
+	case "trojan":
+		parts = append(parts, "trojan", c.Server, strconv.Itoa(c.Port), "password="+c.UUID)
+	case "shadowsocks":
+		parts = append(parts, "ss", c.Server, strconv.Itoa(c.Port), "encrypt-method="+c.Cipher, "password="+c.UUID)
+		switch c.Plugin {
+		case "":
+		case "obfs-local", "simple-obfs":
+			if c.HeaderType != "" {
+				parts = append(parts, "obfs="+c.HeaderType)
+			}
+			if c.Host != "" {
+				parts = append(parts, "obfs-host="+c.Host)
+			}
+			return fmt.Sprintf("%s = %s", result.ProxyName, strings.Join(parts, ", ")), nil
+		default:
+			return "", fmt.Errorf("surge tidak mendukung plugin %s", c.Plugin)
+		}
+	default:
+		return "", fmt.Errorf("surge tidak mendukung protocol %s", c.Protocol)
+	}
+
+	if c.Security == "reality" {
+		return "", fmt.Errorf("surge tidak mendukung REALITY")
+	}
+	if c.TLS || c.Protocol == "trojan" {
+		if c.Protocol != "trojan" {
+			parts = append(parts, "tls=true")
+		}
+		if c.SNI != "" {
+			parts = append(parts, "sni="+c.SNI)
+		}
+		parts = append(parts, "skip-cert-verify=true")
+	}
+	if c.Network == "ws" {
+		parts = append(parts, "ws=true")
+		if c.Path != "" {
+			parts = append(parts, "ws-path="+c.Path)
+		}
+		if c.Host != "" {
+			parts = append(parts, fmt.Sprintf("ws-headers=Host:\"%s\"", c.Host))
+		}
+	}
+
+	return fmt.Sprintf("%s = %s", result.ProxyName, strings.Join(parts, ", ")), nil
+}
+
+func formatQuantumultX(result *database.ModifiedXRayConfig) (string, error) {
+	c := result.ModifiedConfig
+	if c.Security == "reality" || c.Flow != "" {
+		return "", fmt.Errorf("quantumultX tidak mendukung REALITY/XTLS flow")
+	}
+
+	address := net.JoinHostPort(c.Server, strconv.Itoa(c.Port))
+	parts := []string{}
+
+	switch c.Protocol {
+	case "vmess":
+		parts = append(parts, "vmess="+address, "method=chacha20-ietf-poly1305", "password="+c.UUID)
+		if c.AlterID == 0 {
+			parts = append(parts, "aead=true")
+		}
+	case "vless":
+		parts = append(parts, "vless="+address, "method=none", "password="+c.UUID)
+	case "trojan":
+		parts = append(parts, "trojan="+address, "password="+c.UUID)
+	case "shadowsocks":
+		parts = append(parts, "shadowsocks="+address, "method="+c.Cipher, "password="+c.UUID)
+		switch c.Plugin {
+		case "", "v2ray-plugin":
+		case "obfs-local", "simple-obfs":
+			parts = append(parts, "obfs="+c.HeaderType, "obfs-host="+c.Host)
+		default:
+			return "", fmt.Errorf("quantumultX tidak mendukung plugin %s", c.Plugin)
+		}
+	default:
+		return "", fmt.Errorf("quantumultX tidak mendukung protocol %s", c.Protocol)
+	}
+
+	switch c.Network {
+	case "ws":
+		obfs := "ws"
+		if c.TLS {
+			obfs = "wss"
+		}
+		parts = append(parts, "obfs="+obfs)
+		if c.Host != "" {
+			parts = append(parts, "obfs-host="+c.Host)
+		}
+		if c.Path != "" {
+			parts = append(parts, "obfs-uri="+c.Path)
+		}
+		if c.TLS && c.SNI != "" {
+			parts = append(parts, "tls-host="+c.SNI)
+		}
+	case "tcp":
+		if c.TLS && c.Plugin == "" {
+			if c.Protocol == "trojan" {
+				parts = append(parts, "over-tls=true")
+			} else {
+				parts = append(parts, "obfs=over-tls")
+			}
+			if c.SNI != "" {
+				parts = append(parts, "tls-host="+c.SNI)
+			}
+		}
+	default:
+		return "", fmt.Errorf("quantumultX tidak mendukung network %s", c.Network)
+	}
+
+	if c.TLS {
+		parts = append(parts, "tls-verification=false")
+	}
+	parts = append(parts, "fast-open=false", "udp-relay=false", "tag="+result.ProxyName)
+
+	return strings.Join(parts, ", "), nil
+}
+
+// === OUTBOUND BUILDERS ===
+
+// singBoxOutbound membangun outbound sing-box dari config hasil modifikasi
+func singBoxOutbound(c *database.DetectedXRayConfig, tag string) (map[string]interface{}, error) {
+	outbound := map[string]interface{}{
+		"tag":         tag,
+		"server":      c.Server,
+		"server_port": c.Port,
+	}
+
+	switch c.Protocol {
+	case "vmess":
+		outbound["type"] = "vmess"
+		outbound["uuid"] = c.UUID
+		outbound["security"] = "auto"
+		outbound["alter_id"] = c.AlterID
+	case "vless":
+		outbound["type"] = "vless"
+		outbound["uuid"] = c.UUID
+		if c.Flow != "" {
+			outbound["flow"] = c.Flow
+		}
+	case "trojan":
+		outbound["type"] = "trojan"
+		outbound["password"] = c.UUID
+	case "shadowsocks":
+		outbound["type"] = "shadowsocks"
+		outbound["method"] = c.Cipher
+		outbound["password"] = c.UUID
+		if plugin := buildShadowsocksPlugin(c.RawConfig); plugin != "" {
+			name, opts := splitShadowsocksPlugin(plugin)
+			if name == "simple-obfs" {
+				name = "obfs-local"
+			}
+			outbound["plugin"] = name
+			outbound["plugin_opts"] = opts
+		}
+		// Shadowsocks memakai plugin, bukan tls/transport sing-box
+		return outbound, nil
+	default:
+		return nil, fmt.Errorf("sing-box: protocol %s tidak didukung", c.Protocol)
+	}
+
+	if c.TLS {
+		tlsOpts := map[string]interface{}{
+			"enabled": true,
+		}
+		if c.SNI != "" {
+			tlsOpts["server_name"] = c.SNI
+		}
+		fingerprint := c.Fingerprint
+		if c.Security == "reality" {
+			if fingerprint == "" {
+				fingerprint = "chrome"
+			}
+			tlsOpts["reality"] = map[string]interface{}{
+				"enabled":    true,
+				"public_key": c.PublicKey,
+				"short_id":   c.ShortID,
+			}
+		} else {
+			tlsOpts["insecure"] = true
+		}
+		if fingerprint != "" {
+			tlsOpts["utls"] = map[string]interface{}{
+				"enabled":     true,
+				"fingerprint": fingerprint,
+			}
+		}
+		outbound["tls"] = tlsOpts
+	}
+
+	switch c.Network {
+	case "ws":
+		transport := map[string]interface{}{"type": "ws", "path": c.Path}
+		if c.Host != "" {
+			transport["headers"] = map[string]interface{}{"Host": c.Host}
+		}
+		outbound["transport"] = transport
+	case "grpc":
+		outbound["transport"] = map[string]interface{}{"type": "grpc", "service_name": c.ServiceName}
+	case "httpupgrade":
+		outbound["transport"] = map[string]interface{}{"type": "httpupgrade", "host": c.Host, "path": c.Path}
+	case "h2", "http":
+		transport := map[string]interface{}{"type": "http", "path": c.Path}
+		if hosts := splitHosts(c.Host); len(hosts) > 0 {
+			transport["host"] = hosts
+		}
+		outbound["transport"] = transport
+	case "quic":
+		// Transport QUIC v2ray di sing-box tidak punya opsi enkripsi/header
+		if (c.QUICSecurity != "" && c.QUICSecurity != "none") || c.HeaderType != "" {
+			return nil, fmt.Errorf("sing-box: quic dengan security/header tidak didukung")
+		}
+		outbound["transport"] = map[string]interface{}{"type": "quic"}
+	case "tcp", "":
+		if c.HeaderType == "http" {
+			return nil, fmt.Errorf("sing-box: tcp dengan header http tidak didukung")
+		}
+	default:
+		return nil, fmt.Errorf("sing-box: network %s tidak didukung", c.Network)
+	}
+
+	return outbound, nil
+}
+
+// xrayOutbound membangun outbound Xray core dari config hasil modifikasi
+func xrayOutbound(c *database.DetectedXRayConfig, tag string) (map[string]interface{}, error) {
+	outbound := map[string]interface{}{
+		"tag":      tag,
+		"protocol": c.Protocol,
+	}
+
+	switch c.Protocol {
+	case "vmess":
+		outbound["settings"] = map[string]interface{}{
+			"vnext": []interface{}{map[string]interface{}{
+				"address": c.Server,
+				"port":    c.Port,
+				"users":   []interface{}{map[string]interface{}{"id": c.UUID, "alterId": c.AlterID, "security": "auto"}},
+			}},
+		}
+	case "vless":
+		user := map[string]interface{}{"id": c.UUID, "encryption": "none"}
+		if c.Flow != "" {
+			user["flow"] = c.Flow
+		}
+		outbound["settings"] = map[string]interface{}{
+			"vnext": []interface{}{map[string]interface{}{
+				"address": c.Server,
+				"port":    c.Port,
+				"users":   []interface{}{user},
+			}},
+		}
+	case "trojan":
+		outbound["settings"] = map[string]interface{}{
+			"servers": []interface{}{map[string]interface{}{"address": c.Server, "port": c.Port, "password": c.UUID}},
+		}
+	case "shadowsocks":
+		if c.Plugin != "" {
+			return nil, fmt.Errorf("xray core tidak mendukung plugin shadowsocks %s", c.Plugin)
+		}
+		outbound["settings"] = map[string]interface{}{
+			"servers": []interface{}{map[string]interface{}{"address": c.Server, "port": c.Port, "method": c.Cipher, "password": c.UUID}},
+		}
+		return outbound, nil
+	default:
+		return nil, fmt.Errorf("xray: protocol %s tidak didukung", c.Protocol)
+	}
+
+	network := c.Network
+	if network == "" {
+		network = "tcp"
+	}
+	if network == "h2" {
+		network = "http"
+	}
+	stream := map[string]interface{}{"network": network}
+
+	switch c.Security {
+	case "reality":
+		fingerprint := c.Fingerprint
+		if fingerprint == "" {
+			fingerprint = "chrome"
+		}
+		stream["security"] = "reality"
+		stream["realitySettings"] = map[string]interface{}{
+			"serverName":  c.SNI,
+			"fingerprint": fingerprint,
+			"publicKey":   c.PublicKey,
+			"shortId":     c.ShortID,
+			"spiderX":     c.SpiderX,
+		}
+	default:
+		if c.TLS {
+			tlsSettings := map[string]interface{}{"serverName": c.SNI, "allowInsecure": true}
+			if c.Fingerprint != "" {
+				tlsSettings["fingerprint"] = c.Fingerprint
+			}
+			stream["security"] = "tls"
+			stream["tlsSettings"] = tlsSettings
+		} else {
+			stream["security"] = "none"
+		}
+	}
+
+	switch network {
+	case "ws":
+		wsSettings := map[string]interface{}{"path": c.Path}
+		if c.Host != "" {
+			wsSettings["headers"] = map[string]interface{}{"Host": c.Host}
+		}
+		stream["wsSettings"] = wsSettings
+	case "grpc":
+		stream["grpcSettings"] = map[string]interface{}{"serviceName": c.ServiceName}
+	case "httpupgrade":
+		stream["httpupgradeSettings"] = map[string]interface{}{"path": c.Path, "host": c.Host}
+	case "http":
+		httpSettings := map[string]interface{}{"path": c.Path}
+		if hosts := splitHosts(c.Host); len(hosts) > 0 {
+			httpSettings["host"] = hosts
+		}
+		stream["httpSettings"] = httpSettings
+	case "xhttp":
+		xhttpSettings := map[string]interface{}{"path": c.Path, "host": c.Host}
+		if c.Mode != "" {
+			xhttpSettings["mode"] = c.Mode
+		}
+		stream["xhttpSettings"] = xhttpSettings
+	case "kcp":
+		kcpSettings := map[string]interface{}{"header": xrayHeader(c.HeaderType)}
+		if c.Seed != "" {
+			kcpSettings["seed"] = c.Seed
+		}
+		stream["kcpSettings"] = kcpSettings
+	case "quic":
+		security := c.QUICSecurity
+		if security == "" {
+			security = "none"
+		}
+		stream["quicSettings"] = map[string]interface{}{"security": security, "key": c.QUICKey, "header": xrayHeader(c.HeaderType)}
+	case "tcp":
+		if c.HeaderType == "http" {
+			request := map[string]interface{}{"path": []string{"/"}}
+			if paths := splitHosts(c.Path); len(paths) > 0 {
+				request["path"] = paths
+			}
+			if hosts := splitHosts(c.Host); len(hosts) > 0 {
+				request["headers"] = map[string]interface{}{"Host": hosts}
+			}
+			stream["tcpSettings"] = map[string]interface{}{"header": map[string]interface{}{"type": "http", "request": request}}
+		}
+	}
+	outbound["streamSettings"] = stream
+
+	return outbound, nil
+}
+
+// xrayHeader header obfuscation kcp/quic ("none" jika kosong)
+func xrayHeader(headerType string) map[string]interface{} {
+	if headerType == "" {
+		headerType = "none"
+	}
+	return map[string]interface{}{"type": headerType}
+}
+
+// marshalIndentJSON encode JSON dengan indentasi dua spasi
+func marshalIndentJSON(value interface{}) (string, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nabilulilalbab/promote/database"
+	"github.com/nabilulilalbab/promote/utils"
+)
+
+// updateGolden menulis ulang testdata/golden: go test ./services -run TestConverterGolden -update
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+// corpusLink satu link input dari testdata/xray_links.txt
+type corpusLink struct {
+	Name string
+	Link string
+}
+
+// goldenOutput hasil konversi yang diharapkan untuk satu link
+type goldenOutput struct {
+	Link         string `json:"link"`
+	Clash        string `json:"clash,omitempty"`
+	ClashError   string `json:"clash_error,omitempty"`
+	SingBox      string `json:"singbox,omitempty"`
+	SingBoxError string `json:"singbox_error,omitempty"`
+}
+
+// loadLinkCorpus membaca corpus link (baris kosong dan komentar # dilewati)
+func loadLinkCorpus(tb testing.TB) []corpusLink {
+	tb.Helper()
+
+	file, err := os.Open(filepath.Join("testdata", "xray_links.txt"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer file.Close()
+
+	var links []corpusLink
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, link, ok := strings.Cut(line, " ")
+		if !ok {
+			tb.Fatalf("invalid corpus line: %q", line)
+		}
+		links = append(links, corpusLink{Name: name, Link: strings.TrimSpace(link)})
+	}
+	if err := scanner.Err(); err != nil {
+		tb.Fatal(err)
+	}
+	return links
+}
+
+func newGoldenTestService() *XRayConverterService {
+	service := NewXRayConverterService(nil, utils.NewLogger("TEST", false))
+	service.SetResolver(StaticResolver{})
+	return service
+}
+
+// convertGolden menjalankan satu converter terhadap link dan merangkum output link, Clash dan sing-box
+func convertGolden(t *testing.T, service *XRayConverterService, converter *database.XRayConverter, link string) goldenOutput {
+	t.Helper()
+
+	detected, err := service.DetectXRayConfig(link)
+	if err != nil {
+		t.Fatalf("DetectXRayConfig() error = %v", err)
+	}
+	result, err := service.ModifyXRayConfig(detected, converter)
+	if err != nil {
+		t.Fatalf("ModifyXRayConfig() error = %v", err)
+	}
+
+	output := goldenOutput{Link: result.ModifiedLink, Clash: result.YAMLConfig, ClashError: result.YAMLError}
+	if singBox, err := formatSingBox(result); err != nil {
+		output.SingBoxError = err.Error()
+	} else {
+		output.SingBox = singBox
+	}
+	return output
+}
+
+func TestConverterGolden(t *testing.T) {
+	converters, err := database.DefaultXRayConverters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	links := loadLinkCorpus(t)
+	service := newGoldenTestService()
+
+	for i := range converters {
+		converter := &converters[i]
+		t.Run(converter.CommandName, func(t *testing.T) {
+			goldenPath := filepath.Join("testdata", "golden", converter.CommandName+".json")
+
+			got := make(map[string]goldenOutput, len(links))
+			for _, link := range links {
+				got[link.Name] = convertGolden(t, service, converter, link.Link)
+			}
+
+			if *updateGolden {
+				var data bytes.Buffer
+				encoder := json.NewEncoder(&data)
+				encoder.SetEscapeHTML(false)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(got); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(goldenPath, data.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			data, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			var want map[string]goldenOutput
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatalf("invalid golden file %s: %v", goldenPath, err)
+			}
+
+			for _, link := range links {
+				expected, ok := want[link.Name]
+				if !ok {
+					t.Errorf("%s: missing from %s (run with -update)", link.Name, goldenPath)
+					continue
+				}
+				actual := got[link.Name]
+				if actual.Link != expected.Link {
+					t.Errorf("%s: link\n got: %s\nwant: %s", link.Name, actual.Link, expected.Link)
+				}
+				if actual.Clash != expected.Clash || actual.ClashError != expected.ClashError {
+					t.Errorf("%s: clash\n got: %s%s\nwant: %s%s", link.Name, actual.Clash, actual.ClashError, expected.Clash, expected.ClashError)
+				}
+				if actual.SingBox != expected.SingBox || actual.SingBoxError != expected.SingBoxError {
+					t.Errorf("%s: sing-box\n got: %s%s\nwant: %s%s", link.Name, actual.SingBox, actual.SingBoxError, expected.SingBox, expected.SingBoxError)
+				}
+			}
+		})
+	}
+}
+
+// comparableConfig DetectedXRayConfig tanpa RawConfig (isi map mentah boleh berbeda format).
+// SNI tanpa TLS tidak ditulis ke link sehingga ikut diabaikan.
+func comparableConfig(c *database.DetectedXRayConfig) database.DetectedXRayConfig {
+	config := *c
+	config.RawConfig = nil
+	if !config.TLS {
+		config.SNI = ""
+	}
+	return config
+}
+
+func TestConverterRoundTrip(t *testing.T) {
+	converters, err := database.DefaultXRayConverters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Converter tanpa rule: link hasil generate harus sama dengan link asli
+	identity := database.XRayConverter{CommandName: "identity", DisplayName: "Identity", ModifyType: "custom"}
+	converters = append([]database.XRayConverter{identity}, converters...)
+
+	service := newGoldenTestService()
+	for _, link := range loadLinkCorpus(t) {
+		for i := range converters {
+			converter := &converters[i]
+			t.Run(link.Name+"/"+converter.CommandName, func(t *testing.T) {
+				detected, err := service.DetectXRayConfig(link.Link)
+				if err != nil {
+					t.Fatalf("detect: %v", err)
+				}
+				result, err := service.ModifyXRayConfig(detected, converter)
+				if err != nil {
+					t.Fatalf("generate: %v", err)
+				}
+				reparsed, err := service.DetectXRayConfig(result.ModifiedLink)
+				if err != nil {
+					t.Fatalf("detect generated link %s: %v", result.ModifiedLink, err)
+				}
+
+				want := comparableConfig(result.ModifiedConfig)
+				if converter.CommandName == identity.CommandName {
+					want = comparableConfig(detected)
+				}
+				if got := comparableConfig(reparsed); !reflect.DeepEqual(got, want) {
+					t.Errorf("round trip mismatch\nlink: %s\n got: %+v\nwant: %+v", result.ModifiedLink, got, want)
+				}
+			})
+		}
+	}
+}
+
+func FuzzDetectXRayConfig(f *testing.F) {
+	for _, link := range loadLinkCorpus(f) {
+		f.Add(link.Link)
+	}
+	f.Add("vmess://")
+	f.Add("vless://@:0")
+	f.Add("ss://%zz@host:1")
+	f.Add("trojan://user@[::1]:443?path=%252F%25#x")
+
+	service := newGoldenTestService()
+	converter := &database.XRayConverter{DisplayName: "Fuzz", ModifyType: "wildcard", BugHost: "bug.example.com", PathTemplate: "/fuzz"}
+
+	f.Fuzz(func(t *testing.T, link string) {
+		detected, err := service.DetectXRayConfig(link)
+		if err != nil {
+			return
+		}
+		// Link yang lolos deteksi tidak boleh membuat generator panic
+		result, err := service.ModifyXRayConfig(detected, converter)
+		if err != nil {
+			return
+		}
+		formatSingBox(result)
+	})
+}
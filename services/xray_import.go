@@ -0,0 +1,521 @@
+// Package services - import profile Clash YAML / sing-box JSON menjadi XRay link untuk dikonversi
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// Format sumber profile yang bisa di-import
+const (
+	ProfileFormatClash   = "clash"
+	ProfileFormatSingBox = "singbox"
+)
+
+// singBoxImportOutbound field outbound sing-box yang dibaca saat import
+type singBoxImportOutbound struct {
+	Type       string `json:"type"`
+	Tag        string `json:"tag"`
+	Server     string `json:"server"`
+	ServerPort int    `json:"server_port"`
+	UUID       string `json:"uuid"`
+	Password   string `json:"password"`
+	Method     string `json:"method"`
+	Flow       string `json:"flow"`
+	AlterID    int    `json:"alter_id"`
+	Plugin     string `json:"plugin"`
+	PluginOpts string `json:"plugin_opts"`
+	TLS        *struct {
+		Enabled    bool   `json:"enabled"`
+		ServerName string `json:"server_name"`
+		UTLS       *struct {
+			Enabled     bool   `json:"enabled"`
+			Fingerprint string `json:"fingerprint"`
+		} `json:"utls"`
+		Reality *struct {
+			Enabled   bool   `json:"enabled"`
+			PublicKey string `json:"public_key"`
+			ShortID   string `json:"short_id"`
+		} `json:"reality"`
+	} `json:"tls"`
+	Transport *struct {
+		Type        string            `json:"type"`
+		Path        string            `json:"path"`
+		ServiceName string            `json:"service_name"`
+		Host        interface{}       `json:"host"` // string (httpupgrade) atau []string (http)
+		Headers     map[string]string `json:"headers"`
+	} `json:"transport"`
+}
+
+// DetectProfileFormat mengenali isi teks sebagai profile Clash YAML atau sing-box JSON,
+// string kosong jika bukan profile
+func DetectProfileFormat(content string) string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return ""
+	}
+
+	if strings.HasPrefix(content, "{") || strings.HasPrefix(content, "[") {
+		if _, err := parseSingBoxOutbounds(content); err == nil {
+			return ProfileFormatSingBox
+		}
+		return ""
+	}
+
+	var doc struct {
+		Proxies []yaml.Node `yaml:"proxies"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &doc); err == nil && len(doc.Proxies) > 0 {
+		return ProfileFormatClash
+	}
+
+	return ""
+}
+
+// DetectXRayProfile parsing profile Clash/sing-box menjadi share link dan DetectedXRayConfig per proxy.
+// Proxy dengan type yang tidak didukung dicatat di Skipped.
+func (s *XRayConverterService) DetectXRayProfile(content string) (*database.XRayProfileImport, error) {
+	format := DetectProfileFormat(content)
+
+	var proxies []*database.DetectedXRayConfig
+	var skipped []string
+	var err error
+
+	switch format {
+	case ProfileFormatClash:
+		proxies, skipped, err = parseClashImport(content)
+	case ProfileFormatSingBox:
+		proxies, skipped, err = parseSingBoxImport(content)
+	default:
+		return nil, fmt.Errorf("bukan profile Clash YAML atau sing-box JSON")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &database.XRayProfileImport{Format: format, Skipped: skipped}
+	for _, proxy := range proxies {
+		// Generate share link lalu deteksi ulang agar RawConfig sama persis dengan hasil DetectXRayConfig
+		link, err := s.shareLinkFromDetected(proxy)
+		if err != nil {
+			profile.Skipped = append(profile.Skipped, fmt.Sprintf("%s (%v)", proxy.Remarks, err))
+			continue
+		}
+		detected, err := s.DetectXRayConfig(link)
+		if err != nil {
+			profile.Skipped = append(profile.Skipped, fmt.Sprintf("%s (%v)", proxy.Remarks, err))
+			continue
+		}
+		profile.Links = append(profile.Links, link)
+		profile.Configs = append(profile.Configs, detected)
+	}
+
+	if len(profile.Links) == 0 {
+		return nil, fmt.Errorf("tidak ada proxy vmess/vless/trojan/ss yang bisa di-import")
+	}
+
+	return profile, nil
+}
+
+// ProcessProfileImport import profile Clash/sing-box, konversi semua proxy lewat batch conversion,
+// lalu render ulang profile dalam format yang sama dengan sumbernya
+func (s *XRayConverterService) ProcessProfileImport(converterName, content, userJID, groupJID string) (*database.XRayImportResult, error) {
+	profile, err := s.DetectXRayProfile(content)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := s.ProcessBatchConversion(converterName, profile.Links, userJID, groupJID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &database.XRayImportResult{
+		Format:  profile.Format,
+		Batch:   batch,
+		Skipped: profile.Skipped,
+	}
+	if batch.SuccessCount == 0 {
+		return result, nil
+	}
+
+	switch profile.Format {
+	case ProfileFormatSingBox:
+		var results []*database.ModifiedXRayConfig
+		for _, item := range batch.Items {
+			if item.Result != nil {
+				results = append(results, item.Result)
+			}
+		}
+		result.Profile, err = BuildSingBoxProfile(results)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build sing-box profile: %v", err)
+		}
+	default:
+		result.Profile = batch.ClashProfile
+		if result.Profile == "" {
+			result.Profile = batch.ClashProxies
+		}
+	}
+
+	return result, nil
+}
+
+// parseClashImport membaca "proxies:" dari YAML Clash/Mihomo (proxy list atau profile lengkap)
+func parseClashImport(content string) ([]*database.DetectedXRayConfig, []string, error) {
+	var doc clashProxyList
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, nil, fmt.Errorf("invalid Clash YAML: %v", err)
+	}
+
+	var configs []*database.DetectedXRayConfig
+	var skipped []string
+	for _, proxy := range doc.Proxies {
+		if proxy == nil {
+			continue
+		}
+		config, err := detectedFromClashProxy(proxy)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s (%v)", proxy.Name, err))
+			continue
+		}
+		configs = append(configs, config)
+	}
+
+	return configs, skipped, nil
+}
+
+// detectedFromClashProxy memetakan satu proxy Clash ke DetectedXRayConfig
+func detectedFromClashProxy(proxy *clashProxy) (*database.DetectedXRayConfig, error) {
+	config := &database.DetectedXRayConfig{
+		Server:  proxy.Server,
+		Port:    proxy.Port,
+		Network: proxy.Network,
+		Remarks: proxy.Name,
+	}
+	if config.Network == "" {
+		config.Network = "tcp"
+	}
+
+	switch proxy.Type {
+	case "vmess":
+		config.Protocol = "vmess"
+		config.UUID = proxy.UUID
+		if proxy.AlterID != nil {
+			config.AlterID = *proxy.AlterID
+		}
+	case "vless":
+		config.Protocol = "vless"
+		config.UUID = proxy.UUID
+		config.Flow = proxy.Flow
+	case "trojan":
+		config.Protocol = "trojan"
+		config.UUID = proxy.Password
+		// Trojan di Clash default memakai TLS
+		if proxy.TLS == nil {
+			enabled := true
+			proxy.TLS = &enabled
+		}
+	case "ss":
+		config.Protocol = "shadowsocks"
+		config.UUID = proxy.Password
+		config.Cipher = proxy.Cipher
+		config.Network = "tcp"
+		applyClashShadowsocksPlugin(config, proxy)
+		return config, nil
+	default:
+		return nil, fmt.Errorf("type %s tidak didukung", proxy.Type)
+	}
+
+	if proxy.TLS != nil && *proxy.TLS {
+		config.TLS = true
+		config.Security = "tls"
+		config.SNI = proxy.ServerName
+		if proxy.SNI != "" {
+			config.SNI = proxy.SNI
+		}
+		config.Fingerprint = proxy.ClientFingerprint
+		if proxy.RealityOpts != nil {
+			config.Security = "reality"
+			config.PublicKey = proxy.RealityOpts.PublicKey
+			config.ShortID = proxy.RealityOpts.ShortID
+		}
+	}
+
+	var httpOpts *clashHTTPOpts
+	switch config.Network {
+	case "ws":
+		httpOpts = proxy.WSOpts
+	case "httpupgrade":
+		httpOpts = proxy.HTTPUpgradeOpts
+	case "grpc":
+		if proxy.GrpcOpts != nil {
+			config.ServiceName = proxy.GrpcOpts.ServiceName
+		}
+	}
+	if httpOpts != nil {
+		config.Path = httpOpts.Path
+		config.Host = httpOpts.Headers["Host"]
+	}
+
+	return config, nil
+}
+
+// applyClashShadowsocksPlugin memetakan plugin/plugin-opts Clash ke plugin SIP003
+func applyClashShadowsocksPlugin(config *database.DetectedXRayConfig, proxy *clashProxy) {
+	opts := proxy.PluginOpts
+	if opts == nil {
+		opts = &clashPluginOpts{}
+	}
+
+	switch proxy.Plugin {
+	case "v2ray-plugin":
+		mode := opts.Mode
+		if mode == "" {
+			mode = "websocket"
+		}
+		config.Plugin = "v2ray-plugin"
+		config.PluginOpts = "mode=" + mode
+		if opts.TLS {
+			config.PluginOpts += ";tls"
+		}
+		config.Host = opts.Host
+		config.Path = opts.Path
+	case "obfs":
+		config.Plugin = "obfs-local"
+		config.PluginOpts = "obfs=" + opts.Mode
+		config.Host = opts.Host
+	}
+}
+
+// parseSingBoxOutbounds menerima config sing-box lengkap, array outbounds, atau satu outbound
+func parseSingBoxOutbounds(content string) ([]singBoxImportOutbound, error) {
+	content = strings.TrimSpace(content)
+
+	if strings.HasPrefix(content, "[") {
+		var outbounds []singBoxImportOutbound
+		if err := json.Unmarshal([]byte(content), &outbounds); err != nil {
+			return nil, fmt.Errorf("invalid sing-box JSON: %v", err)
+		}
+		return outbounds, nil
+	}
+
+	var doc struct {
+		Outbounds []singBoxImportOutbound `json:"outbounds"`
+	}
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("invalid sing-box JSON: %v", err)
+	}
+	if len(doc.Outbounds) > 0 {
+		return doc.Outbounds, nil
+	}
+
+	var single singBoxImportOutbound
+	if err := json.Unmarshal([]byte(content), &single); err != nil || single.Type == "" {
+		return nil, fmt.Errorf("invalid sing-box JSON: no outbounds")
+	}
+	return []singBoxImportOutbound{single}, nil
+}
+
+// parseSingBoxImport membaca outbound proxy dari JSON sing-box
+// (selector/urltest/direct/block/dns dilewati tanpa dicatat)
+func parseSingBoxImport(content string) ([]*database.DetectedXRayConfig, []string, error) {
+	outbounds, err := parseSingBoxOutbounds(content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var configs []*database.DetectedXRayConfig
+	var skipped []string
+	for i := range outbounds {
+		outbound := &outbounds[i]
+		switch outbound.Type {
+		case "selector", "urltest", "direct", "block", "dns":
+			continue
+		}
+		config, err := detectedFromSingBoxOutbound(outbound)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s (%v)", outbound.Tag, err))
+			continue
+		}
+		configs = append(configs, config)
+	}
+
+	return configs, skipped, nil
+}
+
+// detectedFromSingBoxOutbound memetakan satu outbound sing-box ke DetectedXRayConfig
+func detectedFromSingBoxOutbound(outbound *singBoxImportOutbound) (*database.DetectedXRayConfig, error) {
+	config := &database.DetectedXRayConfig{
+		Server:  outbound.Server,
+		Port:    outbound.ServerPort,
+		Network: "tcp",
+		Remarks: outbound.Tag,
+	}
+
+	switch outbound.Type {
+	case "vmess":
+		config.Protocol = "vmess"
+		config.UUID = outbound.UUID
+		config.AlterID = outbound.AlterID
+	case "vless":
+		config.Protocol = "vless"
+		config.UUID = outbound.UUID
+		config.Flow = outbound.Flow
+	case "trojan":
+		config.Protocol = "trojan"
+		config.UUID = outbound.Password
+	case "shadowsocks":
+		config.Protocol = "shadowsocks"
+		config.UUID = outbound.Password
+		config.Cipher = outbound.Method
+		config.Plugin = outbound.Plugin
+		config.PluginOpts = outbound.PluginOpts
+		return config, nil
+	default:
+		return nil, fmt.Errorf("type %s tidak didukung", outbound.Type)
+	}
+
+	if tls := outbound.TLS; tls != nil && tls.Enabled {
+		config.TLS = true
+		config.Security = "tls"
+		config.SNI = tls.ServerName
+		if tls.UTLS != nil && tls.UTLS.Enabled {
+			config.Fingerprint = tls.UTLS.Fingerprint
+		}
+		if tls.Reality != nil && tls.Reality.Enabled {
+			config.Security = "reality"
+			config.PublicKey = tls.Reality.PublicKey
+			config.ShortID = tls.Reality.ShortID
+		}
+	}
+
+	if transport := outbound.Transport; transport != nil {
+		switch transport.Type {
+		case "ws":
+			config.Network = "ws"
+			config.Path = transport.Path
+			config.Host = transport.Headers["Host"]
+		case "httpupgrade":
+			config.Network = "httpupgrade"
+			config.Path = transport.Path
+			config.Host = singBoxHost(transport.Host)
+		case "http":
+			config.Network = "h2"
+			config.Path = transport.Path
+			config.Host = singBoxHost(transport.Host)
+		case "grpc":
+			config.Network = "grpc"
+			config.ServiceName = transport.ServiceName
+		default:
+			return nil, fmt.Errorf("transport %s tidak didukung", transport.Type)
+		}
+	}
+
+	return config, nil
+}
+
+// singBoxHost mengambil host pertama dari field host sing-box (string atau array)
+func singBoxHost(host interface{}) string {
+	switch v := host.(type) {
+	case string:
+		return v
+	case []interface{}:
+		if len(v) > 0 {
+			if first, ok := v[0].(string); ok {
+				return first
+			}
+		}
+	}
+	return ""
+}
+
+// shareLinkFromDetected membuat share link dari DetectedXRayConfig hasil import
+func (s *XRayConverterService) shareLinkFromDetected(c *database.DetectedXRayConfig) (string, error) {
+	if c.Server == "" || c.Port <= 0 {
+		return "", fmt.Errorf("server/port kosong")
+	}
+	port := strconv.Itoa(c.Port)
+
+	switch c.Protocol {
+	case "vmess":
+		path := c.Path
+		if c.Network == "grpc" {
+			path = c.ServiceName
+		}
+		tls := ""
+		if c.TLS {
+			tls = "tls"
+		}
+		return s.generateVMESSLink(map[string]interface{}{
+			"v":    "2",
+			"ps":   c.Remarks,
+			"add":  c.Server,
+			"port": port,
+			"id":   c.UUID,
+			"aid":  strconv.Itoa(c.AlterID),
+			"scy":  "auto",
+			"net":  c.Network,
+			"type": "none",
+			"host": c.Host,
+			"path": path,
+			"tls":  tls,
+			"sni":  c.SNI,
+			"fp":   c.Fingerprint,
+		})
+
+	case "vless", "trojan":
+		config := map[string]interface{}{
+			"server":   c.Server,
+			"port":     port,
+			"uuid":     c.UUID,
+			"remarks":  c.Remarks,
+			"network":  c.Network,
+			"security": c.Security,
+		}
+		optional := map[string]string{
+			"sni":         c.SNI,
+			"host":        c.Host,
+			"path":        c.Path,
+			"serviceName": c.ServiceName,
+			"flow":        c.Flow,
+			"fp":          c.Fingerprint,
+			"pbk":         c.PublicKey,
+			"sid":         c.ShortID,
+		}
+		for key, value := range optional {
+			if value != "" {
+				config[key] = value
+			}
+		}
+		return s.generateURLFormatLink(config, c.Protocol)
+
+	case "shadowsocks":
+		config := map[string]interface{}{
+			"server":  c.Server,
+			"port":    port,
+			"uuid":    c.UUID,
+			"cipher":  c.Cipher,
+			"remarks": c.Remarks,
+		}
+		if c.Plugin != "" {
+			config["plugin"] = c.Plugin
+			config["plugin_opts"] = c.PluginOpts
+			if c.Host != "" {
+				config["host"] = c.Host
+			}
+			if c.Path != "" {
+				config["path"] = c.Path
+			}
+		}
+		return s.generateShadowsocksLink(config)
+	}
+
+	return "", fmt.Errorf("protocol %s tidak didukung", c.Protocol)
+}
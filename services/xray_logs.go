@@ -0,0 +1,71 @@
+// Package services - penyimpanan log konversi XRay: redaksi kredensial, fingerprint, dan retensi
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/nabilulilalbab/promote/database"
+	"github.com/nabilulilalbab/promote/utils"
+)
+
+// credentialFingerprintLength panjang fingerprint (hex) yang disimpan di log
+const credentialFingerprintLength = 16
+
+// SetFingerprintSecret mengaktifkan fingerprint kredensial (HMAC-SHA256) di log konversi.
+// Secret kosong menonaktifkan fingerprint.
+func (s *XRayConverterService) SetFingerprintSecret(secret string) {
+	if secret == "" {
+		s.fingerprintSecret = nil
+		return
+	}
+	s.fingerprintSecret = []byte(secret)
+}
+
+// logConversion menyimpan log konversi tanpa kredensial: pesan error disamarkan dan
+// UUID/password hanya disimpan sebagai fingerprint (jika diaktifkan)
+func (s *XRayConverterService) logConversion(entry *database.XRayConversionLog, detected *database.DetectedXRayConfig) {
+	if entry.ErrorMessage != nil {
+		redacted := utils.RedactSecrets(*entry.ErrorMessage)
+		entry.ErrorMessage = &redacted
+	}
+	entry.ProbeError = utils.RedactSecrets(entry.ProbeError)
+
+	if detected != nil {
+		entry.CredentialFingerprint = s.credentialFingerprint(detected)
+	}
+
+	if err := s.repository.LogXRayConversion(entry); err != nil {
+		s.logger.Warningf("Failed to save XRay conversion log: %v", err)
+	}
+}
+
+// credentialFingerprint hash kredensial agar penyalahgunaan akun yang sama bisa dilacak
+// tanpa menyimpan UUID/password aslinya
+func (s *XRayConverterService) credentialFingerprint(detected *database.DetectedXRayConfig) string {
+	if len(s.fingerprintSecret) == 0 || detected.UUID == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, s.fingerprintSecret)
+	mac.Write([]byte(detected.Protocol + ":" + detected.UUID))
+	return hex.EncodeToString(mac.Sum(nil))[:credentialFingerprintLength]
+}
+
+// PruneConversionLogs menghapus log konversi yang lebih tua dari retention
+func (s *XRayConverterService) PruneConversionLogs(retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+
+	deleted, err := s.repository.PruneXRayConversionLogs(time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		s.logger.Infof("Pruned %d XRay conversion logs older than %v", deleted, retention)
+	}
+	return deleted, nil
+}
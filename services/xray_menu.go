@@ -0,0 +1,86 @@
+// Package services - menu converter per kategori (operator) untuk command .converters dan .convert <nomor>
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// defaultConverterCategory kategori untuk converter yang belum diberi operator
+const defaultConverterCategory = "Lainnya"
+
+// XRayConverterCategory satu kelompok menu converter
+type XRayConverterCategory struct {
+	Name       string
+	Converters []XRayConverterMenuItem
+}
+
+// XRayConverterMenuItem converter beserta nomor urutnya di menu
+type XRayConverterMenuItem struct {
+	Number    int
+	Converter database.XRayConverter
+}
+
+// GetConverterMenu menyusun menu converter aktif per kategori. Kategori diurutkan alfabetis
+// ("Lainnya" paling akhir) dan nomor menu berurutan lintas kategori.
+func (s *XRayConverterService) GetConverterMenu() ([]XRayConverterCategory, error) {
+	converters, err := s.repository.GetActiveXRayConverters()
+	if err != nil {
+		return nil, err
+	}
+
+	byCategory := make(map[string][]database.XRayConverter)
+	var names []string
+	for _, converter := range converters {
+		category := strings.TrimSpace(converter.Category)
+		if category == "" {
+			category = defaultConverterCategory
+		}
+		if _, ok := byCategory[category]; !ok {
+			names = append(names, category)
+		}
+		byCategory[category] = append(byCategory[category], converter)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == defaultConverterCategory || names[j] == defaultConverterCategory {
+			return names[j] == defaultConverterCategory && names[i] != defaultConverterCategory
+		}
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+
+	var menu []XRayConverterCategory
+	number := 0
+	for _, name := range names {
+		category := XRayConverterCategory{Name: name}
+		for _, converter := range byCategory[name] {
+			number++
+			category.Converters = append(category.Converters, XRayConverterMenuItem{Number: number, Converter: converter})
+		}
+		menu = append(menu, category)
+	}
+
+	return menu, nil
+}
+
+// GetConverterByMenuNumber mencari converter aktif berdasarkan nomor di menu .converters
+func (s *XRayConverterService) GetConverterByMenuNumber(number int) (*database.XRayConverter, error) {
+	menu, err := s.GetConverterMenu()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, category := range menu {
+		for _, item := range category.Converters {
+			if item.Number == number {
+				converter := item.Converter
+				return &converter, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("converter nomor %d tidak ada di menu", number)
+}
@@ -0,0 +1,179 @@
+// Package services - susunan pesan chat hasil konversi XRay (dipakai bot dan preview dry-run)
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// XRayChatMessage satu pesan hasil konversi: teks biasa atau dokumen (FileName terisi)
+type XRayChatMessage struct {
+	Text     string `json:"text,omitempty"`
+	FileName string `json:"file_name,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	Content  string `json:"content,omitempty"` // isi dokumen
+	Caption  string `json:"caption,omitempty"` // caption dokumen
+}
+
+// IsDocument true jika pesan dikirim sebagai file
+func (m XRayChatMessage) IsDocument() bool {
+	return m.FileName != ""
+}
+
+// FormatProbeResult menyusun satu baris status probe koneksi
+func FormatProbeResult(probe *database.XRayProbeResult) string {
+	if probe.Success {
+		return fmt.Sprintf("🟢 Connected (%d ms)", probe.LatencyMs)
+	}
+	return fmt.Sprintf("🔴 Failed at %s after %d ms: %s", strings.ToUpper(probe.Stage), probe.LatencyMs, probe.Error)
+}
+
+// BuildConversionMessages menyusun urutan pesan yang dikirim bot untuk satu hasil konversi:
+// info & detail, file YAML (opsional), output format tambahan, lalu link hasil modifikasi
+func BuildConversionMessages(result *database.ModifiedXRayConfig, displayName, commandName string, delivery XRayDelivery) []XRayChatMessage {
+	var messages []XRayChatMessage
+
+	// === PESAN 1: INFO & DETAILS ===
+	messages = append(messages, XRayChatMessage{Text: formatConversionInfo(result, displayName, delivery)})
+
+	// === PESAN TAMBAHAN: FILE YAML ===
+	if delivery.YAMLAsDocument && len(result.Outputs) == 0 && result.YAMLConfig != "" {
+		yamlContent := result.YAMLConfig
+		if result.ProfileConfig != "" {
+			yamlContent = result.ProfileConfig
+		}
+		messages = append(messages, XRayChatMessage{
+			FileName: commandName + ".yaml",
+			MimeType: "text/yaml",
+			Content:  yamlContent,
+			Caption:  "📁 Clash/OpenClash config",
+		})
+	}
+
+	// === PESAN TAMBAHAN: OUTPUT FORMATS ===
+	for _, output := range result.Outputs {
+		if output.Format == "link" {
+			continue // link selalu dikirim di pesan terakhir
+		}
+
+		// Output YAML (clash/mihomo) sebagai file jika converter memintanya
+		if delivery.YAMLAsDocument && output.Error == "" && (output.Format == "clash" || output.Format == "mihomo") {
+			messages = append(messages, XRayChatMessage{
+				FileName: fmt.Sprintf("%s-%s.yaml", commandName, output.Format),
+				MimeType: "text/yaml",
+				Content:  output.Content,
+				Caption:  "📦 " + output.Label,
+			})
+			continue
+		}
+
+		if output.Error != "" {
+			messages = append(messages, XRayChatMessage{Text: fmt.Sprintf("⚠️ *%s:* %s", output.Label, output.Error)})
+		} else {
+			messages = append(messages, XRayChatMessage{Text: fmt.Sprintf("📦 *%s:*\n```\n%s\n```", output.Label, output.Content)})
+		}
+	}
+
+	// === PESAN 2: MODIFIED LINK ONLY ===
+	messages = append(messages, XRayChatMessage{Text: result.ModifiedLink})
+
+	return messages
+}
+
+// formatConversionInfo pesan pertama: converter, protocol, detail modifikasi, probe, dan YAML
+func formatConversionInfo(result *database.ModifiedXRayConfig, displayName string, delivery XRayDelivery) string {
+	var infoBuilder strings.Builder
+
+	// Header dengan emoji dan info
+	tls := "No"
+	if result.DetectedConfig.Security == "reality" {
+		tls = "REALITY"
+	} else if result.DetectedConfig.TLS {
+		tls = "Yes"
+	}
+	infoBuilder.WriteString("✅ *Conversion Success!*\n\n")
+	infoBuilder.WriteString(fmt.Sprintf("🏷️ *Converter:* %s\n", displayName))
+	infoBuilder.WriteString(fmt.Sprintf("🔧 *Type:* %s\n", strings.ToUpper(result.ModifyType)))
+	infoBuilder.WriteString(fmt.Sprintf("📡 *Protocol:* %s | *Network:* %s | *TLS:* %s\n\n",
+		strings.ToUpper(result.DetectedConfig.Protocol),
+		strings.ToUpper(result.DetectedConfig.Network),
+		tls))
+	if result.DetectedConfig.Flow != "" {
+		infoBuilder.WriteString(fmt.Sprintf("⚡ *Flow:* %s\n\n", result.DetectedConfig.Flow))
+	}
+
+	// Modification details dengan format rapi
+	infoBuilder.WriteString("🔍 *Modification Details:*\n")
+	infoBuilder.WriteString(fmt.Sprintf("• Original Server: %s\n", result.DetectedConfig.Server))
+	infoBuilder.WriteString(fmt.Sprintf("• Bug Host: %s\n", result.BugHost))
+
+	switch result.ModifyType {
+	case "wildcard":
+		infoBuilder.WriteString(fmt.Sprintf("• Modified Server: %s\n", result.ModifiedServer))
+		infoBuilder.WriteString(fmt.Sprintf("• Modified Host: %s\n", result.ModifiedHost))
+		if result.DetectedConfig.TLS {
+			infoBuilder.WriteString(fmt.Sprintf("• Modified SNI: %s\n", result.ModifiedSNI))
+		}
+	case "sni":
+		infoBuilder.WriteString(fmt.Sprintf("• Modified SNI: %s\n", result.ModifiedSNI))
+		infoBuilder.WriteString("• Server & Host: _unchanged_\n")
+	case "ws", "grpc":
+		infoBuilder.WriteString(fmt.Sprintf("• Modified Server: %s\n", result.ModifiedServer))
+		infoBuilder.WriteString("• Host & SNI: _unchanged_\n")
+	}
+
+	// Hasil probe koneksi (jika diminta)
+	if result.Probe != nil {
+		infoBuilder.WriteString(fmt.Sprintf("\n🩺 *Probe %s:* %s\n", result.Probe.Target, FormatProbeResult(result.Probe)))
+	}
+
+	// YAML Configuration dengan format rapi (jika tidak ada format lain yang diminta)
+	if len(result.Outputs) == 0 {
+		if result.YAMLConfig == "" && result.ProfileConfig == "" {
+			infoBuilder.WriteString(fmt.Sprintf("\n⚠️ *Clash/OpenClash:* %s\n\n", result.YAMLError))
+		} else if delivery.YAMLAsDocument {
+			infoBuilder.WriteString("\n📁 *YAML Configuration:* _dikirim sebagai file .yaml_\n\n")
+		} else if result.ProfileConfig != "" {
+			infoBuilder.WriteString("\n📁 *Mihomo/OpenClash Profile:*\n")
+			infoBuilder.WriteString("```yaml\n")
+			infoBuilder.WriteString(result.ProfileConfig)
+			infoBuilder.WriteString("```\n\n")
+		} else {
+			infoBuilder.WriteString("\n📁 *YAML Configuration:*\n")
+			infoBuilder.WriteString("```yaml\n")
+			infoBuilder.WriteString(result.YAMLConfig)
+			infoBuilder.WriteString("```\n\n")
+		}
+
+		infoBuilder.WriteString("💡 *Usage Instructions:*\n")
+		infoBuilder.WriteString("1. Copy modified link untuk V2Ray/Xray\n")
+		if result.ProfileConfig != "" {
+			infoBuilder.WriteString("2. Simpan profile sebagai config.yaml untuk OpenClash/Mihomo\n")
+		} else {
+			infoBuilder.WriteString("2. Copy YAML config untuk Clash/OpenClash\n")
+		}
+		infoBuilder.WriteString("3. Restart aplikasi setelah config\n\n")
+	} else {
+		infoBuilder.WriteString("\n📦 *Output Formats:* ")
+		var labels []string
+		for _, output := range result.Outputs {
+			labels = append(labels, output.Label)
+		}
+		infoBuilder.WriteString(strings.Join(labels, ", "))
+		infoBuilder.WriteString("\n\n")
+	}
+	infoBuilder.WriteString("📱 _Modified link akan dikirim di pesan berikutnya untuk kemudahan copy..._")
+
+	return infoBuilder.String()
+}
+
+// ConverterDisplayName nama tampilan converter untuk pesan chat (fallback: command dalam huruf besar)
+func (s *XRayConverterService) ConverterDisplayName(commandName string) string {
+	converter, err := s.repository.GetXRayConverter(commandName)
+	if err == nil && converter != nil && converter.DisplayName != "" {
+		return converter.DisplayName
+	}
+	return strings.ToUpper(commandName)
+}
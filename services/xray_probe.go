@@ -0,0 +1,165 @@
+// Package services - probe koneksi hasil konversi (TCP, TLS dengan SNI, HTTP upgrade ws/httpupgrade)
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// defaultProbeTimeout batas waktu total satu probe
+const defaultProbeTimeout = 8 * time.Second
+
+// Tahap probe, dipakai untuk melaporkan di mana koneksi gagal
+const (
+	ProbeStageTCP     = "tcp"
+	ProbeStageTLS     = "tls"
+	ProbeStageUpgrade = "upgrade"
+)
+
+// XRayProber melakukan handshake ke server hasil modifikasi untuk memastikan config bisa terhubung
+type XRayProber struct {
+	Timeout time.Duration
+
+	// DialContext membuka koneksi TCP, bisa diganti untuk mengarahkan probe ke server lokal
+	DialContext func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// NewXRayProber membuat prober dengan net.Dialer standar
+func NewXRayProber(timeout time.Duration) *XRayProber {
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	dialer := &net.Dialer{}
+	return &XRayProber{
+		Timeout:     timeout,
+		DialContext: dialer.DialContext,
+	}
+}
+
+// Probe menjalankan TCP connect, TLS handshake (SNI hasil modifikasi), lalu HTTP upgrade
+// untuk network ws/httpupgrade. Latency diukur dari awal dial sampai tahap terakhir berhasil.
+func (p *XRayProber) Probe(ctx context.Context, c *database.DetectedXRayConfig) *database.XRayProbeResult {
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	address := net.JoinHostPort(c.Server, strconv.Itoa(c.Port))
+	result := &database.XRayProbeResult{Target: address, Stage: ProbeStageTCP}
+	start := time.Now()
+
+	// kcp/quic berjalan di atas UDP, handshake TCP/TLS tidak mewakili koneksi sebenarnya
+	if isUDPTransport(c.Network) {
+		return probeFailed(result, start, fmt.Errorf("transport %s (UDP) tidak bisa di-probe", c.Network))
+	}
+
+	conn, err := p.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return probeFailed(result, start, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if c.TLS {
+		result.Stage = ProbeStageTLS
+		tlsConn := tls.Client(conn, probeTLSConfig(c))
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return probeFailed(result, start, err)
+		}
+		conn = tlsConn
+	}
+
+	if c.Network == "ws" || c.Network == "httpupgrade" {
+		result.Stage = ProbeStageUpgrade
+		if err := probeHTTPUpgrade(conn, c); err != nil {
+			return probeFailed(result, start, err)
+		}
+	}
+
+	result.Success = true
+	result.LatencyMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// probeTLSConfig konfigurasi TLS probe; sertifikat tidak diverifikasi karena bug host
+// hampir selalu tidak cocok dengan sertifikat server (sama seperti skip-cert-verify di client)
+func probeTLSConfig(c *database.DetectedXRayConfig) *tls.Config {
+	serverName := c.SNI
+	if serverName == "" {
+		serverName = c.Host
+	}
+	if serverName == "" {
+		serverName = c.Server
+	}
+
+	config := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	}
+	switch c.Network {
+	case "ws", "httpupgrade":
+		config.NextProtos = []string{"http/1.1"}
+	case "grpc", "h2", "http", "xhttp":
+		config.NextProtos = []string{"h2"}
+	}
+	return config
+}
+
+// probeHTTPUpgrade mengirim request upgrade websocket dan mengharapkan 101 Switching Protocols
+func probeHTTPUpgrade(conn net.Conn, c *database.DetectedXRayConfig) error {
+	path := c.Path
+	if path == "" {
+		path = "/"
+	}
+	host := c.Host
+	if host == "" {
+		host = c.Server
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return err
+	}
+
+	request := fmt.Sprintf("GET %s HTTP/1.1\r\n"+
+		"Host: %s\r\n"+
+		"User-Agent: Mozilla/5.0\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %s\r\n"+
+		"Sec-WebSocket-Version: 13\r\n\r\n",
+		path, host, base64.StdEncoding.EncodeToString(keyBytes))
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return fmt.Errorf("invalid upgrade response: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return fmt.Errorf("upgrade rejected: %s", resp.Status)
+	}
+	return nil
+}
+
+// probeFailed mengisi hasil probe yang gagal pada tahap saat ini
+func probeFailed(result *database.XRayProbeResult, start time.Time, err error) *database.XRayProbeResult {
+	result.Success = false
+	result.LatencyMs = time.Since(start).Milliseconds()
+	result.Error = err.Error()
+	return result
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// probeSNI SNI yang diterima server uji; SNI lain ditolak saat handshake
+const probeSNI = "sni.example.com"
+
+// newProbeTestServer server TLS lokal yang menjawab upgrade websocket di /ws dengan 101
+// dan menolak path lain dengan 403
+func newProbeTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws" || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if r.Host != "cdn.example.com" {
+			t.Errorf("upgrade Host header = %q, want cdn.example.com", r.Host)
+		}
+
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		buf.Flush()
+	}))
+	server.TLS = &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			if hello.ServerName != probeSNI {
+				return nil, fmt.Errorf("unknown server name %q", hello.ServerName)
+			}
+			return nil, nil
+		},
+	}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newLocalProber prober yang mengarahkan semua dial ke address server uji
+func newLocalProber(address string) *XRayProber {
+	prober := NewXRayProber(2 * time.Second)
+	dialer := &net.Dialer{}
+	prober.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+	return prober
+}
+
+func TestXRayProberProbe(t *testing.T) {
+	server := newProbeTestServer(t)
+	prober := newLocalProber(server.Listener.Addr().String())
+
+	tests := []struct {
+		name      string
+		config    database.DetectedXRayConfig
+		wantOK    bool
+		wantStage string
+		wantError string
+	}{
+		{
+			name:      "successful upgrade",
+			config:    database.DetectedXRayConfig{Server: "bug.example.com", Port: 443, Network: "ws", TLS: true, SNI: probeSNI, Host: "cdn.example.com", Path: "/ws"},
+			wantOK:    true,
+			wantStage: ProbeStageUpgrade,
+		},
+		{
+			name:      "rejected upgrade",
+			config:    database.DetectedXRayConfig{Server: "bug.example.com", Port: 443, Network: "ws", TLS: true, SNI: probeSNI, Host: "cdn.example.com", Path: "/blocked"},
+			wantStage: ProbeStageUpgrade,
+			wantError: "upgrade rejected: 403",
+		},
+		{
+			name:      "sni mismatch",
+			config:    database.DetectedXRayConfig{Server: "bug.example.com", Port: 443, Network: "ws", TLS: true, SNI: "other.example.com", Host: "cdn.example.com", Path: "/ws"},
+			wantStage: ProbeStageTLS,
+			wantError: "remote error: tls",
+		},
+		{
+			name:      "tls only for tcp",
+			config:    database.DetectedXRayConfig{Server: "bug.example.com", Port: 443, Network: "tcp", TLS: true, SNI: probeSNI},
+			wantOK:    true,
+			wantStage: ProbeStageTLS,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := prober.Probe(context.Background(), &tt.config)
+			if result.Success != tt.wantOK || result.Stage != tt.wantStage {
+				t.Fatalf("Probe() = success %v stage %q (error %q), want success %v stage %q",
+					result.Success, result.Stage, result.Error, tt.wantOK, tt.wantStage)
+			}
+			if result.Target != "bug.example.com:443" {
+				t.Errorf("Target = %q, want bug.example.com:443", result.Target)
+			}
+			if !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("Error = %q, want it to contain %q", result.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestXRayProberDialFailure(t *testing.T) {
+	prober := NewXRayProber(time.Second)
+	prober.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	result := prober.Probe(context.Background(), &database.DetectedXRayConfig{Server: "bug.example.com", Port: 443, Network: "ws", TLS: true})
+	if result.Success || result.Stage != ProbeStageTCP || result.Error != "connection refused" {
+		t.Errorf("Probe() = %+v, want tcp failure", result)
+	}
+}
+
+func TestXRayProberClosedPort(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	result := newLocalProber(address).Probe(context.Background(), &database.DetectedXRayConfig{Server: "127.0.0.1", Port: 1, Network: "tcp"})
+	if result.Success || result.Stage != ProbeStageTCP {
+		t.Errorf("Probe() = %+v, want tcp failure", result)
+	}
+}
+
+func TestXRayProberSkipsUDPTransport(t *testing.T) {
+	prober := NewXRayProber(time.Second)
+	prober.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		t.Fatal("kcp transport must not be dialed")
+		return nil, nil
+	}
+
+	result := prober.Probe(context.Background(), &database.DetectedXRayConfig{Server: "bug.example.com", Port: 443, Network: "kcp"})
+	if result.Success || !strings.Contains(result.Error, "UDP") {
+		t.Errorf("Probe() = %+v, want UDP transport failure", result)
+	}
+}
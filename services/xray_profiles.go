@@ -0,0 +1,266 @@
+// Package services - builder profile lengkap (Clash, sing-box) dari banyak hasil konversi
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// profileTestURL URL untuk health check url-test/fallback/load-balance
+const profileTestURL = "http://www.gstatic.com/generate_204"
+
+// defaultProfileRules rules jika converter tidak punya template
+var defaultProfileRules = []string{
+	"GEOIP,PRIVATE,DIRECT,no-resolve",
+	"MATCH,PROXY",
+}
+
+// BuildClashProfile membuat profile Mihomo/OpenClash lengkap: DNS, proxies, proxy-groups,
+// rule-providers dan rules dari template (nil = template bawaan)
+func BuildClashProfile(results []*database.ModifiedXRayConfig, template *database.XRayRuleTemplate) (string, error) {
+	proxies, err := parseClashProxies(results)
+	if err != nil {
+		return "", err
+	}
+
+	profile := clashProfile{
+		MixedPort:    7890,
+		AllowLAN:     false,
+		Mode:         "rule",
+		LogLevel:     "info",
+		UnifiedDelay: true,
+		DNS:          profileDNS(template),
+		Proxies:      proxies,
+		ProxyGroups:  profileGroups(proxies),
+		Rules:        profileRules(template),
+	}
+
+	if template != nil {
+		profile.RuleProviders, err = parseRuleProviders(template.RuleProviders)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	content, err := marshalClashYAML(profile)
+	if err != nil {
+		return "", err
+	}
+	if err := ValidateClashYAML(content); err != nil {
+		return "", err
+	}
+
+	return content, nil
+}
+
+// profileDNS section dns sesuai mode template
+func profileDNS(template *database.XRayRuleTemplate) *clashDNS {
+	mode := "fake-ip"
+	if template != nil && template.DNSMode != "" {
+		mode = template.DNSMode
+	}
+
+	dns := &clashDNS{
+		Enable:            true,
+		Listen:            "0.0.0.0:7874",
+		IPv6:              false,
+		EnhancedMode:      mode,
+		DefaultNameserver: []string{"8.8.8.8", "1.1.1.1"},
+		Nameserver:        []string{"https://dns.google/dns-query", "https://1.1.1.1/dns-query"},
+	}
+	if mode == "fake-ip" {
+		dns.FakeIPRange = "198.18.0.1/16"
+		dns.FakeIPFilter = []string{"*.lan", "+.local", "time.*.com"}
+	}
+
+	return dns
+}
+
+// profileGroups proxy-groups: select, url-test, fallback, load-balance
+func profileGroups(proxies []*clashProxy) []clashProxyGroup {
+	names := make([]string, 0, len(proxies))
+	for _, proxy := range proxies {
+		names = append(names, proxy.Name)
+	}
+
+	return []clashProxyGroup{
+		{
+			Name:    "PROXY",
+			Type:    "select",
+			Proxies: append([]string{"AUTO", "FALLBACK", "BALANCE"}, names...),
+		},
+		{
+			Name:      "AUTO",
+			Type:      "url-test",
+			URL:       profileTestURL,
+			Interval:  300,
+			Tolerance: 50,
+			Proxies:   names,
+		},
+		{
+			Name:     "FALLBACK",
+			Type:     "fallback",
+			URL:      profileTestURL,
+			Interval: 300,
+			Proxies:  names,
+		},
+		{
+			Name:     "BALANCE",
+			Type:     "load-balance",
+			Strategy: "consistent-hashing",
+			URL:      profileTestURL,
+			Interval: 300,
+			Proxies:  names,
+		},
+	}
+}
+
+// profileRules mengambil rules dari template dan memastikan diakhiri MATCH
+func profileRules(template *database.XRayRuleTemplate) []string {
+	if template == nil {
+		return defaultProfileRules
+	}
+
+	var rules []string
+	for _, line := range strings.Split(template.Rules, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "- "))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rules = append(rules, line)
+	}
+	if len(rules) == 0 {
+		return defaultProfileRules
+	}
+	if !strings.HasPrefix(strings.ToUpper(rules[len(rules)-1]), "MATCH,") {
+		rules = append(rules, "MATCH,PROXY")
+	}
+
+	return rules
+}
+
+// ValidateRuleTemplate cek template sebelum disimpan: dns mode, format rule, dan RULE-SET yang dirujuk
+func ValidateRuleTemplate(template *database.XRayRuleTemplate) error {
+	if strings.TrimSpace(template.Name) == "" {
+		return fmt.Errorf("template name is required")
+	}
+	switch template.DNSMode {
+	case "", "fake-ip", "redir-host":
+	default:
+		return fmt.Errorf("invalid dns mode: %s (gunakan fake-ip atau redir-host)", template.DNSMode)
+	}
+
+	providersNode, err := parseRuleProviders(template.RuleProviders)
+	if err != nil {
+		return err
+	}
+	providers := make(map[string]bool)
+	if providersNode != nil {
+		// Content mapping node berisi pasangan key/value berurutan
+		for i := 0; i+1 < len(providersNode.Content); i += 2 {
+			providers[providersNode.Content[i].Value] = true
+		}
+	}
+	for _, rule := range profileRules(template) {
+		parts := strings.Split(rule, ",")
+		if len(parts) < 2 {
+			return fmt.Errorf("invalid rule: %s", rule)
+		}
+		if strings.EqualFold(parts[0], "RULE-SET") && len(parts) >= 3 && !providers[parts[1]] {
+			return fmt.Errorf("rule-provider tidak ditemukan untuk rule: %s", rule)
+		}
+	}
+
+	return nil
+}
+
+// attachRuleTemplate memasang rule template converter ke hasil konversi (jika ada)
+func (s *XRayConverterService) attachRuleTemplate(result *database.ModifiedXRayConfig, converter *database.XRayConverter) {
+	if converter.ProfileTemplate == "" {
+		return
+	}
+
+	template, err := s.repository.GetXRayRuleTemplate(converter.ProfileTemplate)
+	if err != nil || template == nil || !template.IsActive {
+		if s.logger != nil {
+			s.logger.Warningf("Rule template %q unavailable for %s, using default rules", converter.ProfileTemplate, converter.CommandName)
+		}
+		template = nil
+	}
+
+	result.RuleTemplate = template
+	if template == nil {
+		// Tetap buat profile lengkap dengan rules bawaan
+		result.RuleTemplate = &database.XRayRuleTemplate{Name: converter.ProfileTemplate, DNSMode: "fake-ip"}
+	}
+}
+
+// profileTemplateOf mengambil rule template pertama dari hasil konversi
+func profileTemplateOf(results []*database.ModifiedXRayConfig) *database.XRayRuleTemplate {
+	for _, result := range results {
+		if result.RuleTemplate != nil {
+			return result.RuleTemplate
+		}
+	}
+	return nil
+}
+
+// BuildSingBoxProfile membuat profile sing-box lengkap (inbound mixed, selector & urltest)
+func BuildSingBoxProfile(results []*database.ModifiedXRayConfig) (string, error) {
+	var tags []string
+	var proxyOutbounds []interface{}
+
+	for _, result := range results {
+		outbound, err := singBoxOutbound(result.ModifiedConfig, result.ProxyName)
+		if err != nil {
+			continue // lewati proxy yang tidak didukung sing-box
+		}
+		tags = append(tags, result.ProxyName)
+		proxyOutbounds = append(proxyOutbounds, outbound)
+	}
+	if len(tags) == 0 {
+		return "", fmt.Errorf("tidak ada proxy yang didukung sing-box")
+	}
+
+	outbounds := []interface{}{
+		map[string]interface{}{
+			"type":      "selector",
+			"tag":       "select",
+			"outbounds": append([]string{"auto"}, tags...),
+			"default":   "auto",
+		},
+		map[string]interface{}{
+			"type":      "urltest",
+			"tag":       "auto",
+			"outbounds": tags,
+			"url":       "http://www.gstatic.com/generate_204",
+			"interval":  "5m",
+		},
+	}
+	outbounds = append(outbounds, proxyOutbounds...)
+	outbounds = append(outbounds, map[string]interface{}{"type": "direct", "tag": "direct"})
+
+	profile := map[string]interface{}{
+		"log": map[string]interface{}{"level": "warn"},
+		"inbounds": []interface{}{
+			map[string]interface{}{
+				"type":        "mixed",
+				"tag":         "mixed-in",
+				"listen":      "127.0.0.1",
+				"listen_port": 2080,
+			},
+		},
+		"outbounds": outbounds,
+		"route": map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{"ip_is_private": true, "outbound": "direct"},
+			},
+			"final":                 "select",
+			"auto_detect_interface": true,
+		},
+	}
+
+	return marshalIndentJSON(profile)
+}
@@ -0,0 +1,158 @@
+// Package services - kuota conversion per user, per grup, dan global untuk XRay converter
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// quotaWindow window kuota harian (rolling 24 jam dari conversion tertua)
+const quotaWindow = 24 * time.Hour
+
+// Cakupan kuota converter
+const (
+	QuotaScopeUser   = "user"
+	QuotaScopeGroup  = "group"
+	QuotaScopeGlobal = "global"
+)
+
+// QuotaExceededError dikembalikan ProcessConversion jika kuota converter sudah habis
+type QuotaExceededError struct {
+	ConverterName string
+	Scope         string
+	Limit         int
+	ResetAt       time.Time
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota exhausted: %s limit %d/day for %s, resets at %s",
+		e.Scope, e.Limit, e.ConverterName, e.ResetAt.Local().Format("15:04"))
+}
+
+// checkConversionQuota memastikan user, grup, dan converter masih punya sisa kuota.
+// Override admin menggantikan kuota per user; override 0 membebaskan user dari semua kuota.
+func (s *XRayConverterService) checkConversionQuota(converter *database.XRayConverter, userJID, groupJID string) error {
+	userNumber := NormalizeUserNumber(userJID)
+	userLimit := converter.DailyUserQuota
+
+	override, err := s.repository.GetXRayQuotaOverride(userNumber, converter.CommandName)
+	if err != nil {
+		return fmt.Errorf("failed to check quota: %v", err)
+	}
+	if override != nil {
+		if override.DailyLimit == 0 {
+			return nil
+		}
+		userLimit = override.DailyLimit
+	}
+
+	since := time.Now().Add(-quotaWindow)
+	checks := []struct {
+		scope  string
+		limit  int
+		filter database.XRayConversionFilter
+	}{
+		{QuotaScopeUser, userLimit, database.XRayConversionFilter{ConverterName: converter.CommandName, UserNumber: userNumber, Since: since}},
+		{QuotaScopeGroup, converter.DailyGroupQuota, database.XRayConversionFilter{ConverterName: converter.CommandName, GroupJID: groupJID, Since: since}},
+		{QuotaScopeGlobal, converter.DailyGlobalQuota, database.XRayConversionFilter{ConverterName: converter.CommandName, Since: since}},
+	}
+
+	for _, check := range checks {
+		if check.limit <= 0 {
+			continue
+		}
+		// Kuota grup hanya berlaku untuk chat grup, bukan personal chat admin
+		if check.scope == QuotaScopeGroup && !strings.HasSuffix(groupJID, "@g.us") {
+			continue
+		}
+
+		count, oldest, err := s.repository.CountXRayConversions(check.filter)
+		if err != nil {
+			return fmt.Errorf("failed to check quota: %v", err)
+		}
+		if count < check.limit {
+			continue
+		}
+
+		// Slot pertama baru kosong saat conversion ke-(count-limit+1) keluar dari window,
+		// misal limit diturunkan admin sehingga count > limit
+		resetAt := time.Now().Add(quotaWindow)
+		if oldest != nil {
+			resetAt = oldest.Add(quotaWindow)
+		}
+		if count > check.limit {
+			freedAt, err := s.repository.GetXRayConversionTimeAt(check.filter, count-check.limit)
+			if err != nil {
+				return fmt.Errorf("failed to check quota: %v", err)
+			}
+			if freedAt != nil {
+				resetAt = freedAt.Add(quotaWindow)
+			}
+		}
+		return &QuotaExceededError{
+			ConverterName: converter.CommandName,
+			Scope:         check.scope,
+			Limit:         check.limit,
+			ResetAt:       resetAt,
+		}
+	}
+
+	return nil
+}
+
+// SetQuotaOverride menetapkan kuota khusus user (converterName kosong = semua converter, limit 0 = tanpa batas)
+func (s *XRayConverterService) SetQuotaOverride(userNumber, converterName string, limit int, createdBy string) error {
+	userNumber = NormalizeUserNumber(userNumber)
+	if userNumber == "" {
+		return fmt.Errorf("nomor user tidak valid")
+	}
+	if limit < 0 {
+		return fmt.Errorf("limit tidak boleh negatif")
+	}
+
+	if converterName != "" {
+		converter, err := s.repository.GetXRayConverter(converterName)
+		if err != nil {
+			return err
+		}
+		if converter == nil {
+			return fmt.Errorf("converter not found: %s", converterName)
+		}
+	}
+
+	return s.repository.SetXRayQuotaOverride(&database.XRayQuotaOverride{
+		UserNumber:    userNumber,
+		ConverterName: converterName,
+		DailyLimit:    limit,
+		CreatedBy:     createdBy,
+	})
+}
+
+// RemoveQuotaOverride menghapus kuota khusus user
+func (s *XRayConverterService) RemoveQuotaOverride(userNumber, converterName string) error {
+	return s.repository.DeleteXRayQuotaOverride(NormalizeUserNumber(userNumber), converterName)
+}
+
+// GetQuotaOverrides mengambil semua kuota khusus user
+func (s *XRayConverterService) GetQuotaOverrides() ([]database.XRayQuotaOverride, error) {
+	return s.repository.GetAllXRayQuotaOverrides()
+}
+
+// NormalizeUserNumber mengambil nomor dari JID (628xx@s.whatsapp.net, 628xx:8@s.whatsapp.net, @628xx, +62 8xx)
+func NormalizeUserNumber(user string) string {
+	user = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(user), "@"))
+	if i := strings.IndexAny(user, "@:"); i >= 0 {
+		user = user[:i]
+	}
+
+	var digits strings.Builder
+	for _, r := range user {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	return digits.String()
+}
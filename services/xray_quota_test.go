@@ -0,0 +1,64 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nabilulilalbab/promote/database"
+	"github.com/nabilulilalbab/promote/utils"
+)
+
+func TestCheckConversionQuotaResetAt(t *testing.T) {
+	db, repo, err := database.InitializeLearningDatabase(filepath.Join(t.TempDir(), "quota.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// 5 conversion dalam window: 20, 16, 12, 8 dan 4 jam yang lalu
+	now := time.Now().UTC()
+	for _, hoursAgo := range []int{20, 16, 12, 8, 4} {
+		usedAt := now.Add(-time.Duration(hoursAgo) * time.Hour).Format("2006-01-02 15:04:05")
+		if _, err := db.Exec(`INSERT INTO xray_conversion_logs (converter_name, user_jid, group_jid, original_protocol,
+			original_server, modified_server, success, used_at) VALUES ('convtest', '6281@s.whatsapp.net', '', 'vless', 'a', 'b', 1, ?)`, usedAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	service := NewXRayConverterService(repo, utils.NewLogger("TEST", false))
+	tests := []struct {
+		name      string
+		limit     int
+		hoursAgo  int // conversion yang menentukan reset, 0 = kuota masih ada
+		wantError bool
+	}{
+		{"under limit", 6, 0, false},
+		{"at limit resets with oldest", 5, 20, true},
+		{"over limit resets with (count-limit+1)-th oldest", 3, 12, true},
+		{"limit one resets with newest", 1, 4, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converter := &database.XRayConverter{CommandName: "convtest", DailyUserQuota: tt.limit}
+			err := service.checkConversionQuota(converter, "6281@s.whatsapp.net", "6281@s.whatsapp.net")
+			if !tt.wantError {
+				if err != nil {
+					t.Fatalf("checkConversionQuota() error = %v", err)
+				}
+				return
+			}
+
+			var quotaErr *QuotaExceededError
+			if !errors.As(err, &quotaErr) {
+				t.Fatalf("checkConversionQuota() error = %v, want QuotaExceededError", err)
+			}
+			want := now.Add(-time.Duration(tt.hoursAgo) * time.Hour).Add(quotaWindow)
+			if diff := quotaErr.ResetAt.Sub(want); diff < -time.Second || diff > time.Second {
+				t.Errorf("ResetAt = %v, want %v", quotaErr.ResetAt, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// Package services - resolver DNS untuk placeholder {bug_ip} (system, DoH, DoT, cache, static)
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultResolverCacheTTL lama cache hasil resolve bug host
+const defaultResolverCacheTTL = 10 * time.Minute
+
+// resolverTimeout batas waktu satu kali resolve agar konversi tidak menggantung
+const resolverTimeout = 5 * time.Second
+
+// HostResolver resolve domain bug host menjadi alamat IP
+type HostResolver interface {
+	// ResolveIP mengembalikan satu alamat IP (IPv4 diutamakan) untuk host
+	ResolveIP(ctx context.Context, host string) (string, error)
+}
+
+// NewHostResolver membuat resolver dari string upstream lalu membungkusnya dengan cache:
+//   - "" atau "system"           : resolver sistem
+//   - "https://.../dns-query"    : DNS-over-HTTPS (JSON API, misal Cloudflare/Google)
+//   - "tls://1.1.1.1:853"        : DNS-over-TLS
+func NewHostResolver(upstream string, cacheTTL time.Duration) (HostResolver, error) {
+	var resolver HostResolver
+
+	upstream = strings.TrimSpace(upstream)
+	switch {
+	case upstream == "" || upstream == "system":
+		resolver = SystemResolver{}
+	case strings.HasPrefix(upstream, "https://"):
+		resolver = NewDoHResolver(upstream)
+	case strings.HasPrefix(upstream, "tls://"):
+		resolver = NewDoTResolver(strings.TrimPrefix(upstream, "tls://"))
+	default:
+		return nil, fmt.Errorf("unsupported DNS upstream: %s (gunakan system, https:// atau tls://)", upstream)
+	}
+
+	if cacheTTL <= 0 {
+		return resolver, nil
+	}
+	return NewCachingResolver(resolver, cacheTTL), nil
+}
+
+// SystemResolver memakai resolver bawaan sistem operasi
+type SystemResolver struct{}
+
+// ResolveIP resolve host memakai net.DefaultResolver
+func (SystemResolver) ResolveIP(ctx context.Context, host string) (string, error) {
+	return lookupWith(ctx, net.DefaultResolver, host)
+}
+
+// DoTResolver resolve host lewat DNS-over-TLS
+type DoTResolver struct {
+	resolver *net.Resolver
+}
+
+// NewDoTResolver membuat resolver DoT ke server "host:port" (port default 853)
+func NewDoTResolver(server string) *DoTResolver {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "853")
+	}
+	serverName, _, _ := net.SplitHostPort(server)
+
+	return &DoTResolver{
+		resolver: &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				dialer := &tls.Dialer{Config: &tls.Config{ServerName: serverName}}
+				return dialer.DialContext(ctx, "tcp", server)
+			},
+		},
+	}
+}
+
+// ResolveIP resolve host lewat upstream DoT
+func (r *DoTResolver) ResolveIP(ctx context.Context, host string) (string, error) {
+	return lookupWith(ctx, r.resolver, host)
+}
+
+// DoHResolver resolve host lewat DNS-over-HTTPS (format application/dns-json)
+type DoHResolver struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewDoHResolver membuat resolver DoH, contoh endpoint: https://cloudflare-dns.com/dns-query
+func NewDoHResolver(endpoint string) *DoHResolver {
+	return &DoHResolver{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: resolverTimeout},
+	}
+}
+
+// ResolveIP query record A (lalu AAAA) ke upstream DoH
+func (r *DoHResolver) ResolveIP(ctx context.Context, host string) (string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return host, nil
+	}
+
+	var lastErr error
+	for _, recordType := range []string{"A", "AAAA"} {
+		ip, err := r.query(ctx, host, recordType)
+		if err == nil {
+			return ip, nil
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// query satu request DoH untuk record type tertentu
+func (r *DoHResolver) query(ctx context.Context, host, recordType string) (string, error) {
+	params := url.Values{}
+	params.Set("name", host)
+	params.Set("type", recordType)
+
+	separator := "?"
+	if strings.Contains(r.endpoint, "?") {
+		separator = "&"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.endpoint+separator+params.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/dns-json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("DoH request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("DoH upstream returned %s", resp.Status)
+	}
+
+	var answer struct {
+		Status int `json:"Status"`
+		Answer []struct {
+			Type int    `json:"type"`
+			Data string `json:"data"`
+		} `json:"Answer"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+		return "", fmt.Errorf("invalid DoH response: %v", err)
+	}
+
+	for _, record := range answer.Answer {
+		// Lewati CNAME dan record lain, ambil yang berupa IP
+		if ip := net.ParseIP(record.Data); ip != nil {
+			return ip.String(), nil
+		}
+	}
+	return "", fmt.Errorf("no %s record for %s", recordType, host)
+}
+
+// CachingResolver menyimpan hasil resolve selama TTL agar bug host tidak di-query setiap konversi
+type CachingResolver struct {
+	next    HostResolver
+	ttl     time.Duration
+	mutex   sync.Mutex
+	entries map[string]resolverCacheEntry
+}
+
+// resolverCacheEntry satu entry cache resolver
+type resolverCacheEntry struct {
+	ip        string
+	expiresAt time.Time
+}
+
+// NewCachingResolver membungkus resolver dengan cache TTL
+func NewCachingResolver(next HostResolver, ttl time.Duration) *CachingResolver {
+	return &CachingResolver{
+		next:    next,
+		ttl:     ttl,
+		entries: make(map[string]resolverCacheEntry),
+	}
+}
+
+// ResolveIP mengambil dari cache atau resolve ke resolver berikutnya
+func (r *CachingResolver) ResolveIP(ctx context.Context, host string) (string, error) {
+	host = strings.ToLower(host)
+	now := time.Now()
+
+	r.mutex.Lock()
+	entry, ok := r.entries[host]
+	r.mutex.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.ip, nil
+	}
+
+	ip, err := r.next.ResolveIP(ctx, host)
+	if err != nil {
+		return "", err
+	}
+
+	r.mutex.Lock()
+	r.entries[host] = resolverCacheEntry{ip: ip, expiresAt: now.Add(r.ttl)}
+	r.mutex.Unlock()
+
+	return ip, nil
+}
+
+// StaticResolver resolver tetap dari map host -> IP (untuk testing atau override manual)
+type StaticResolver map[string]string
+
+// ResolveIP mengembalikan IP dari map, error jika host tidak terdaftar
+func (r StaticResolver) ResolveIP(_ context.Context, host string) (string, error) {
+	if ip, ok := r[strings.ToLower(host)]; ok {
+		return ip, nil
+	}
+	return "", fmt.Errorf("host %s not found in static resolver", host)
+}
+
+// lookupWith resolve host dengan net.Resolver dan mengutamakan IPv4
+func lookupWith(ctx context.Context, resolver *net.Resolver, host string) (string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return host, nil
+	}
+
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("no address for %s", host)
+	}
+
+	for _, addr := range addrs {
+		if ip4 := addr.IP.To4(); ip4 != nil {
+			return ip4.String(), nil
+		}
+	}
+	return addrs[0].IP.String(), nil
+}
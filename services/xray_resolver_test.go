@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nabilulilalbab/promote/database"
+	"github.com/nabilulilalbab/promote/utils"
+)
+
+// countingResolver resolver palsu yang menghitung jumlah query ke upstream
+type countingResolver struct {
+	mutex sync.Mutex
+	calls map[string]int
+	ips   map[string]string
+}
+
+func (r *countingResolver) ResolveIP(_ context.Context, host string) (string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.calls == nil {
+		r.calls = make(map[string]int)
+	}
+	r.calls[host]++
+	if ip, ok := r.ips[host]; ok {
+		return ip, nil
+	}
+	return "", errors.New("not found")
+}
+
+func (r *countingResolver) count(host string) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.calls[host]
+}
+
+func TestTemplateValuesPlaceholders(t *testing.T) {
+	service := NewXRayConverterService(nil, utils.NewLogger("TEST", false))
+	service.SetResolver(StaticResolver{"bug.example.com": "104.16.1.1"})
+
+	detected := &database.DetectedXRayConfig{
+		Server:  "origin.example.net",
+		Host:    "cdn.example.net",
+		SNI:     "sni.example.net",
+		Port:    443,
+		Path:    "/ws",
+		UUID:    "11111111-2222-3333-4444-555555555555",
+		Remarks: "SG-1",
+	}
+
+	tests := []struct {
+		name     string
+		bugHost  string
+		template string
+		want     string
+	}{
+		{"bug host", "bug.example.com", "{bug_host}", "bug.example.com"},
+		{"bug ip resolved", "bug.example.com", "{bug_ip}", "104.16.1.1"},
+		{"bug ip falls back to domain", "unknown.example.com", "{bug_ip}", "unknown.example.com"},
+		{"original server", "bug.example.com", "{original_server}", "origin.example.net"},
+		{"original host and sni", "bug.example.com", "{original_host}|{original_sni}", "cdn.example.net|sni.example.net"},
+		{"original port and path", "bug.example.com", "{original_path}?port={original_port}", "/ws?port=443"},
+		{"uuid and remarks", "bug.example.com", "{uuid}-{remarks}", "11111111-2222-3333-4444-555555555555-SG-1"},
+		{"combined", "bug.example.com", "{bug_ip}.{original_host}", "104.16.1.1.cdn.example.net"},
+		{"no placeholder", "bug.example.com", "static.example.org", "static.example.org"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converter := &database.XRayConverter{BugHost: tt.bugHost, ServerTemplate: tt.template}
+			got := processTemplate(tt.template, service.templateValues(converter, detected))
+			if got != tt.want {
+				t.Errorf("processTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateValuesRandomSubdomain(t *testing.T) {
+	service := NewXRayConverterService(nil, utils.NewLogger("TEST", false))
+	converter := &database.XRayConverter{BugHost: "bug.example.com", HostTemplate: "{random_subdomain}.{bug_host}"}
+	values := service.templateValues(converter, &database.DetectedXRayConfig{})
+
+	got := processTemplate(converter.HostTemplate, values)
+	if !regexp.MustCompile(`^[a-z0-9]{8}\.bug\.example\.com$`).MatchString(got) {
+		t.Fatalf("random subdomain host = %q", got)
+	}
+	// Satu konversi memakai subdomain yang sama di semua template
+	if again := processTemplate("{random_subdomain}", values); again+".bug.example.com" != got {
+		t.Errorf("random subdomain not stable within one conversion: %q vs %q", again, got)
+	}
+}
+
+func TestTemplateValuesSkipsResolveWhenUnused(t *testing.T) {
+	upstream := &countingResolver{ips: map[string]string{"bug.example.com": "1.2.3.4"}}
+	service := NewXRayConverterService(nil, utils.NewLogger("TEST", false))
+	service.SetResolver(upstream)
+
+	converter := &database.XRayConverter{BugHost: "bug.example.com", ServerTemplate: "{bug_host}"}
+	service.templateValues(converter, &database.DetectedXRayConfig{})
+	if calls := upstream.count("bug.example.com"); calls != 0 {
+		t.Errorf("resolver called %d times for template without {bug_ip}", calls)
+	}
+}
+
+func TestCachingResolverTTL(t *testing.T) {
+	upstream := &countingResolver{ips: map[string]string{"bug.example.com": "1.2.3.4"}}
+	resolver := NewCachingResolver(upstream, 50*time.Millisecond)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		ip, err := resolver.ResolveIP(ctx, "BUG.example.com")
+		if err != nil || ip != "1.2.3.4" {
+			t.Fatalf("ResolveIP = %q, %v", ip, err)
+		}
+	}
+	if calls := upstream.count("bug.example.com"); calls != 1 {
+		t.Fatalf("upstream calls within TTL = %d, want 1", calls)
+	}
+
+	time.Sleep(80 * time.Millisecond)
+	if _, err := resolver.ResolveIP(ctx, "bug.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := upstream.count("bug.example.com"); calls != 2 {
+		t.Errorf("upstream calls after expiry = %d, want 2", calls)
+	}
+}
+
+func TestCachingResolverDoesNotCacheErrors(t *testing.T) {
+	upstream := &countingResolver{}
+	resolver := NewCachingResolver(upstream, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if _, err := resolver.ResolveIP(context.Background(), "missing.example.com"); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if calls := upstream.count("missing.example.com"); calls != 2 {
+		t.Errorf("upstream calls = %d, want 2 (errors must not be cached)", calls)
+	}
+}
+
+func TestDoHResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/dns-json" {
+			t.Errorf("Accept header = %q", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "application/dns-json")
+		switch r.URL.Query().Get("name") + "/" + r.URL.Query().Get("type") {
+		case "bug.example.com/A":
+			w.Write([]byte(`{"Status":0,"Answer":[{"type":5,"data":"cdn.example.com."},{"type":1,"data":"104.16.1.1"}]}`))
+		case "v6.example.com/A":
+			w.Write([]byte(`{"Status":0,"Answer":[]}`))
+		case "v6.example.com/AAAA":
+			w.Write([]byte(`{"Status":0,"Answer":[{"type":28,"data":"2606:4700::1"}]}`))
+		default:
+			w.Write([]byte(`{"Status":3}`))
+		}
+	}))
+	defer server.Close()
+
+	resolver := NewDoHResolver(server.URL + "/dns-query")
+	tests := []struct {
+		host    string
+		want    string
+		wantErr bool
+	}{
+		{"bug.example.com", "104.16.1.1", false},
+		{"v6.example.com", "2606:4700::1", false},
+		{"10.0.0.1", "10.0.0.1", false},
+		{"missing.example.com", "", true},
+	}
+	for _, tt := range tests {
+		got, err := resolver.ResolveIP(context.Background(), tt.host)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ResolveIP(%q) = %q, %v; want %q, error %v", tt.host, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDoHResolverUpstreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	if _, err := NewDoHResolver(server.URL).ResolveIP(context.Background(), "bug.example.com"); err == nil {
+		t.Fatal("expected error for non-200 upstream")
+	}
+}
+
+func TestNewHostResolver(t *testing.T) {
+	tests := []struct {
+		upstream string
+		ttl      time.Duration
+		want     string
+		wantErr  bool
+	}{
+		{"", 0, "SystemResolver", false},
+		{"system", 0, "SystemResolver", false},
+		{"https://cloudflare-dns.com/dns-query", 0, "DoHResolver", false},
+		{"tls://1.1.1.1", 0, "DoTResolver", false},
+		{"system", time.Minute, "CachingResolver", false},
+		{"udp://8.8.8.8", 0, "", true},
+	}
+	for _, tt := range tests {
+		resolver, err := NewHostResolver(tt.upstream, tt.ttl)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NewHostResolver(%q) expected error", tt.upstream)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NewHostResolver(%q) error: %v", tt.upstream, err)
+			continue
+		}
+
+		var got string
+		switch resolver.(type) {
+		case SystemResolver:
+			got = "SystemResolver"
+		case *DoHResolver:
+			got = "DoHResolver"
+		case *DoTResolver:
+			got = "DoTResolver"
+		case *CachingResolver:
+			got = "CachingResolver"
+		}
+		if got != tt.want {
+			t.Errorf("NewHostResolver(%q, %v) = %T, want %s", tt.upstream, tt.ttl, resolver, tt.want)
+		}
+	}
+}
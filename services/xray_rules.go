@@ -0,0 +1,216 @@
+// Package services - rule engine deklaratif untuk modifikasi field XRay config
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// Field yang bisa diubah oleh rule converter
+const (
+	RuleFieldServer      = "server"
+	RuleFieldHost        = "host"
+	RuleFieldSNI         = "sni"
+	RuleFieldPath        = "path"
+	RuleFieldPort        = "port"
+	RuleFieldServiceName = "service_name"
+	RuleFieldRemarks     = "remarks"
+)
+
+// ruleFields urutan field yang dikenali rule engine
+var ruleFields = []string{
+	RuleFieldServer, RuleFieldHost, RuleFieldSNI, RuleFieldPath,
+	RuleFieldPort, RuleFieldServiceName, RuleFieldRemarks,
+}
+
+// Action rule converter
+const (
+	RuleActionSet     = "set"     // ganti seluruh nilai field dengan Value
+	RuleActionReplace = "replace" // ganti substring Match dengan Value
+	RuleActionRegex   = "regex"   // regexp.ReplaceAllString(Match, Value), mendukung $1
+)
+
+// ruleFieldValues nilai field config yang sedang diproses rule engine
+type ruleFieldValues map[string]string
+
+// newRuleFieldValues mengambil nilai awal field dari config hasil deteksi
+func newRuleFieldValues(detected *database.DetectedXRayConfig) ruleFieldValues {
+	return ruleFieldValues{
+		RuleFieldServer:      detected.Server,
+		RuleFieldHost:        detected.Host,
+		RuleFieldSNI:         detected.SNI,
+		RuleFieldPath:        detected.Path,
+		RuleFieldPort:        strconv.Itoa(detected.Port),
+		RuleFieldServiceName: detected.ServiceName,
+		RuleFieldRemarks:     detected.Remarks,
+	}
+}
+
+// converterRules menyusun rule lengkap converter: rule bawaan dari template/modify type
+// (perilaku lama) diikuti rule tambahan milik converter sesuai urutan
+func converterRules(converter *database.XRayConverter) []database.XRayConverterRule {
+	var rules []database.XRayConverterRule
+
+	set := func(field, value string) {
+		rules = append(rules, database.XRayConverterRule{Field: field, Action: RuleActionSet, Value: value})
+	}
+
+	if converter.ServerTemplate != "" || converter.HostTemplate != "" || converter.SNITemplate != "" {
+		// Template kosong = pakai nilai asli
+		if converter.ServerTemplate != "" {
+			set(RuleFieldServer, converter.ServerTemplate)
+		}
+		if converter.HostTemplate != "" {
+			set(RuleFieldHost, converter.HostTemplate)
+		}
+		if converter.SNITemplate != "" {
+			set(RuleFieldSNI, converter.SNITemplate)
+		}
+	} else {
+		switch converter.ModifyType {
+		case "wildcard":
+			set(RuleFieldServer, "{bug_host}")
+			set(RuleFieldHost, "{bug_host}.{original_server}")
+			set(RuleFieldSNI, "{bug_host}.{original_server}")
+		case "sni":
+			set(RuleFieldSNI, "{bug_host}")
+		case "ws", "grpc":
+			set(RuleFieldServer, "{bug_host}")
+		}
+	}
+
+	// Path template hanya untuk tipe ws/grpc pada network berbasis HTTP,
+	// wildcard dan sni mempertahankan path asli user
+	if converter.PathTemplate != "" && (converter.ModifyType == "ws" || converter.ModifyType == "grpc") {
+		rules = append(rules, database.XRayConverterRule{
+			Field:  RuleFieldPath,
+			Action: RuleActionSet,
+			Value:  converter.PathTemplate,
+			When:   database.XRayRuleCondition{Networks: []string{"ws", "httpupgrade", "h2"}},
+		})
+	}
+
+	if converter.PortOverride != nil {
+		set(RuleFieldPort, strconv.Itoa(*converter.PortOverride))
+	}
+
+	return append(rules, converter.Rules...)
+}
+
+// applyConverterRules menjalankan rules secara berurutan terhadap nilai field.
+// Placeholder template diproses di Value sebelum dipakai.
+func applyConverterRules(fields ruleFieldValues, rules []database.XRayConverterRule, detected *database.DetectedXRayConfig, values map[string]string) error {
+	for i, rule := range rules {
+		if !ruleMatches(rule.When, detected) {
+			continue
+		}
+
+		current, ok := fields[rule.Field]
+		if !ok {
+			return fmt.Errorf("rule #%d: unknown field %q", i+1, rule.Field)
+		}
+		value := processTemplate(rule.Value, values)
+
+		switch rule.Action {
+		case RuleActionSet:
+			current = value
+		case RuleActionReplace:
+			if rule.Match != "" {
+				current = strings.ReplaceAll(current, rule.Match, value)
+			}
+		case RuleActionRegex:
+			pattern, err := regexp.Compile(rule.Match)
+			if err != nil {
+				return fmt.Errorf("rule #%d: invalid regex: %v", i+1, err)
+			}
+			current = pattern.ReplaceAllString(current, value)
+		default:
+			return fmt.Errorf("rule #%d: unknown action %q", i+1, rule.Action)
+		}
+
+		fields[rule.Field] = current
+	}
+
+	if port, err := strconv.Atoi(fields[RuleFieldPort]); err != nil || port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid port after rules: %q", fields[RuleFieldPort])
+	}
+
+	return nil
+}
+
+// ruleMatches cek kondisi rule terhadap config asli
+func ruleMatches(when database.XRayRuleCondition, detected *database.DetectedXRayConfig) bool {
+	if len(when.Protocols) > 0 && !containsFold(when.Protocols, detected.Protocol) {
+		return false
+	}
+	if len(when.Networks) > 0 && !containsFold(when.Networks, detected.Network) {
+		return false
+	}
+	if when.TLS != nil && *when.TLS != detected.TLS {
+		return false
+	}
+	if len(when.Ports) > 0 {
+		found := false
+		for _, port := range when.Ports {
+			if port == detected.Port {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold cek apakah list berisi value (case-insensitive)
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateConverterRules memastikan field, action, dan regex setiap rule valid
+func ValidateConverterRules(rules []database.XRayConverterRule) error {
+	for i, rule := range rules {
+		known := false
+		for _, field := range ruleFields {
+			if rule.Field == field {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("rule #%d: unknown field %q (tersedia: %s)", i+1, rule.Field, strings.Join(ruleFields, ", "))
+		}
+
+		switch rule.Action {
+		case RuleActionSet:
+		case RuleActionReplace:
+			if rule.Match == "" {
+				return fmt.Errorf("rule #%d: replace membutuhkan match", i+1)
+			}
+		case RuleActionRegex:
+			if _, err := regexp.Compile(rule.Match); err != nil {
+				return fmt.Errorf("rule #%d: invalid regex: %v", i+1, err)
+			}
+		default:
+			return fmt.Errorf("rule #%d: unknown action %q (set, replace, regex)", i+1, rule.Action)
+		}
+
+		if rule.Field == RuleFieldPort && rule.Action == RuleActionSet && !strings.Contains(rule.Value, "{") {
+			if port, err := strconv.Atoi(rule.Value); err != nil || port <= 0 || port > 65535 {
+				return fmt.Errorf("rule #%d: invalid port %q", i+1, rule.Value)
+			}
+		}
+	}
+	return nil
+}
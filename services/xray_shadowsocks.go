@@ -0,0 +1,259 @@
+// Package services - parsing dan regenerasi link Shadowsocks (SIP002 & legacy base64)
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// parseShadowsocks parsing Shadowsocks link, mendukung dua format:
+//   - SIP002: ss://base64(method:password)@server:port/?plugin=...#remarks
+//   - Legacy: ss://base64(method:password@server:port)#remarks
+func (s *XRayConverterService) parseShadowsocks(ssLink string) (*database.DetectedXRayConfig, error) {
+	body := strings.TrimPrefix(ssLink, "ss://")
+
+	// Pisahkan remarks dari fragment
+	remarks := ""
+	if idx := strings.Index(body, "#"); idx >= 0 {
+		remarks, _ = url.PathUnescape(body[idx+1:])
+		body = body[:idx]
+	}
+
+	// Legacy format: seluruh body (sebelum query) adalah base64
+	mainPart := body
+	query := ""
+	if idx := strings.Index(body, "?"); idx >= 0 {
+		mainPart = strings.TrimSuffix(body[:idx], "/")
+		query = body[idx+1:]
+	}
+	if !strings.Contains(mainPart, "@") {
+		decoded, err := decodeBase64String(mainPart)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode shadowsocks link: %v", err)
+		}
+		mainPart = decoded
+	}
+
+	// Sekarang mainPart berbentuk userinfo@server:port
+	atIdx := strings.LastIndex(mainPart, "@")
+	if atIdx < 0 {
+		return nil, fmt.Errorf("invalid shadowsocks link: missing server")
+	}
+	userInfo := mainPart[:atIdx]
+	hostPort := mainPart[atIdx+1:]
+
+	cipher, password, err := parseShadowsocksUserInfo(userInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	server, portStr, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shadowsocks server: %v", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shadowsocks port: %s", portStr)
+	}
+
+	queryParams, _ := url.ParseQuery(query)
+
+	config := &database.DetectedXRayConfig{
+		Protocol: "shadowsocks",
+		Server:   server,
+		Port:     port,
+		UUID:     password,
+		Cipher:   cipher,
+		Network:  "tcp",
+		Remarks:  remarks,
+	}
+
+	// Plugin SIP003: "v2ray-plugin;mode=websocket;host=x;path=/y;tls"
+	if plugin := queryParams.Get("plugin"); plugin != "" {
+		config.Plugin, config.PluginOpts = splitShadowsocksPlugin(plugin)
+		switch config.Plugin {
+		case "v2ray-plugin":
+			if mode, _ := pluginOptValue(config.PluginOpts, "mode"); mode == "" || mode == "websocket" {
+				config.Network = "ws"
+			}
+			config.Host, _ = pluginOptValue(config.PluginOpts, "host")
+			config.Path, _ = pluginOptValue(config.PluginOpts, "path")
+			if _, ok := pluginOptValue(config.PluginOpts, "tls"); ok {
+				config.TLS = true
+				config.Security = "tls"
+				config.SNI = config.Host
+			}
+		case "obfs-local", "simple-obfs":
+			config.Host, _ = pluginOptValue(config.PluginOpts, "obfs-host")
+			config.HeaderType, _ = pluginOptValue(config.PluginOpts, "obfs")
+		}
+	}
+	if config.Host == "" {
+		config.Host = config.Server
+	}
+
+	rawConfig := map[string]interface{}{
+		"protocol": "shadowsocks",
+		"server":   config.Server,
+		"port":     strconv.Itoa(config.Port),
+		"uuid":     config.UUID,
+		"cipher":   config.Cipher,
+		"network":  config.Network,
+		"remarks":  config.Remarks,
+		"host":     config.Host,
+	}
+	if config.Path != "" {
+		rawConfig["path"] = config.Path
+	}
+	if config.TLS {
+		rawConfig["tls"] = "tls"
+		rawConfig["sni"] = config.SNI
+	}
+	if config.Plugin != "" {
+		rawConfig["plugin"] = config.Plugin
+		rawConfig["plugin_opts"] = config.PluginOpts
+	}
+	config.RawConfig = rawConfig
+
+	return config, nil
+}
+
+// parseShadowsocksUserInfo decode userinfo SIP002 (base64 atau percent-encoded untuk SS 2022)
+func parseShadowsocksUserInfo(userInfo string) (string, string, error) {
+	plain := ""
+	if decoded, err := decodeBase64String(userInfo); err == nil && strings.Contains(decoded, ":") {
+		plain = decoded
+	} else if unescaped, err := url.PathUnescape(userInfo); err == nil {
+		plain = unescaped
+	}
+
+	parts := strings.SplitN(plain, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", fmt.Errorf("invalid shadowsocks userinfo: expected method:password")
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// generateShadowsocksLink generate link SIP002 dari modified config
+func (s *XRayConverterService) generateShadowsocksLink(config map[string]interface{}) (string, error) {
+	server := getString(config, "server")
+	port := getString(config, "port")
+	cipher := getString(config, "cipher")
+	password := getString(config, "uuid")
+	remarks := getString(config, "remarks")
+
+	if server == "" || port == "" || cipher == "" || password == "" {
+		return "", fmt.Errorf("missing required fields for shadowsocks link")
+	}
+
+	userInfo := base64.RawURLEncoding.EncodeToString([]byte(cipher + ":" + password))
+	linkURL := fmt.Sprintf("ss://%s@%s", userInfo, net.JoinHostPort(server, port))
+
+	if plugin := buildShadowsocksPlugin(config); plugin != "" {
+		linkURL += "/?plugin=" + url.QueryEscape(plugin)
+	}
+
+	if remarks != "" {
+		linkURL += "#" + url.PathEscape(remarks)
+	}
+
+	return linkURL, nil
+}
+
+// buildShadowsocksPlugin menyusun ulang string plugin dengan host/path hasil modifikasi
+func buildShadowsocksPlugin(config map[string]interface{}) string {
+	plugin := getString(config, "plugin")
+	if plugin == "" {
+		return ""
+	}
+	opts := getString(config, "plugin_opts")
+
+	switch plugin {
+	case "v2ray-plugin":
+		if host := getString(config, "host"); host != "" {
+			opts = setPluginOpt(opts, "host", host)
+		}
+		if path := getString(config, "path"); path != "" {
+			opts = setPluginOpt(opts, "path", path)
+		}
+	case "obfs-local", "simple-obfs":
+		if host := getString(config, "host"); host != "" {
+			opts = setPluginOpt(opts, "obfs-host", host)
+		}
+	}
+
+	if opts == "" {
+		return plugin
+	}
+	return plugin + ";" + opts
+}
+
+// splitShadowsocksPlugin memisahkan nama plugin dari opsi-opsinya
+func splitShadowsocksPlugin(plugin string) (string, string) {
+	parts := strings.SplitN(plugin, ";", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// pluginOptValue mengambil nilai opsi plugin; flag tanpa nilai (misal "tls") mengembalikan ok=true
+func pluginOptValue(opts, key string) (string, bool) {
+	for _, opt := range strings.Split(opts, ";") {
+		k, v, _ := strings.Cut(opt, "=")
+		if k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// setPluginOpt mengganti (atau menambahkan) opsi plugin dengan tetap menjaga urutan
+func setPluginOpt(opts, key, value string) string {
+	var result []string
+	found := false
+	for _, opt := range strings.Split(opts, ";") {
+		if opt == "" {
+			continue
+		}
+		k, _, _ := strings.Cut(opt, "=")
+		if k == key {
+			opt = key + "=" + value
+			found = true
+		}
+		result = append(result, opt)
+	}
+	if !found {
+		result = append(result, key+"="+value)
+	}
+	return strings.Join(result, ";")
+}
+
+// decodeBase64String decode base64 dengan toleransi padding dan varian URL-safe
+func decodeBase64String(data string) (string, error) {
+	data = strings.TrimSpace(data)
+	encodings := []*base64.Encoding{
+		base64.StdEncoding,
+		base64.RawStdEncoding,
+		base64.URLEncoding,
+		base64.RawURLEncoding,
+	}
+
+	var lastErr error
+	for _, enc := range encodings {
+		decoded, err := enc.DecodeString(data)
+		if err == nil {
+			return string(decoded), nil
+		}
+		lastErr = err
+	}
+
+	return "", lastErr
+}
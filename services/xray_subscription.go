@@ -0,0 +1,212 @@
+// Package services - subscription URL per user untuk hasil konversi XRay
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nabilulilalbab/promote/database"
+)
+
+// maxSubscriptionItems batas jumlah link yang disimpan per subscription
+const maxSubscriptionItems = 100
+
+// ErrSubscriptionNotFound dikembalikan jika token subscription tidak dikenal
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+// ConvertLink mengkonversi link dengan converter tanpa mencatat log/usage (dipakai subscription)
+func (s *XRayConverterService) ConvertLink(converter *database.XRayConverter, xrayLink string) (*database.ModifiedXRayConfig, error) {
+	detected, err := s.DetectXRayConfig(xrayLink)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.ModifyXRayConfig(detected, converter)
+	if err != nil {
+		return nil, err
+	}
+	s.attachRuleTemplate(result, converter)
+	return result, nil
+}
+
+// GetOrCreateSubscription mendapatkan subscription user, membuat baru jika belum ada
+func (s *XRayConverterService) GetOrCreateSubscription(userJID string) (*database.XRaySubscription, error) {
+	sub, err := s.repository.GetXRaySubscriptionByUser(userJID)
+	if err != nil {
+		return nil, err
+	}
+	if sub != nil {
+		return sub, nil
+	}
+
+	token, err := generateSubscriptionToken()
+	if err != nil {
+		return nil, err
+	}
+	sub = &database.XRaySubscription{
+		UserJID: userJID,
+		Token:   token,
+	}
+	if err := s.repository.CreateXRaySubscription(sub); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// AddToSubscription menyimpan link asli ke subscription user agar bisa dikonversi ulang
+func (s *XRayConverterService) AddToSubscription(userJID, converterName string, links []string) (*database.XRaySubscription, error) {
+	sub, err := s.GetOrCreateSubscription(userJID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.repository.GetXRaySubscriptionItems(sub.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items)+len(links) > maxSubscriptionItems {
+		return nil, fmt.Errorf("subscription penuh (maksimal %d link), gunakan .clearsub", maxSubscriptionItems)
+	}
+
+	for _, link := range links {
+		item := &database.XRaySubscriptionItem{
+			SubscriptionID: sub.ID,
+			ConverterName:  converterName,
+			OriginalLink:   strings.TrimSpace(link),
+		}
+		if err := s.repository.AddXRaySubscriptionItem(item); err != nil {
+			return nil, err
+		}
+	}
+
+	return sub, nil
+}
+
+// ResetSubscriptionToken mengganti token subscription (URL lama tidak berlaku lagi)
+func (s *XRayConverterService) ResetSubscriptionToken(userJID string) (*database.XRaySubscription, error) {
+	sub, err := s.GetOrCreateSubscription(userJID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generateSubscriptionToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repository.UpdateXRaySubscriptionToken(sub.ID, token); err != nil {
+		return nil, err
+	}
+	sub.Token = token
+
+	return sub, nil
+}
+
+// ClearSubscription menghapus semua link dari subscription user
+func (s *XRayConverterService) ClearSubscription(userJID string) error {
+	sub, err := s.repository.GetXRaySubscriptionByUser(userJID)
+	if err != nil || sub == nil {
+		return err
+	}
+	return s.repository.DeleteXRaySubscriptionItems(sub.ID)
+}
+
+// CountSubscriptionItems jumlah link yang tersimpan di subscription
+func (s *XRayConverterService) CountSubscriptionItems(sub *database.XRaySubscription) int {
+	items, err := s.repository.GetXRaySubscriptionItems(sub.ID)
+	if err != nil {
+		return 0
+	}
+	return len(items)
+}
+
+// RenderSubscription mengkonversi ulang semua link subscription dengan converter terbaru
+// dan merender ke format: v2ray (base64), clash, atau singbox.
+// Mengembalikan isi dan content-type.
+func (s *XRayConverterService) RenderSubscription(token, format string) (string, string, error) {
+	sub, err := s.repository.GetXRaySubscriptionByToken(token)
+	if err != nil {
+		return "", "", err
+	}
+	if sub == nil {
+		return "", "", ErrSubscriptionNotFound
+	}
+
+	results, err := s.convertSubscriptionItems(sub)
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(results) == 0 {
+		return "", "", fmt.Errorf("subscription is empty")
+	}
+
+	switch normalizeFormatName(format) {
+	case "", "v2ray", "xray", "base64", "link":
+		return combineSubscription(results), "text/plain; charset=utf-8", nil
+	case "clash":
+		profile, err := BuildClashProfile(results, profileTemplateOf(results))
+		if err != nil {
+			return "", "", err
+		}
+		return profile, "text/yaml; charset=utf-8", nil
+	case "singbox":
+		profile, err := BuildSingBoxProfile(results)
+		if err != nil {
+			return "", "", err
+		}
+		return profile, "application/json; charset=utf-8", nil
+	}
+
+	return "", "", fmt.Errorf("unsupported subscription format: %s", format)
+}
+
+// convertSubscriptionItems konversi ulang setiap link memakai setting converter saat ini
+func (s *XRayConverterService) convertSubscriptionItems(sub *database.XRaySubscription) ([]*database.ModifiedXRayConfig, error) {
+	items, err := s.repository.GetXRaySubscriptionItems(sub.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	converters := make(map[string]*database.XRayConverter)
+	usedNames := make(proxyNameSet)
+	var results []*database.ModifiedXRayConfig
+
+	for _, item := range items {
+		converter, ok := converters[item.ConverterName]
+		if !ok {
+			converter, err = s.repository.GetXRayConverter(item.ConverterName)
+			if err != nil {
+				return nil, err
+			}
+			converters[item.ConverterName] = converter
+		}
+		if converter == nil || !converter.IsActive {
+			continue // converter sudah dihapus/nonaktif
+		}
+
+		result, err := s.ConvertLink(converter, item.OriginalLink)
+		if err != nil {
+			if s.logger != nil {
+				s.logger.Warningf("Subscription item %d skipped: %v", item.ID, err)
+			}
+			continue
+		}
+
+		s.ensureUniqueProxyName(usedNames, result)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// generateSubscriptionToken membuat token acak 32 karakter hex
+func generateSubscriptionToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
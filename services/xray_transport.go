@@ -0,0 +1,70 @@
+// Package services - normalisasi transport XRay (tcp/ws/h2/grpc/httpupgrade/kcp/quic/xhttp)
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// networkAliases nama transport lama/alternatif yang dipakai share link -> nama kanonik
+var networkAliases = map[string]string{
+	"":          "tcp",
+	"raw":       "tcp",
+	"http":      "h2",
+	"mkcp":      "kcp",
+	"splithttp": "xhttp",
+}
+
+// normalizeNetwork menyeragamkan nama transport dari link (http -> h2, splithttp -> xhttp, dst)
+func normalizeNetwork(network string) string {
+	network = strings.ToLower(strings.TrimSpace(network))
+	if alias, ok := networkAliases[network]; ok {
+		return alias
+	}
+	return network
+}
+
+// normalizeHeaderType header type "none" sama dengan tanpa header
+func normalizeHeaderType(headerType string) string {
+	headerType = strings.ToLower(strings.TrimSpace(headerType))
+	if headerType == "none" {
+		return ""
+	}
+	return headerType
+}
+
+// transportUsesHostPath cek apakah field host/path link dipakai sebagai Host header & path HTTP.
+// kcp dan quic (UDP) memakai field tersebut untuk seed/security/key sehingga tidak boleh diubah rule.
+func transportUsesHostPath(network string) bool {
+	switch network {
+	case "kcp", "quic":
+		return false
+	}
+	return true
+}
+
+// isUDPTransport transport berbasis UDP (tidak bisa di-probe lewat TCP)
+func isUDPTransport(network string) bool {
+	return network == "kcp" || network == "quic"
+}
+
+// splitHosts memecah daftar host dipisah koma (h2/http header bisa punya beberapa host)
+func splitHosts(hosts string) []string {
+	var result []string
+	for _, host := range strings.Split(hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			result = append(result, host)
+		}
+	}
+	return result
+}
+
+// UnsupportedTransportError transport valid di Xray tapi tidak bisa ditulis untuk client tertentu
+type UnsupportedTransportError struct {
+	Client  string // clash/sing-box
+	Network string
+}
+
+func (e *UnsupportedTransportError) Error() string {
+	return fmt.Sprintf("%s tidak mendukung transport %s", e.Client, e.Network)
+}
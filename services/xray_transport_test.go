@@ -0,0 +1,188 @@
+package services
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/nabilulilalbab/promote/database"
+	"github.com/nabilulilalbab/promote/utils"
+)
+
+const transportTestUUID = "3f1c8a52-5d1e-4b7a-9c2f-6e4d8b0a1c3e"
+
+// vmessJSONLink membungkus config VMESS JSON menjadi link vmess:// base64
+func vmessJSONLink(config string) string {
+	return "vmess://" + base64.StdEncoding.EncodeToString([]byte(config))
+}
+
+// transportFields field transport yang harus bertahan dari parse sampai generate ulang
+type transportFields struct {
+	Network      string
+	TLS          bool
+	SNI          string
+	Host         string
+	Path         string
+	HeaderType   string
+	Seed         string
+	QUICSecurity string
+	Mode         string
+}
+
+func transportFieldsOf(c *database.DetectedXRayConfig) transportFields {
+	return transportFields{
+		Network:      c.Network,
+		TLS:          c.TLS,
+		SNI:          c.SNI,
+		Host:         c.Host,
+		Path:         c.Path,
+		HeaderType:   c.HeaderType,
+		Seed:         c.Seed,
+		QUICSecurity: c.QUICSecurity,
+		Mode:         c.Mode,
+	}
+}
+
+func TestTransportLinks(t *testing.T) {
+	tests := []struct {
+		name       string
+		link       string
+		want       transportFields
+		linkParams []string // query link hasil generate (kosong untuk VMESS JSON)
+		clash      []string // potongan YAML Clash yang diharapkan
+		clashErr   string   // alasan YAMLError jika transport tidak didukung Clash
+		singBox    []string // potongan outbound sing-box yang diharapkan
+		singBoxErr string
+	}{
+		{
+			name:       "vless h2 (type=http)",
+			link:       "vless://" + transportTestUUID + "@h2.example.com:443?type=http&security=tls&sni=h2.example.com&host=h2.example.com&path=%2Fh2-path#H2-TLS",
+			want:       transportFields{Network: "h2", TLS: true, SNI: "h2.example.com", Host: "h2.example.com", Path: "/h2-path"},
+			linkParams: []string{"type=http", "sni=h2.example.com", "host=h2.example.com", "path=/h2-path"},
+			clash:      []string{"network: h2", "servername: h2.example.com", "h2-opts:", "- h2.example.com", "path: /h2-path"},
+			singBox:    []string{`"type": "http"`, `"path": "/h2-path"`, `"server_name": "h2.example.com"`},
+		},
+		{
+			name:       "vmess url h2 with multiple hosts",
+			link:       "vmess://" + transportTestUUID + "@h2vm.example.com:443?type=h2&security=tls&sni=h2vm.example.com&host=a.example.com,b.example.com&path=%2Fvmh2#VM-H2",
+			want:       transportFields{Network: "h2", TLS: true, SNI: "h2vm.example.com", Host: "a.example.com,b.example.com", Path: "/vmh2"},
+			linkParams: []string{"type=h2", "host=a.example.com%2Cb.example.com", "path=/vmh2"},
+			clash:      []string{"uuid: " + transportTestUUID, "network: h2", "- a.example.com", "- b.example.com", "path: /vmh2"},
+			singBox:    []string{`"type": "http"`, `"a.example.com"`, `"b.example.com"`},
+		},
+		{
+			name:       "vmess json kcp with seed and header",
+			link:       vmessJSONLink(`{"v":"2","ps":"SG-KCP","add":"kcp.example.com","port":"8443","id":"` + transportTestUUID + `","aid":"0","scy":"auto","net":"kcp","type":"wechat-video","host":"","path":"kcpseed123","tls":""}`),
+			want:       transportFields{Network: "kcp", HeaderType: "wechat-video", Seed: "kcpseed123"},
+			clashErr:   "Clash/Mihomo tidak mendukung transport kcp",
+			singBoxErr: "network kcp tidak didukung",
+		},
+		{
+			name:       "trojan kcp with seed",
+			link:       "trojan://secret@kcp2.example.com:443?type=kcp&security=tls&sni=kcp2.example.com&seed=trojanseed&headerType=srtp#TROJAN-KCP",
+			want:       transportFields{Network: "kcp", TLS: true, SNI: "kcp2.example.com", HeaderType: "srtp", Seed: "trojanseed"},
+			linkParams: []string{"type=kcp", "seed=trojanseed", "headerType=srtp"},
+			clashErr:   "Clash/Mihomo tidak mendukung transport kcp",
+			singBoxErr: "network kcp tidak didukung",
+		},
+		{
+			name:       "vless quic",
+			link:       "vless://" + transportTestUUID + "@quic.example.com:443?type=quic&security=tls&sni=quic.example.com&quicSecurity=none&key=&headerType=none#QUIC",
+			want:       transportFields{Network: "quic", TLS: true, SNI: "quic.example.com", QUICSecurity: "none"},
+			linkParams: []string{"type=quic", "quicSecurity=none", "sni=quic.example.com"},
+			clashErr:   "Clash/Mihomo tidak mendukung transport quic",
+			singBox:    []string{`"type": "quic"`, `"server_name": "quic.example.com"`},
+		},
+		{
+			name:       "vless xhttp packet-up",
+			link:       "vless://" + transportTestUUID + "@xh.example.com:443?type=xhttp&security=tls&sni=xh.example.com&host=xh.example.com&path=%2Fxhttp&mode=packet-up#XHTTP",
+			want:       transportFields{Network: "xhttp", TLS: true, SNI: "xh.example.com", Host: "xh.example.com", Path: "/xhttp", Mode: "packet-up"},
+			linkParams: []string{"type=xhttp", "mode=packet-up", "path=/xhttp"},
+			clash:      []string{"network: xhttp", "xhttp-opts:", "host: xh.example.com", "mode: packet-up"},
+			singBoxErr: "network xhttp tidak didukung",
+		},
+		{
+			name:       "vmess json tcp with http header",
+			link:       vmessJSONLink(`{"v":"2","ps":"TCP-HTTP","add":"tcp.example.com","port":"80","id":"` + transportTestUUID + `","aid":"0","net":"tcp","type":"http","host":"tcp.example.com,cdn.example.com","path":"/","tls":""}`),
+			want:       transportFields{Network: "tcp", Host: "tcp.example.com,cdn.example.com", Path: "/", HeaderType: "http"},
+			clash:      []string{"network: http", "http-opts:", "method: GET", "- tcp.example.com", "- cdn.example.com"},
+			singBoxErr: "tcp dengan header http tidak didukung",
+		},
+		{
+			name:       "vless tcp with http header",
+			link:       "vless://" + transportTestUUID + "@tcp2.example.com:80?type=tcp&headerType=http&host=tcp2.example.com&path=%2Fvideo#VLESS-TCP-HTTP",
+			want:       transportFields{Network: "tcp", SNI: "tcp2.example.com", Host: "tcp2.example.com", Path: "/video", HeaderType: "http"},
+			linkParams: []string{"headerType=http", "host=tcp2.example.com", "path=/video"},
+			clash:      []string{"network: http", "- /video", "- tcp2.example.com"},
+			singBoxErr: "tcp dengan header http tidak didukung",
+		},
+	}
+
+	service := NewXRayConverterService(nil, utils.NewLogger("TEST", false))
+	converter := &database.XRayConverter{DisplayName: "T", ModifyType: "ws", BugHost: "bug.example.com"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detected, err := service.DetectXRayConfig(tt.link)
+			if err != nil {
+				t.Fatalf("DetectXRayConfig() error = %v", err)
+			}
+			if got := transportFieldsOf(detected); got != tt.want {
+				t.Fatalf("parsed transport = %+v, want %+v", got, tt.want)
+			}
+
+			result, err := service.ModifyXRayConfig(detected, converter)
+			if err != nil {
+				t.Fatalf("ModifyXRayConfig() error = %v", err)
+			}
+
+			// Link hasil generate harus terbaca ulang dengan transport yang sama
+			for _, param := range tt.linkParams {
+				if !strings.Contains(result.ModifiedLink, param) {
+					t.Errorf("regenerated link %s missing %q", result.ModifiedLink, param)
+				}
+			}
+			reparsed, err := service.DetectXRayConfig(result.ModifiedLink)
+			if err != nil {
+				t.Fatalf("re-detect regenerated link: %v", err)
+			}
+			if reparsed.Server != "bug.example.com" || reparsed.Port != detected.Port {
+				t.Errorf("regenerated server = %s:%d, want bug.example.com:%d", reparsed.Server, reparsed.Port, detected.Port)
+			}
+			if got := transportFieldsOf(reparsed); got != tt.want {
+				t.Errorf("regenerated transport = %+v, want %+v", got, tt.want)
+			}
+
+			if tt.clashErr != "" {
+				if result.YAMLConfig != "" || result.YAMLError != tt.clashErr {
+					t.Errorf("Clash YAMLError = %q, want %q", result.YAMLError, tt.clashErr)
+				}
+			} else {
+				if err := ValidateClashYAML(result.YAMLConfig); err != nil {
+					t.Errorf("invalid Clash YAML: %v\n%s", err, result.YAMLConfig)
+				}
+				for _, part := range append([]string{"server: bug.example.com"}, tt.clash...) {
+					if !strings.Contains(result.YAMLConfig, part) {
+						t.Errorf("Clash YAML missing %q:\n%s", part, result.YAMLConfig)
+					}
+				}
+			}
+
+			singBox, err := formatSingBox(result)
+			if tt.singBoxErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.singBoxErr) {
+					t.Errorf("sing-box error = %v, want %q", err, tt.singBoxErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sing-box error = %v", err)
+			}
+			for _, part := range append([]string{`"server": "bug.example.com"`}, tt.singBox...) {
+				if !strings.Contains(singBox, part) {
+					t.Errorf("sing-box outbound missing %q:\n%s", part, singBox)
+				}
+			}
+		})
+	}
+}
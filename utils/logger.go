@@ -79,7 +79,8 @@ func (l *Logger) log(level, emoji, message string) {
 	if output != "" {
 		output += " "
 	}
-	output += fmt.Sprintf("%s %s: %s", emoji, level, message)
+	// Kredensial proxy (UUID/password) tidak boleh tertulis di log
+	output += fmt.Sprintf("%s %s: %s", emoji, level, RedactSecrets(message))
 	
 	// Print ke console
 	fmt.Println(output)
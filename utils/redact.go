@@ -0,0 +1,53 @@
+// Package utils - File redact.go
+// File ini berisi fungsi untuk menyamarkan kredensial (UUID/password) proxy sebelum ditulis ke log
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// redactedValue pengganti kredensial yang disamarkan
+const redactedValue = "***"
+
+var (
+	// proxyLinkPattern share link proxy yang berisi kredensial
+	proxyLinkPattern = regexp.MustCompile(`(?i)\b(vmess|vless|trojan|ss|ssr|hysteria2|hy2|tuic)://[^\s"'<>]+`)
+
+	// uuidPattern UUID lepas (misal dari JSON vmess atau pesan error)
+	uuidPattern = regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`)
+
+	// secretFieldPattern field kredensial di YAML/JSON/query string (password: x, "uuid":"x", pwd=x)
+	secretFieldPattern = regexp.MustCompile(`(?i)(["']?\b(?:password|passwd|pwd|psk|uuid|auth-str|auth)\b["']?\s*[:=]\s*["']?)([^"'\s,;&}]+)`)
+)
+
+// RedactSecrets menyamarkan semua kredensial proxy di dalam teks: share link, UUID, dan field password
+func RedactSecrets(text string) string {
+	text = proxyLinkPattern.ReplaceAllStringFunc(text, RedactProxyLink)
+	text = uuidPattern.ReplaceAllString(text, redactedValue)
+	return secretFieldPattern.ReplaceAllString(text, "${1}"+redactedValue)
+}
+
+// RedactProxyLink menyamarkan kredensial share link dan hanya menyisakan protocol, host, dan port.
+// vmess:// (base64 JSON) disamarkan seluruhnya karena UUID ada di dalam payload.
+func RedactProxyLink(link string) string {
+	schemeEnd := strings.Index(link, "://")
+	if schemeEnd < 0 {
+		return link
+	}
+	scheme := link[:schemeEnd]
+	rest := link[schemeEnd+3:]
+
+	if strings.EqualFold(scheme, "vmess") {
+		return scheme + "://" + redactedValue
+	}
+
+	// Buang query dan fragment (bisa berisi password plugin / remarks pribadi)
+	if i := strings.IndexAny(rest, "?#/"); i >= 0 {
+		rest = rest[:i]
+	}
+	if at := strings.LastIndex(rest, "@"); at >= 0 {
+		return scheme + "://" + redactedValue + "@" + rest[at+1:]
+	}
+	return scheme + "://" + redactedValue
+}
@@ -0,0 +1,616 @@
+// Package utils - File template.go
+// File ini berisi template engine yang dipakai bersama oleh template promosi dan layout pesan.
+//
+// Sintaks yang didukung:
+//   - Variabel: {{GROUP_NAME}} (huruf besar/kecil sama) dan gaya lama {DATE}
+//   - Kondisi: {{#if MEMBER_COUNT > 100}}...{{else}}...{{/if}}, {{#unless GROUP_NAME}}...{{/unless}}
+//   - Loop: {{#each PRODUCTS 5}}{{@index}}. {{NAME}} - {{PRICE}}{{/each}} (angka = batas item, opsional)
+//     dengan {{@index}} (mulai 1), {{@first}}, dan {{@last}} di dalam loop
+//   - Spintax acak: {Halo|Hai|Hey}, boleh bersarang dan berisi variabel
+//   - Komentar: {{! catatan admin }}
+//
+// Variabel yang tidak punya nilai dibiarkan apa adanya agar kesalahan terlihat saat preview.
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TemplateData nilai variabel dan daftar (untuk {{#each}}) saat render
+type TemplateData struct {
+	Vars  map[string]string
+	Lists map[string][]map[string]string
+}
+
+// Template hasil parse yang siap dirender berulang kali
+type Template struct {
+	nodes []templateNode
+}
+
+var (
+	// templateVarName nama variabel di {{...}}; @ untuk variabel khusus loop
+	templateVarName = regexp.MustCompile(`^@?[A-Za-z_][A-Za-z0-9_]*$`)
+	// legacyVarPattern variabel gaya lama {DATE} (harus huruf besar)
+	legacyVarPattern = regexp.MustCompile(`^\{([A-Z][A-Z0-9_]*)\}`)
+	// conditionPattern kondisi {{#if NAMA op nilai}}
+	conditionPattern = regexp.MustCompile(`^(!?)(@?[A-Za-z_][A-Za-z0-9_]*)(?:\s*(==|!=|>=|<=|>|<)\s*(.+))?$`)
+)
+
+// loopVariables variabel khusus yang hanya ada di dalam {{#each}}
+var loopVariables = map[string]bool{"@INDEX": true, "@FIRST": true, "@LAST": true}
+
+type templateNodeKind int
+
+const (
+	textNode templateNodeKind = iota
+	varNode
+	spinNode
+	ifNode
+	eachNode
+	groupNode // node hasil parse yang dipakai ulang apa adanya (body)
+)
+
+// templateNode satu elemen template; field yang terpakai tergantung kind
+type templateNode struct {
+	kind templateNodeKind
+	text string // teks literal, atau teks asli variabel jika tidak punya nilai
+	name string // nama variabel / daftar (huruf besar)
+
+	options [][]templateNode // pilihan spintax
+
+	condition templateCondition
+	body      []templateNode
+	elseBody  []templateNode
+	limit     int // batas item {{#each}} (0 = semua)
+}
+
+// templateCondition kondisi {{#if}} / {{#unless}}
+type templateCondition struct {
+	negate   bool
+	name     string
+	operator string
+	value    string
+}
+
+// ParseTemplate mem-parse dan memvalidasi sintaks template
+func ParseTemplate(src string) (*Template, error) {
+	parser := &templateParser{src: src}
+	nodes, stop, err := parser.parseNodes(false)
+	if err != nil {
+		return nil, err
+	}
+	if stop != "" {
+		return nil, fmt.Errorf("{{%s}} tanpa pembuka blok", stop)
+	}
+	return &Template{nodes: nodes}, nil
+}
+
+// RenderTemplate parse lalu render template. Jika sintaks salah, src dikembalikan apa adanya beserta error.
+func RenderTemplate(src string, data TemplateData) (string, error) {
+	tmpl, err := ParseTemplate(src)
+	if err != nil {
+		return src, err
+	}
+	return tmpl.Render(data), nil
+}
+
+// templateParser parser rekursif di atas teks template
+type templateParser struct {
+	src string
+	pos int
+
+	// spinScans hasil parse satu pilihan spintax per posisi awal. Parse mode spintax hanya
+	// bergantung pada posisi, jadi "{" yang gagal jadi spintax memakai ulang hasilnya alih-alih
+	// men-scan ulang (tanpa ini setiap "{" yang tidak ditutup melipatgandakan waktu parse).
+	spinScans  map[int]spinScan
+	spinChains map[int]spinChain
+}
+
+// spinScan hasil parseNodes mode spintax dari satu posisi awal
+type spinScan struct {
+	nodes []templateNode
+	stop  string
+	end   int
+}
+
+// spinChain ringkasan pilihan spintax berurutan: penghenti terakhir, posisi akhir,
+// dan jumlah pilihan (dibatasi 2, cukup untuk menentukan spintax valid atau tidak)
+type spinChain struct {
+	stop    string
+	end     int
+	options int
+}
+
+// parseNodes membaca node sampai akhir teks atau tag penutup ({{else}}, {{/x}}).
+// Dalam mode spintax, "|" dan "}" juga menghentikan parse. Tag penghenti dikembalikan ke pemanggil.
+func (p *templateParser) parseNodes(inSpin bool) ([]templateNode, string, error) {
+	var nodes []templateNode
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, templateNode{kind: textNode, text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.src) {
+		rest := p.src[p.pos:]
+
+		switch {
+		case strings.HasPrefix(rest, "{{"):
+			end := strings.Index(rest, "}}")
+			if end < 0 {
+				return nil, "", fmt.Errorf("tag {{ tidak ditutup dengan }} (posisi %d)", p.pos)
+			}
+			raw := rest[:end+2]
+			tag := strings.TrimSpace(rest[2:end])
+			p.pos += end + 2
+
+			switch {
+			case strings.HasPrefix(tag, "!"):
+				// Komentar, tidak dirender
+			case tag == "else" || strings.HasPrefix(tag, "/"):
+				flush()
+				return nodes, tag, nil
+			case strings.HasPrefix(tag, "#"):
+				flush()
+				node, err := p.parseBlock(tag[1:])
+				if err != nil {
+					return nil, "", err
+				}
+				nodes = append(nodes, node)
+			default:
+				if !templateVarName.MatchString(tag) {
+					return nil, "", fmt.Errorf("nama variabel tidak valid: %s", raw)
+				}
+				flush()
+				nodes = append(nodes, templateNode{kind: varNode, name: strings.ToUpper(tag), text: raw})
+			}
+
+		case rest[0] == '{':
+			if match := legacyVarPattern.FindStringSubmatch(rest); match != nil {
+				flush()
+				nodes = append(nodes, templateNode{kind: varNode, name: match[1], text: match[0]})
+				p.pos += len(match[0])
+				continue
+			}
+
+			start := p.pos
+			p.pos++
+			node, ok, err := p.parseSpin()
+			if err != nil {
+				return nil, "", err
+			}
+			if !ok {
+				// Bukan spintax, "{" diperlakukan sebagai teks biasa
+				p.pos = start + 1
+				text.WriteByte('{')
+				if inSpin {
+					// Sisa pilihan ini persis pilihan pertama "{" tadi yang sudah di-parse
+					rest, stop, err := p.parseSpinOption()
+					if err != nil {
+						return nil, "", err
+					}
+					flush()
+					return append(nodes, templateNode{kind: groupNode, body: rest}), stop, nil
+				}
+				continue
+			}
+			flush()
+			nodes = append(nodes, node)
+
+		case inSpin && (rest[0] == '|' || rest[0] == '}'):
+			flush()
+			p.pos++
+			return nodes, rest[:1], nil
+
+		default:
+			next := strings.IndexAny(rest[1:], "{|}")
+			if next < 0 {
+				next = len(rest) - 1
+			}
+			text.WriteString(rest[:next+1])
+			p.pos += next + 1
+		}
+	}
+
+	flush()
+	return nodes, "", nil
+}
+
+// parseSpin membaca pilihan spintax setelah "{". ok=false jika ternyata bukan spintax
+// (tidak ada "|" atau tidak ditutup), pemanggil lalu menganggap "{" sebagai teks.
+func (p *templateParser) parseSpin() (templateNode, bool, error) {
+	start := p.pos
+	chain, err := p.spinChainFrom(start)
+	if err != nil {
+		return templateNode{}, false, err
+	}
+	if chain.stop != "}" || chain.options < 2 {
+		return templateNode{}, false, nil
+	}
+
+	// Semua pilihan sudah ada di spinScans, tinggal dikumpulkan
+	var options [][]templateNode
+	for pos := start; ; {
+		scan := p.spinScans[pos]
+		options = append(options, scan.nodes)
+		pos = scan.end
+		if scan.stop == "}" {
+			break
+		}
+	}
+	p.pos = chain.end
+	return templateNode{kind: spinNode, options: options}, true, nil
+}
+
+// spinChainFrom ringkasan deretan pilihan spintax mulai dari pos sampai "}" atau akhir teks
+// (hasil diingat per posisi agar "|" yang tidak ditutup tidak di-scan berulang kali)
+func (p *templateParser) spinChainFrom(pos int) (spinChain, error) {
+	if chain, ok := p.spinChains[pos]; ok {
+		return chain, nil
+	}
+
+	p.pos = pos
+	_, stop, err := p.parseSpinOption()
+	if err != nil {
+		return spinChain{}, err
+	}
+	chain := spinChain{stop: stop, end: p.pos, options: 1}
+	if stop == "|" {
+		next, err := p.spinChainFrom(p.pos)
+		if err != nil {
+			return spinChain{}, err
+		}
+		chain = spinChain{stop: next.stop, end: next.end, options: min(next.options+1, 2)}
+	}
+
+	if p.spinChains == nil {
+		p.spinChains = make(map[int]spinChain)
+	}
+	p.spinChains[pos] = chain
+	return chain, nil
+}
+
+// parseSpinOption membaca satu pilihan spintax dari posisi saat ini (hasil diingat per posisi)
+func (p *templateParser) parseSpinOption() ([]templateNode, string, error) {
+	start := p.pos
+	if scan, ok := p.spinScans[start]; ok {
+		p.pos = scan.end
+		return scan.nodes, scan.stop, nil
+	}
+
+	nodes, stop, err := p.parseNodes(true)
+	if err != nil {
+		return nil, "", err
+	}
+	if p.spinScans == nil {
+		p.spinScans = make(map[int]spinScan)
+	}
+	p.spinScans[start] = spinScan{nodes: nodes, stop: stop, end: p.pos}
+	return nodes, stop, nil
+}
+
+// parseBlock membaca blok {{#if}}, {{#unless}}, atau {{#each}} sampai tag penutupnya
+func (p *templateParser) parseBlock(tag string) (templateNode, error) {
+	fields := strings.Fields(tag)
+	if len(fields) == 0 {
+		return templateNode{}, fmt.Errorf("tag blok {{#}} kosong")
+	}
+	blockName := strings.ToLower(fields[0])
+	args := strings.TrimSpace(strings.TrimPrefix(tag, fields[0]))
+
+	var node templateNode
+	switch blockName {
+	case "if", "unless":
+		condition, err := parseTemplateCondition(args)
+		if err != nil {
+			return templateNode{}, fmt.Errorf("{{#%s %s}}: %v", blockName, args, err)
+		}
+		if blockName == "unless" {
+			condition.negate = !condition.negate
+		}
+		node = templateNode{kind: ifNode, condition: condition}
+	case "each":
+		argFields := strings.Fields(args)
+		if len(argFields) == 0 || len(argFields) > 2 || !templateVarName.MatchString(argFields[0]) {
+			return templateNode{}, fmt.Errorf("format loop: {{#each NAMA_DAFTAR [batas]}}")
+		}
+		node = templateNode{kind: eachNode, name: strings.ToUpper(argFields[0])}
+		if len(argFields) == 2 {
+			limit, err := strconv.Atoi(argFields[1])
+			if err != nil || limit <= 0 {
+				return templateNode{}, fmt.Errorf("batas loop harus angka positif: %s", argFields[1])
+			}
+			node.limit = limit
+		}
+	default:
+		return templateNode{}, fmt.Errorf("blok tidak dikenal: {{#%s}} (pilihan: if, unless, each)", blockName)
+	}
+
+	body, stop, err := p.parseNodes(false)
+	if err != nil {
+		return templateNode{}, err
+	}
+	node.body = body
+	if stop == "else" {
+		if node.kind == eachNode {
+			return templateNode{}, fmt.Errorf("{{else}} tidak didukung di dalam {{#each}}")
+		}
+		if node.elseBody, stop, err = p.parseNodes(false); err != nil {
+			return templateNode{}, err
+		}
+	}
+
+	switch {
+	case stop == "":
+		return templateNode{}, fmt.Errorf("blok {{#%s}} tidak ditutup dengan {{/%s}}", blockName, blockName)
+	case strings.ToLower(stop) != "/"+blockName:
+		return templateNode{}, fmt.Errorf("blok {{#%s}} ditutup dengan {{%s}}", blockName, stop)
+	}
+	return node, nil
+}
+
+// parseTemplateCondition membaca "NAMA", "!NAMA", atau "NAMA op nilai"
+func parseTemplateCondition(args string) (templateCondition, error) {
+	match := conditionPattern.FindStringSubmatch(strings.TrimSpace(args))
+	if match == nil {
+		return templateCondition{}, fmt.Errorf("kondisi tidak valid (contoh: GROUP_NAME atau MEMBER_COUNT > 100)")
+	}
+
+	condition := templateCondition{
+		negate:   match[1] == "!",
+		name:     strings.ToUpper(match[2]),
+		operator: match[3],
+		value:    strings.TrimSpace(match[4]),
+	}
+	if unquoted, err := strconv.Unquote(condition.value); err == nil {
+		condition.value = unquoted
+	}
+	return condition, nil
+}
+
+// templateScope lookup variabel: item loop dulu, lalu variabel global
+type templateScope struct {
+	vars   map[string]string
+	lists  map[string][]map[string]string
+	parent *templateScope
+}
+
+func (s *templateScope) lookup(name string) (string, bool) {
+	for scope := s; scope != nil; scope = scope.parent {
+		if value, ok := scope.vars[name]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// upperKeys menyalin map dengan key huruf besar agar lookup tidak peka huruf
+func upperKeys(values map[string]string) map[string]string {
+	result := make(map[string]string, len(values))
+	for key, value := range values {
+		result[strings.ToUpper(key)] = value
+	}
+	return result
+}
+
+// Render merender template; spintax dipilih acak setiap render
+func (t *Template) Render(data TemplateData) string {
+	lists := make(map[string][]map[string]string, len(data.Lists))
+	for name, items := range data.Lists {
+		lists[strings.ToUpper(name)] = items
+	}
+
+	var builder strings.Builder
+	renderTemplateNodes(&builder, t.nodes, &templateScope{vars: upperKeys(data.Vars), lists: lists})
+	return builder.String()
+}
+
+func renderTemplateNodes(builder *strings.Builder, nodes []templateNode, scope *templateScope) {
+	for _, node := range nodes {
+		switch node.kind {
+		case textNode:
+			builder.WriteString(node.text)
+		case varNode:
+			if value, ok := scope.lookup(node.name); ok {
+				builder.WriteString(value)
+			} else {
+				builder.WriteString(node.text)
+			}
+		case spinNode:
+			renderTemplateNodes(builder, node.options[rand.Intn(len(node.options))], scope)
+		case groupNode:
+			renderTemplateNodes(builder, node.body, scope)
+		case ifNode:
+			if node.condition.evaluate(scope) {
+				renderTemplateNodes(builder, node.body, scope)
+			} else {
+				renderTemplateNodes(builder, node.elseBody, scope)
+			}
+		case eachNode:
+			items := scope.lists[node.name]
+			if node.limit > 0 && len(items) > node.limit {
+				items = items[:node.limit]
+			}
+			for i, item := range items {
+				vars := upperKeys(item)
+				vars["@INDEX"] = strconv.Itoa(i + 1)
+				vars["@FIRST"] = templateBool(i == 0)
+				vars["@LAST"] = templateBool(i == len(items)-1)
+				renderTemplateNodes(builder, node.body, &templateScope{vars: vars, lists: scope.lists, parent: scope})
+			}
+		}
+	}
+}
+
+// templateBool nilai boolean variabel loop ("true" atau kosong)
+func templateBool(value bool) string {
+	if value {
+		return "true"
+	}
+	return ""
+}
+
+// evaluate menilai kondisi; tanpa operator = nilai tidak kosong, bukan "0"/"false"
+func (c templateCondition) evaluate(scope *templateScope) bool {
+	value, _ := scope.lookup(c.name)
+
+	var result bool
+	if c.operator == "" {
+		lower := strings.ToLower(strings.TrimSpace(value))
+		result = lower != "" && lower != "0" && lower != "false"
+	} else {
+		result = compareTemplateValues(value, c.operator, c.value)
+	}
+
+	if c.negate {
+		return !result
+	}
+	return result
+}
+
+// compareTemplateValues bandingkan sebagai angka jika keduanya angka, selain itu sebagai teks
+func compareTemplateValues(left, operator, right string) bool {
+	comparison := strings.Compare(strings.ToLower(left), strings.ToLower(right))
+	leftNumber, errLeft := strconv.ParseFloat(strings.TrimSpace(left), 64)
+	rightNumber, errRight := strconv.ParseFloat(strings.TrimSpace(right), 64)
+	if errLeft == nil && errRight == nil {
+		switch {
+		case leftNumber < rightNumber:
+			comparison = -1
+		case leftNumber > rightNumber:
+			comparison = 1
+		default:
+			comparison = 0
+		}
+	}
+
+	switch operator {
+	case "==":
+		return comparison == 0
+	case "!=":
+		return comparison != 0
+	case ">":
+		return comparison > 0
+	case "<":
+		return comparison < 0
+	case ">=":
+		return comparison >= 0
+	case "<=":
+		return comparison <= 0
+	}
+	return false
+}
+
+// Uses true jika template memakai salah satu variabel/daftar (termasuk di dalam kondisi dan loop)
+func (t *Template) Uses(names ...string) bool {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[strings.ToUpper(name)] = true
+	}
+
+	found := false
+	walkTemplateNodes(t.nodes, func(name string) {
+		if wanted[name] {
+			found = true
+		}
+	})
+	return found
+}
+
+// UnknownVariables variabel {{...}} dan daftar {{#each}} yang tidak dikenal. Di dalam loop,
+// field item (listFields[NAMA_DAFTAR]) dan variabel @index/@first/@last juga dikenal.
+// Variabel gaya lama {X} tidak dicek karena bisa saja teks biasa.
+func (t *Template) UnknownVariables(known []string, listFields map[string][]string) []string {
+	knownVars := make(map[string]bool, len(known))
+	for _, name := range known {
+		knownVars[strings.ToUpper(name)] = true
+	}
+	fields := make(map[string]map[string]bool, len(listFields))
+	for list, names := range listFields {
+		set := make(map[string]bool, len(names))
+		for _, name := range names {
+			set[strings.ToUpper(name)] = true
+		}
+		fields[strings.ToUpper(list)] = set
+	}
+
+	unknown := make(map[string]bool)
+	var check func(nodes []templateNode, loopFields map[string]bool)
+	check = func(nodes []templateNode, loopFields map[string]bool) {
+		isKnown := func(name string) bool {
+			return knownVars[name] || (loopFields != nil && (loopFields[name] || loopVariables[name]))
+		}
+		for _, node := range nodes {
+			switch node.kind {
+			case varNode:
+				if strings.HasPrefix(node.text, "{{") && !isKnown(node.name) {
+					unknown[node.name] = true
+				}
+			case spinNode:
+				for _, option := range node.options {
+					check(option, loopFields)
+				}
+			case groupNode:
+				check(node.body, loopFields)
+			case ifNode:
+				if !isKnown(node.condition.name) {
+					unknown[node.condition.name] = true
+				}
+				check(node.body, loopFields)
+				check(node.elseBody, loopFields)
+			case eachNode:
+				itemFields, ok := fields[node.name]
+				if !ok {
+					unknown[node.name] = true
+				}
+				scoped := make(map[string]bool, len(itemFields)+len(loopFields))
+				for name := range loopFields {
+					scoped[name] = true
+				}
+				for name := range itemFields {
+					scoped[name] = true
+				}
+				check(node.body, scoped)
+			}
+		}
+	}
+	check(t.nodes, nil)
+
+	names := make([]string, 0, len(unknown))
+	for name := range unknown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// walkTemplateNodes memanggil visit untuk setiap nama variabel, kondisi, dan daftar
+func walkTemplateNodes(nodes []templateNode, visit func(name string)) {
+	for _, node := range nodes {
+		switch node.kind {
+		case varNode:
+			visit(node.name)
+		case spinNode:
+			for _, option := range node.options {
+				walkTemplateNodes(option, visit)
+			}
+		case groupNode:
+			walkTemplateNodes(node.body, visit)
+		case ifNode:
+			visit(node.condition.name)
+			walkTemplateNodes(node.body, visit)
+			walkTemplateNodes(node.elseBody, visit)
+		case eachNode:
+			visit(node.name)
+			walkTemplateNodes(node.body, visit)
+		}
+	}
+}
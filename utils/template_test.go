@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// templateTestData data contoh: variabel grup dan daftar produk
+func templateTestData() TemplateData {
+	return TemplateData{
+		Vars: map[string]string{"GROUP_NAME": "Grup Jualan", "MEMBER_COUNT": "150", "DATE": "16/10/2026", "EMPTY": ""},
+		Lists: map[string][]map[string]string{
+			"products": {
+				{"name": "Kopi", "price": "15000"},
+				{"name": "Teh", "price": "8000"},
+				{"name": "Susu", "price": "12000"},
+			},
+		},
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"plain text", "Halo semua", "Halo semua"},
+		{"variable", "Halo {{GROUP_NAME}}!", "Halo Grup Jualan!"},
+		{"variable is case-insensitive", "{{ group_name }} / {{Member_Count}}", "Grup Jualan / 150"},
+		{"legacy variable", "Tanggal {DATE}", "Tanggal 16/10/2026"},
+		{"unknown variable kept", "{{UNKNOWN}} dan {LEGACY_UNKNOWN}", "{{UNKNOWN}} dan {LEGACY_UNKNOWN}"},
+		{"comment", "A{{! catatan admin }}B", "AB"},
+		{"if true", "{{#if MEMBER_COUNT > 100}}ramai{{else}}sepi{{/if}}", "ramai"},
+		{"if false", "{{#if MEMBER_COUNT >= 200}}ramai{{else}}sepi{{/if}}", "sepi"},
+		{"if numeric compare", "{{#if MEMBER_COUNT < 20}}kecil{{/if}}", ""},
+		{"if text equality", `{{#if GROUP_NAME == "grup jualan"}}cocok{{/if}}`, "cocok"},
+		{"if empty value", "{{#if EMPTY}}ada{{else}}kosong{{/if}}", "kosong"},
+		{"if negated", "{{#if !MISSING}}tidak ada{{/if}}", "tidak ada"},
+		{"unless", "{{#unless EMPTY}}kosong{{/unless}}{{#unless GROUP_NAME}}x{{/unless}}", "kosong"},
+		{"unless with else", "{{#unless MEMBER_COUNT}}a{{else}}b{{/unless}}", "b"},
+		{"each", "{{#each PRODUCTS}}{{@index}}. {{NAME}} - {{PRICE}}\n{{/each}}", "1. Kopi - 15000\n2. Teh - 8000\n3. Susu - 12000\n"},
+		{"each with limit", "{{#each products 2}}{{@index}}. {{name}};{{/each}}", "1. Kopi;2. Teh;"},
+		{"each first and last", "{{#each PRODUCTS}}{{#if @first}}[{{/if}}{{NAME}}{{#unless @last}}, {{/unless}}{{#if @last}}]{{/if}}{{/each}}", "[Kopi, Teh, Susu]"},
+		{"each sees global variables", "{{#each PRODUCTS 1}}{{NAME}} di {{GROUP_NAME}}{{/each}}", "Kopi di Grup Jualan"},
+		{"each unknown list", "{{#each MISSING}}x{{/each}}", ""},
+		{"single option braces are text", "{bukan spintax} {x", "{bukan spintax} {x"},
+		{"empty braces", "{} {{!}}", "{} "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderTemplate(tt.src, templateTestData())
+			if err != nil {
+				t.Fatalf("RenderTemplate(%q) error = %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateSpintax(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"{Halo|Hai|Hey}", []string{"Halo", "Hai", "Hey"}},
+		{"{a|{b|c}}", []string{"a", "b", "c"}},
+		{"{Hai {{GROUP_NAME}}|x}!", []string{"Hai Grup Jualan!", "x!"}},
+		{"{a|}", []string{"a", ""}},
+		{"{a|{b}", []string{"a", "{b"}},
+		{"{ {x|y}", []string{"{ x", "{ y"}},
+		{"{#{{#if EMPTY}}{a|b}{{else}}{c|d}{{/if}}|e}", []string{"#c", "#d", "e"}},
+	}
+
+	for _, tt := range tests {
+		tmpl, err := ParseTemplate(tt.src)
+		if err != nil {
+			t.Fatalf("ParseTemplate(%q) error = %v", tt.src, err)
+		}
+
+		seen := make(map[string]bool)
+		for i := 0; i < 200; i++ {
+			seen[tmpl.Render(templateTestData())] = true
+		}
+		allowed := make(map[string]bool, len(tt.want))
+		for _, want := range tt.want {
+			allowed[want] = true
+			if !seen[want] {
+				t.Errorf("Render(%q) never produced %q (seen %v)", tt.src, want, seen)
+			}
+		}
+		for got := range seen {
+			if !allowed[got] {
+				t.Errorf("Render(%q) = %q, want one of %q", tt.src, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"unclosed tag", "Halo {{GROUP_NAME", "tidak ditutup dengan }}"},
+		{"unclosed tag inside spintax", "{a|{{b}", "tidak ditutup dengan }}"},
+		{"invalid variable name", "{{GROUP NAME}}", "nama variabel tidak valid"},
+		{"empty block", "{{#}}", "kosong"},
+		{"unknown block", "{{#for PRODUCTS}}x{{/for}}", "blok tidak dikenal"},
+		{"unclosed block", "{{#if GROUP_NAME}}x", "tidak ditutup dengan {{/if}}"},
+		{"mismatched close", "{{#if GROUP_NAME}}x{{/each}}", "ditutup dengan {{/each}}"},
+		{"stray close", "x{{/if}}", "tanpa pembuka blok"},
+		{"stray else", "x{{else}}y", "tanpa pembuka blok"},
+		{"else inside each", "{{#each PRODUCTS}}a{{else}}b{{/each}}", "tidak didukung"},
+		{"invalid condition", "{{#if > 5}}x{{/if}}", "kondisi tidak valid"},
+		{"each without list", "{{#each}}x{{/each}}", "format loop"},
+		{"each with zero limit", "{{#each PRODUCTS 0}}x{{/each}}", "batas loop"},
+		{"error inside nested block", "{{#if A}}{{#each B}}{{bad-name}}{{/each}}{{/if}}", "nama variabel tidak valid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseTemplate(tt.src); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ParseTemplate(%q) error = %v, want %q", tt.src, err, tt.wantErr)
+			}
+
+			// RenderTemplate mengembalikan teks asli jika sintaks salah
+			if got, err := RenderTemplate(tt.src, templateTestData()); err == nil || got != tt.src {
+				t.Errorf("RenderTemplate(%q) = %q, %v; want source and error", tt.src, got, err)
+			}
+		})
+	}
+}
+
+func TestParseTemplateUnclosedBraces(t *testing.T) {
+	const count = 20000
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"unclosed braces", strings.Repeat("{ ", count), strings.Repeat("{ ", count)},
+		{"unclosed braces around spintax", strings.Repeat("{ {x|x} ", count), strings.Repeat("{ x ", count)},
+		{"unclosed braces with variables", strings.Repeat("{ {{GROUP_NAME}} {DATE} ", count), strings.Repeat("{ Grup Jualan 16/10/2026 ", count)},
+		{"unclosed braces then spintax", strings.Repeat("{x", count) + "{a|a}", strings.Repeat("{x", count) + "a"},
+		{"unclosed option separators", strings.Repeat("{a|", count), strings.Repeat("{a|", count)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Sebelumnya setiap "{" yang tidak ditutup melipatgandakan waktu parse
+			start := time.Now()
+			tmpl, err := ParseTemplate(tt.src)
+			if err != nil {
+				t.Fatalf("ParseTemplate() error = %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("ParseTemplate() took %v for %d bytes", elapsed, len(tt.src))
+			}
+			if got := tmpl.Render(templateTestData()); got != tt.want {
+				t.Errorf("Render() mismatch: got %d bytes, want %d bytes", len(got), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestTemplateUses(t *testing.T) {
+	tmpl, err := ParseTemplate("{Halo|Hai} {{#if member_count > 10}}{{#each PRODUCTS}}{{NAME}}{{/each}}{{/if}} {DATE}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"MEMBER_COUNT", "products", "NAME", "DATE"} {
+		if !tmpl.Uses(name) {
+			t.Errorf("Uses(%q) = false, want true", name)
+		}
+	}
+	if tmpl.Uses("GROUP_NAME", "PRICE") {
+		t.Error("Uses(GROUP_NAME, PRICE) = true, want false")
+	}
+}
+
+func TestTemplateUnknownVariables(t *testing.T) {
+	src := "{{GROUP_NAME}} {{TYPO}} {LEGACY} {{#if COUNT}}{{#each PRODUCTS}}{{@index}} {{NAME}} {{COLOR}}{{/each}}{{/if}} {{NAME}} {{#each ITEMS}}x{{/each}} {a|{{OTHER}}}"
+	tmpl, err := ParseTemplate(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := tmpl.UnknownVariables([]string{"group_name"}, map[string][]string{"products": {"name", "price"}})
+	want := []string{"COLOR", "COUNT", "ITEMS", "NAME", "OTHER", "TYPO"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("UnknownVariables() = %v, want %v", got, want)
+	}
+}
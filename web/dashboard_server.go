@@ -3,21 +3,29 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/nabilulilalbab/promote/database"
+	"github.com/nabilulilalbab/promote/services"
 	"github.com/nabilulilalbab/promote/utils"
 )
 
 // DashboardServer manages the web dashboard
 type DashboardServer struct {
-	repository     database.Repository
-	logger         *utils.Logger
-	adminNumbers   []string
-	mediaPath      string
-	whatsappClient interface{} // WhatsApp client untuk akses grup
+	repository             database.Repository
+	logger                 *utils.Logger
+	adminNumbers           []string
+	mediaPath              string
+	whatsappClient         interface{} // WhatsApp client untuk akses grup
+	xrayService            *services.XRayConverterService
+	promoteService         *services.AutoPromoteService
+	promoteTemplateService *services.TemplateService
 }
 
 // NewDashboardServer creates a new dashboard server
@@ -35,6 +43,11 @@ func (s *DashboardServer) SetWhatsAppClient(client interface{}) {
 	s.whatsappClient = client
 }
 
+// SetXRayConverterService sets the XRay converter service for subscription & test endpoints
+func (s *DashboardServer) SetXRayConverterService(svc *services.XRayConverterService) {
+	s.xrayService = svc
+}
+
 // StartServer starts the web dashboard server
 func (s *DashboardServer) StartServer(port int) error {
 	// Setup routes
@@ -48,6 +61,14 @@ func (s *DashboardServer) StartServer(port int) error {
 	http.HandleFunc("/api/stats", s.handleStats)
 	http.HandleFunc("/api/xray_converters", s.handleXRayConverters)
 	http.HandleFunc("/api/xray_converters/test", s.handleXRayConverterTest)
+	http.HandleFunc("/api/xray_converters/history", s.handleXRayConverterHistory)
+	http.HandleFunc("/api/xray_converters/rollback", s.handleXRayConverterRollback)
+	http.HandleFunc("/api/xray_converters/export", s.handleXRayConverterExport)
+	http.HandleFunc("/api/xray_converters/import", s.handleXRayConverterImport)
+	http.HandleFunc("/api/xray_rule_templates", s.handleXRayRuleTemplates)
+	http.HandleFunc("/api/promote/groups", s.handlePromoteGroups)
+	http.HandleFunc("/api/promote/templates/media", s.handlePromoteTemplateMedia)
+	http.HandleFunc("/sub/", s.handleSubscription)
 	
 	// Static files
 	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("web/static/"))))
@@ -128,6 +149,9 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
                     <a class="nav-link" href="#" onclick="showTab('xray')">
                         <i class="fas fa-exchange-alt"></i> XRay Converter
                     </a>
+                    <a class="nav-link" href="#" onclick="showTab('promote')">
+                        <i class="fas fa-bullhorn"></i> Auto Promote
+                    </a>
                 </nav>
             </div>
             
@@ -198,11 +222,55 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
                             <button class="btn btn-primary" onclick="refreshXRayConverters()">
                                 <i class="fas fa-sync"></i> Refresh
                             </button>
+                            <button class="btn btn-outline-secondary" onclick="promptXRayConverterHistory()">
+                                <i class="fas fa-history"></i> Riwayat
+                            </button>
+                            <button class="btn btn-outline-primary" onclick="exportXRayConverters()">
+                                <i class="fas fa-file-export"></i> Export
+                            </button>
+                            <button class="btn btn-outline-primary" onclick="showXRayImportModal()">
+                                <i class="fas fa-file-import"></i> Import
+                            </button>
+                        </div>
+                        <div class="input-group" style="max-width: 260px;">
+                            <span class="input-group-text"><i class="fas fa-user-edit"></i></span>
+                            <input type="text" class="form-control" id="xrayChangedBy" maxlength="64"
+                                   placeholder="Nama pengubah" onchange="saveXRayChangedBy()">
                         </div>
                     </div>
                     <div id="xray-converters-list" class="row">
                         <!-- XRay converters will be loaded here -->
                     </div>
+
+                    <h4 class="mt-4"><i class="fas fa-file-code"></i> Rule Templates (Profile Mihomo)</h4>
+                    <div class="d-flex justify-content-between align-items-center mb-3">
+                        <div>
+                            <button class="btn btn-success" onclick="showRuleTemplateModal()">
+                                <i class="fas fa-plus"></i> Tambah Template
+                            </button>
+                        </div>
+                    </div>
+                    <div id="xray-rule-templates-list">
+                        <!-- Rule templates will be loaded here -->
+                    </div>
+                </div>
+
+                <!-- Auto Promote Tab -->
+                <div id="promote-tab" class="tab-content" style="display:none;">
+                    <h2><i class="fas fa-bullhorn"></i> Target Template Auto Promote</h2>
+                    <div class="row mb-3">
+                        <div class="col-md-12">
+                            <button class="btn btn-primary" onclick="refreshPromoteGroups()">
+                                <i class="fas fa-sync"></i> Refresh
+                            </button>
+                            <small class="text-muted ms-2">Tanpa kategori/template yang dicentang = semua template dikirim ke grup.</small>
+                        </div>
+                    </div>
+                    <div id="promote-groups-list" class="row"></div>
+
+                    <h4 class="mt-4"><i class="fas fa-photo-video"></i> Media Template</h4>
+                    <p class="text-muted">Gambar (JPG/PNG) dan video (MP4) dikirim sebagai media, file lain sebagai dokumen. Konten template menjadi caption.</p>
+                    <div id="promote-templates-list"></div>
                 </div>
 
                 <!-- Stats Tab -->
@@ -534,6 +602,25 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
                                 </div>
                             </div>
                         </div>
+                        <div class="row">
+                            <div class="col-md-4">
+                                <div class="mb-3">
+                                    <label class="form-label">Kategori / Operator</label>
+                                    <input type="text" class="form-control" id="editConverterCategory" list="converterCategoryOptions">
+                                </div>
+                            </div>
+                            <div class="col-md-8">
+                                <div class="mb-3">
+                                    <label class="form-label">Deskripsi</label>
+                                    <input type="text" class="form-control" id="editConverterDescription">
+                                </div>
+                            </div>
+                        </div>
+                        <div class="mb-3">
+                            <label class="form-label">Contoh Pemakaian</label>
+                            <input type="text" class="form-control" id="editConverterExample">
+                            <small class="text-muted">Ditampilkan di menu .converters, converter tanpa kategori masuk "Lainnya"</small>
+                        </div>
                         <div class="row">
                             <div class="col-md-6">
                                 <div class="mb-3">
@@ -561,10 +648,15 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
                             <div class="alert alert-info">
                                 <strong>Available Placeholders:</strong><br>
                                 <code>{bug_host}</code> - Bug host domain<br>
-                                <code>{bug_ip}</code> - Bug host IP<br>
+                                <code>{bug_ip}</code> - Bug host IP (resolved via DNS)<br>
                                 <code>{original_server}</code> - Original server<br>
                                 <code>{original_host}</code> - Original host<br>
                                 <code>{original_sni}</code> - Original SNI<br>
+                                <code>{original_port}</code> - Original port<br>
+                                <code>{original_path}</code> - Original path<br>
+                                <code>{uuid}</code> - UUID / password<br>
+                                <code>{remarks}</code> - Original remarks<br>
+                                <code>{random_subdomain}</code> - Random 8-char label<br>
                                 <small class="text-muted">Leave empty to use original value</small>
                             </div>
                             <div class="row">
@@ -623,6 +715,72 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
                                 </div>
                             </div>
                         </div>
+                        <div class="mb-3">
+                            <label class="form-label">Output Formats</label>
+                            <input type="text" class="form-control" id="editConverterOutputFormats">
+                            <small class="text-muted">Format tambahan dipisah koma: clash, mihomo, singbox, xray, v2rayn, surge, quanx. Kosongkan untuk link + YAML saja</small>
+                        </div>
+                        <div class="mb-3">
+                            <label class="form-label">Profile Template</label>
+                            <select class="form-control profile-template-select" id="editConverterProfileTemplate"></select>
+                            <small class="text-muted">Pilih template untuk menghasilkan profile Mihomo/OpenClash lengkap (DNS, proxy-groups, rules)</small>
+                        </div>
+                        <div class="mb-3">
+                            <label class="form-label">Rules (JSON)</label>
+                            <textarea class="form-control font-monospace" id="editConverterRules" rows="4" placeholder="[]"></textarea>
+                            <small class="text-muted">Array JSON, dijalankan berurutan setelah template. Field: server, host, sni, path, port, service_name, remarks. Action: set, replace, regex. Contoh: [{"field":"path","action":"regex","match":"^","value":"/{bug_host}","when":{"networks":["ws"],"tls":true}}]</small>
+                        </div>
+                        <div class="mb-3">
+                            <label class="form-label">Probe Koneksi</label>
+                            <select class="form-control" id="editConverterProbeEnabled">
+                                <option value="false">❌ Nonaktif</option>
+                                <option value="true">✅ Selalu probe</option>
+                            </select>
+                            <small class="text-muted">Cek TCP/TLS (SNI hasil modifikasi) dan upgrade WebSocket setiap konversi. User tetap bisa memakai --probe</small>
+                        </div>
+                        <div class="row">
+                            <div class="col-md-4">
+                                <div class="mb-3">
+                                    <label class="form-label">Kuota User/Hari</label>
+                                    <input type="number" min="0" class="form-control" id="editConverterUserQuota" placeholder="0">
+                                </div>
+                            </div>
+                            <div class="col-md-4">
+                                <div class="mb-3">
+                                    <label class="form-label">Kuota Grup/Hari</label>
+                                    <input type="number" min="0" class="form-control" id="editConverterGroupQuota" placeholder="0">
+                                </div>
+                            </div>
+                            <div class="col-md-4">
+                                <div class="mb-3">
+                                    <label class="form-label">Kuota Global/Hari</label>
+                                    <input type="number" min="0" class="form-control" id="editConverterGlobalQuota" placeholder="0">
+                                </div>
+                            </div>
+                            <small class="text-muted mb-3">0/kosong = tanpa batas. Dihitung dari conversion sukses 24 jam terakhir. Kuota khusus user diatur via .setquota</small>
+                        </div>
+                        <div class="row">
+                            <div class="col-md-6">
+                                <div class="mb-3">
+                                    <label class="form-label">Pengiriman Hasil</label>
+                                    <select class="form-control" id="editConverterDeliveryMode">
+                                        <option value="">Ikut setting grup</option>
+                                        <option value="group">💬 Balas di grup</option>
+                                        <option value="dm">📩 DM user saja</option>
+                                        <option value="dm_ack">📩 DM + konfirmasi di grup</option>
+                                    </select>
+                                </div>
+                            </div>
+                            <div class="col-md-6">
+                                <div class="mb-3">
+                                    <label class="form-label">Format YAML</label>
+                                    <select class="form-control" id="editConverterYAMLAsDocument">
+                                        <option value="false">Teks di chat</option>
+                                        <option value="true">📎 File .yaml</option>
+                                    </select>
+                                </div>
+                            </div>
+                        </div>
                     </form>
                 </div>
                 <div class="modal-footer">
@@ -633,6 +791,16 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
         </div>
     </div>
 
+    <datalist id="converterCategoryOptions">
+        <option value="XL"></option>
+        <option value="Axis"></option>
+        <option value="Telkomsel"></option>
+        <option value="Indosat"></option>
+        <option value="Tri"></option>
+        <option value="Smartfren"></option>
+        <option value="Umum"></option>
+    </datalist>
+
     <!-- Add XRay Converter Modal -->
     <div class="modal fade" id="addXRayConverterModal" tabindex="-1">
         <div class="modal-dialog modal-lg">
@@ -658,6 +826,25 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
                                 </div>
                             </div>
                         </div>
+                        <div class="row">
+                            <div class="col-md-4">
+                                <div class="mb-3">
+                                    <label class="form-label">Kategori / Operator</label>
+                                    <input type="text" class="form-control" id="newConverterCategory" list="converterCategoryOptions" placeholder="XL">
+                                </div>
+                            </div>
+                            <div class="col-md-8">
+                                <div class="mb-3">
+                                    <label class="form-label">Deskripsi</label>
+                                    <input type="text" class="form-control" id="newConverterDescription" placeholder="Wildcard bug LINE (ava.game.naver.com)">
+                                </div>
+                            </div>
+                        </div>
+                        <div class="mb-3">
+                            <label class="form-label">Contoh Pemakaian</label>
+                            <input type="text" class="form-control" id="newConverterExample" placeholder=".convertbizz vmess://xxx">
+                            <small class="text-muted">Ditampilkan di menu .converters, converter tanpa kategori masuk "Lainnya"</small>
+                        </div>
                         <div class="row">
                             <div class="col-md-6">
                                 <div class="mb-3">
@@ -685,10 +872,15 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
                             <div class="alert alert-info">
                                 <strong>Available Placeholders:</strong><br>
                                 <code>{bug_host}</code> - Bug host domain<br>
-                                <code>{bug_ip}</code> - Bug host IP<br>
+                                <code>{bug_ip}</code> - Bug host IP (resolved via DNS)<br>
                                 <code>{original_server}</code> - Original server<br>
                                 <code>{original_host}</code> - Original host<br>
                                 <code>{original_sni}</code> - Original SNI<br>
+                                <code>{original_port}</code> - Original port<br>
+                                <code>{original_path}</code> - Original path<br>
+                                <code>{uuid}</code> - UUID / password<br>
+                                <code>{remarks}</code> - Original remarks<br>
+                                <code>{random_subdomain}</code> - Random 8-char label<br>
                                 <small class="text-muted">Leave empty to use original value</small>
                             </div>
                             <div class="row">
@@ -737,6 +929,72 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
                             <input type="number" class="form-control" id="newConverterPortOverride" placeholder="443">
                             <small class="text-muted">Kosongkan untuk gunakan port original</small>
                         </div>
+                        <div class="mb-3">
+                            <label class="form-label">Output Formats</label>
+                            <input type="text" class="form-control" id="newConverterOutputFormats" placeholder="singbox,surge">
+                            <small class="text-muted">Format tambahan dipisah koma: clash, mihomo, singbox, xray, v2rayn, surge, quanx. Kosongkan untuk link + YAML saja</small>
+                        </div>
+                        <div class="mb-3">
+                            <label class="form-label">Profile Template</label>
+                            <select class="form-control profile-template-select" id="newConverterProfileTemplate"></select>
+                            <small class="text-muted">Pilih template untuk menghasilkan profile Mihomo/OpenClash lengkap (DNS, proxy-groups, rules)</small>
+                        </div>
+                        <div class="mb-3">
+                            <label class="form-label">Rules (JSON)</label>
+                            <textarea class="form-control font-monospace" id="newConverterRules" rows="4" placeholder="[]"></textarea>
+                            <small class="text-muted">Array JSON, dijalankan berurutan setelah template. Field: server, host, sni, path, port, service_name, remarks. Action: set, replace, regex. Contoh: [{"field":"path","action":"regex","match":"^","value":"/{bug_host}","when":{"networks":["ws"],"tls":true}}]</small>
+                        </div>
+                        <div class="mb-3">
+                            <label class="form-label">Probe Koneksi</label>
+                            <select class="form-control" id="newConverterProbeEnabled">
+                                <option value="false">❌ Nonaktif</option>
+                                <option value="true">✅ Selalu probe</option>
+                            </select>
+                            <small class="text-muted">Cek TCP/TLS (SNI hasil modifikasi) dan upgrade WebSocket setiap konversi. User tetap bisa memakai --probe</small>
+                        </div>
+                        <div class="row">
+                            <div class="col-md-4">
+                                <div class="mb-3">
+                                    <label class="form-label">Kuota User/Hari</label>
+                                    <input type="number" min="0" class="form-control" id="newConverterUserQuota" placeholder="0">
+                                </div>
+                            </div>
+                            <div class="col-md-4">
+                                <div class="mb-3">
+                                    <label class="form-label">Kuota Grup/Hari</label>
+                                    <input type="number" min="0" class="form-control" id="newConverterGroupQuota" placeholder="0">
+                                </div>
+                            </div>
+                            <div class="col-md-4">
+                                <div class="mb-3">
+                                    <label class="form-label">Kuota Global/Hari</label>
+                                    <input type="number" min="0" class="form-control" id="newConverterGlobalQuota" placeholder="0">
+                                </div>
+                            </div>
+                            <small class="text-muted mb-3">0/kosong = tanpa batas. Dihitung dari conversion sukses 24 jam terakhir. Kuota khusus user diatur via .setquota</small>
+                        </div>
+                        <div class="row">
+                            <div class="col-md-6">
+                                <div class="mb-3">
+                                    <label class="form-label">Pengiriman Hasil</label>
+                                    <select class="form-control" id="newConverterDeliveryMode">
+                                        <option value="">Ikut setting grup</option>
+                                        <option value="group">💬 Balas di grup</option>
+                                        <option value="dm">📩 DM user saja</option>
+                                        <option value="dm_ack">📩 DM + konfirmasi di grup</option>
+                                    </select>
+                                </div>
+                            </div>
+                            <div class="col-md-6">
+                                <div class="mb-3">
+                                    <label class="form-label">Format YAML</label>
+                                    <select class="form-control" id="newConverterYAMLAsDocument">
+                                        <option value="false">Teks di chat</option>
+                                        <option value="true">📎 File .yaml</option>
+                                    </select>
+                                </div>
+                            </div>
+                        </div>
                     </form>
                 </div>
                 <div class="modal-footer">
@@ -747,12 +1005,150 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
         </div>
     </div>
     
+    <!-- XRay Converter History Modal -->
+    <div class="modal fade" id="xrayConverterHistoryModal" tabindex="-1">
+        <div class="modal-dialog modal-xl">
+            <div class="modal-content">
+                <div class="modal-header">
+                    <h5 class="modal-title" id="xrayConverterHistoryTitle">Riwayat Converter</h5>
+                    <button type="button" class="btn-close" data-bs-dismiss="modal"></button>
+                </div>
+                <div class="modal-body">
+                    <div id="xrayConverterHistoryList"></div>
+                </div>
+                <div class="modal-footer">
+                    <button type="button" class="btn btn-secondary" data-bs-dismiss="modal">Tutup</button>
+                </div>
+            </div>
+        </div>
+    </div>
+    
+    <!-- XRay Converter Import Modal -->
+    <div class="modal fade" id="xrayConverterTestModal" tabindex="-1">
+        <div class="modal-dialog modal-lg">
+            <div class="modal-content">
+                <div class="modal-header">
+                    <h5 class="modal-title" id="xrayConverterTestTitle">Tes Converter</h5>
+                    <button type="button" class="btn-close" data-bs-dismiss="modal"></button>
+                </div>
+                <div class="modal-body">
+                    <div class="mb-3">
+                        <label class="form-label">Link XRay</label>
+                        <textarea class="form-control font-monospace" id="xrayTestLink" rows="3" placeholder="vmess://... / vless://... / trojan://... / ss://..."></textarea>
+                        <small class="text-muted">Dry-run: tidak tercatat di log, kuota, maupun usage.</small>
+                    </div>
+                    <div id="xrayTestResult"></div>
+                </div>
+                <div class="modal-footer">
+                    <button type="button" class="btn btn-secondary" data-bs-dismiss="modal">Tutup</button>
+                    <button type="button" class="btn btn-primary" onclick="testXRayConverter()">Tes</button>
+                </div>
+            </div>
+        </div>
+    </div>
+
+    <div class="modal fade" id="xrayImportModal" tabindex="-1">
+        <div class="modal-dialog modal-lg">
+            <div class="modal-content">
+                <div class="modal-header">
+                    <h5 class="modal-title">Import Bundle Converter</h5>
+                    <button type="button" class="btn-close" data-bs-dismiss="modal"></button>
+                </div>
+                <div class="modal-body">
+                    <div class="mb-3">
+                        <label class="form-label">File Bundle (.json)</label>
+                        <input type="file" class="form-control" id="xrayImportFile" accept=".json,application/json" onchange="loadXRayImportFile()">
+                    </div>
+                    <div class="mb-3">
+                        <label class="form-label">Isi Bundle</label>
+                        <textarea class="form-control font-monospace" id="xrayImportBundle" rows="8" placeholder='{"format": "promote-xray-converters", "version": 1, "converters": [...]}'></textarea>
+                    </div>
+                    <div class="mb-3">
+                        <label class="form-label">Jika nama converter sudah ada</label>
+                        <select class="form-control" id="xrayImportMode">
+                            <option value="skip">Lewati (skip)</option>
+                            <option value="overwrite">Timpa (overwrite, tercatat di riwayat versi)</option>
+                            <option value="rename">Simpan dengan nama baru (rename)</option>
+                        </select>
+                    </div>
+                    <div id="xrayImportResult"></div>
+                </div>
+                <div class="modal-footer">
+                    <button type="button" class="btn btn-secondary" data-bs-dismiss="modal">Tutup</button>
+                    <button type="button" class="btn btn-outline-primary" onclick="importXRayBundle(true)">Preview</button>
+                    <button type="button" class="btn btn-primary" onclick="importXRayBundle(false)">Import</button>
+                </div>
+            </div>
+        </div>
+    </div>
+    
+    <!-- Rule Template Modal -->
+    <div class="modal fade" id="ruleTemplateModal" tabindex="-1">
+        <div class="modal-dialog modal-lg">
+            <div class="modal-content">
+                <div class="modal-header">
+                    <h5 class="modal-title" id="ruleTemplateModalTitle">Rule Template</h5>
+                    <button type="button" class="btn-close" data-bs-dismiss="modal"></button>
+                </div>
+                <div class="modal-body">
+                    <form id="ruleTemplateForm">
+                        <input type="hidden" id="ruleTemplateMode" value="create">
+                        <div class="row">
+                            <div class="col-md-6">
+                                <div class="mb-3">
+                                    <label class="form-label">Nama *</label>
+                                    <input type="text" class="form-control" id="ruleTemplateName" placeholder="adblock">
+                                </div>
+                            </div>
+                            <div class="col-md-6">
+                                <div class="mb-3">
+                                    <label class="form-label">DNS Mode</label>
+                                    <select class="form-control" id="ruleTemplateDNSMode">
+                                        <option value="fake-ip">fake-ip</option>
+                                        <option value="redir-host">redir-host</option>
+                                    </select>
+                                </div>
+                            </div>
+                        </div>
+                        <div class="mb-3">
+                            <label class="form-label">Deskripsi</label>
+                            <input type="text" class="form-control" id="ruleTemplateDescription">
+                        </div>
+                        <div class="mb-3">
+                            <label class="form-label">Rule Providers (YAML)</label>
+                            <textarea class="form-control font-monospace" id="ruleTemplateProviders" rows="8" placeholder="reject:&#10;  type: http&#10;  behavior: domain&#10;  url: &quot;https://...&quot;&#10;  path: ./ruleset/reject.yaml&#10;  interval: 86400"></textarea>
+                            <small class="text-muted">Isi di bawah key <code>rule-providers:</code>, tanpa indentasi awal</small>
+                        </div>
+                        <div class="mb-3">
+                            <label class="form-label">Rules</label>
+                            <textarea class="form-control font-monospace" id="ruleTemplateRules" rows="6" placeholder="RULE-SET,reject,REJECT&#10;GEOIP,PRIVATE,DIRECT,no-resolve&#10;MATCH,PROXY"></textarea>
+                            <small class="text-muted">Satu rule per baris. MATCH,PROXY otomatis ditambahkan jika tidak ada</small>
+                        </div>
+                        <div class="mb-3">
+                            <label class="form-label">Status</label>
+                            <select class="form-control" id="ruleTemplateIsActive">
+                                <option value="true">✅ Aktif</option>
+                                <option value="false">❌ Nonaktif</option>
+                            </select>
+                        </div>
+                    </form>
+                </div>
+                <div class="modal-footer">
+                    <button type="button" class="btn btn-secondary" data-bs-dismiss="modal">Batal</button>
+                    <button type="button" class="btn btn-primary" onclick="saveRuleTemplate()">Simpan</button>
+                </div>
+            </div>
+        </div>
+    </div>
+    
     <script src="https://cdn.jsdelivr.net/npm/bootstrap@5.1.3/dist/js/bootstrap.bundle.min.js"></script>
     <script>
         let currentGroups = [];
         let currentCommands = [];
         let currentAutoResponses = [];
         let currentXRayConverters = [];
+        let currentRuleTemplates = [];
+        let currentPromoteData = null;
 
         document.addEventListener('DOMContentLoaded', function() {
             showTab('groups');
@@ -776,9 +1172,10 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
                 case 'groups': refreshGroups(); break;
                 case 'commands': refreshCommands(); break;
                 case 'autoresponses': refreshAutoResponses(); break;
-                case 'xray': refreshXRayConverters(); break;
+                case 'xray': refreshXRayConverters(); refreshRuleTemplates(); break;
                 case 'autoremove': refreshAutoRemoveTab(); break;
                 case 'stats': refreshStats(); break;
+                case 'promote': refreshPromoteGroups(); break;
             }
         }
 
@@ -810,6 +1207,12 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
                 html += '<div class="col-md-6 mb-3"><div class="card"><div class="card-body">';
                 html += '<h6>' + (group.group_name || 'Tanpa Nama') + ' <span class="badge ' + badge + '">' + status + '</span></h6>';
                 html += '<p class="small text-muted">JID: ' + group.group_jid + '</p>';
+                html += '<label class="form-label small mb-1">Pengiriman hasil converter</label>';
+                html += '<select class="form-select form-select-sm mb-2" onchange="setGroupDeliveryMode(\'' + group.group_jid + '\', this.value)">';
+                [['', '💬 Balas di grup'], ['dm', '📩 DM user saja'], ['dm_ack', '📩 DM + konfirmasi di grup']].forEach(([value, label]) => {
+                    html += '<option value="' + value + '"' + ((group.xray_delivery_mode || '') === value ? ' selected' : '') + '>' + label + '</option>';
+                });
+                html += '</select>';
                 html += '<div class="mt-2">';
                 html += '<button class="btn btn-sm btn-danger" onclick="removeLearningGroup(\'' + group.group_jid + '\', \'' + (group.group_name || 'Tanpa Nama') + '\')">Hapus</button>';
                 html += '</div>';
@@ -1496,6 +1899,30 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
             });
         }
 
+        function setGroupDeliveryMode(jid, mode) {
+            const group = currentGroups.find(g => g.group_jid === jid);
+            if (!group) return;
+
+            fetch('/api/groups', {
+                method: 'PUT',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({...group, xray_delivery_mode: mode})
+            })
+            .then(response => response.json())
+            .then(data => {
+                if (data.status === 'success') {
+                    group.xray_delivery_mode = mode;
+                    showAlert('success', 'Mode pengiriman grup diperbarui');
+                } else {
+                    showAlert('danger', 'Gagal memperbarui mode pengiriman');
+                }
+            })
+            .catch(error => {
+                console.error('Error updating delivery mode:', error);
+                showAlert('danger', 'Gagal memperbarui mode pengiriman');
+            });
+        }
+
         function removeLearningGroup(jid, name) {
             if (!confirm('Hapus grup "' + name + '" dari daftar pembelajaran?')) return;
             
@@ -1666,60 +2093,269 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
             });
         }
 
-        // === XRAY CONVERTER FUNCTIONS ===
+        // === AUTO PROMOTE TARGET FUNCTIONS ===
 
-        function refreshXRayConverters() {
-            fetch('/api/xray_converters')
+        function refreshPromoteGroups() {
+            fetch('/api/promote/groups')
                 .then(response => response.json())
                 .then(data => {
-                    currentXRayConverters = data.converters || [];
-                    displayXRayConverters();
+                    currentPromoteData = data;
+                    displayPromoteGroups();
+                    displayPromoteTemplateMedia();
                 })
-                .catch(error => console.error('Error:', error));
+                .catch(error => {
+                    console.error('Error:', error);
+                    showAlert('danger', 'Gagal memuat grup auto promote');
+                });
         }
 
-        function displayXRayConverters() {
-            const container = document.getElementById('xray-converters-list');
-            if (currentXRayConverters.length === 0) {
-                container.innerHTML = '<div class="col-12"><div class="alert alert-info"><i class="fas fa-info-circle"></i> Belum ada XRay converter. Klik "Tambah Converter" untuk membuat yang pertama.</div></div>';
+        function escapePromoteText(value) {
+            const div = document.createElement('div');
+            div.textContent = value === undefined || value === null ? '' : String(value);
+            return div.innerHTML;
+        }
+
+        function displayPromoteGroups() {
+            const container = document.getElementById('promote-groups-list');
+            const data = currentPromoteData || {};
+            if (!data.success) {
+                container.innerHTML = '<div class="col-12"><div class="alert alert-warning"><i class="fas fa-exclamation-triangle"></i> ' + escapePromoteText(data.message || 'Auto promote tidak tersedia') + '</div></div>';
                 return;
             }
 
+            const groups = data.groups || [];
+            if (groups.length === 0) {
+                container.innerHTML = '<div class="col-12"><div class="alert alert-info"><i class="fas fa-info-circle"></i> Belum ada grup auto promote. Aktifkan dari chat dengan .promote di grup.</div></div>';
+                return;
+            }
+
+            const names = data.group_names || {};
+            const categories = data.categories || [];
+            const templates = data.templates || [];
+
             let html = '';
-            currentXRayConverters.forEach(converter => {
-                const statusBadge = converter.is_active ? 
-                    '<span class="badge bg-success">Aktif</span>' : 
+            groups.forEach((item, index) => {
+                const group = item.group;
+                const selectedCategories = item.categories || [];
+                const selectedTemplates = item.template_ids || [];
+                const statusBadge = group.is_active ?
+                    '<span class="badge bg-success">Aktif</span>' :
                     '<span class="badge bg-secondary">Nonaktif</span>';
-                
-                const typeIcon = {
-                    'wildcard': '🌐',
-                    'sni': '🔐',
-                    'ws': '📡',
-                    'grpc': '⚡'
-                }[converter.modify_type] || '🔧';
 
-                html += ` + "`" + `
-                    <div class="col-md-6 col-lg-4 mb-3">
-                        <div class="card h-100">
-                            <div class="card-body">
-                                <div class="d-flex justify-content-between align-items-start mb-2">
-                                    <h6 class="card-title mb-0">${converter.display_name}</h6>
-                                    ${statusBadge}
-                                </div>
-                                <p class="card-text mb-1">
-                                    <strong>Command:</strong> .${converter.command_name}<br>
-                                    <strong>Type:</strong> ${typeIcon} ${converter.modify_type}<br>
-                                    <strong>Bug Host:</strong> ${converter.bug_host}<br>
-                                    <strong>Usage:</strong> ${converter.usage_count || 0}x
-                                </p>
-                                ${converter.path_template ? ` + "`" + `<small class="text-muted">Path: ${converter.path_template}</small><br>` + "`" + ` : ''}
-                                ${converter.grpc_service_name ? ` + "`" + `<small class="text-muted">gRPC: ${converter.grpc_service_name}</small><br>` + "`" + ` : ''}
-                                <small class="text-muted">Created by: ${converter.created_by}</small>
-                            </div>
-                            <div class="card-footer">
-                                <div class="btn-group w-100" role="group">
-                                    <button class="btn btn-outline-primary btn-sm" onclick="editXRayConverter('${converter.command_name}')">
-                                        <i class="fas fa-edit"></i>
+                let categoryHtml = '';
+                categories.forEach(category => {
+                    const checked = selectedCategories.indexOf(category) >= 0 ? ' checked' : '';
+                    categoryHtml += '<div class="form-check form-check-inline">' +
+                        '<input class="form-check-input promote-category-' + index + '" type="checkbox" value="' + escapePromoteText(category) + '"' + checked + '>' +
+                        '<label class="form-check-label">' + escapePromoteText(category) + '</label></div>';
+                });
+                // Kategori target yang belum punya template tetap ditampilkan
+                selectedCategories.forEach(category => {
+                    if (categories.indexOf(category) < 0) {
+                        categoryHtml += '<div class="form-check form-check-inline">' +
+                            '<input class="form-check-input promote-category-' + index + '" type="checkbox" value="' + escapePromoteText(category) + '" checked>' +
+                            '<label class="form-check-label text-muted">' + escapePromoteText(category) + ' (belum ada template)</label></div>';
+                    }
+                });
+
+                let templateHtml = '';
+                templates.forEach(template => {
+                    const checked = selectedTemplates.indexOf(template.id) >= 0 ? ' checked' : '';
+                    const inactive = template.is_active ? '' : ' <span class="badge bg-secondary">Nonaktif</span>';
+                    templateHtml += '<div class="form-check">' +
+                        '<input class="form-check-input promote-template-' + index + '" type="checkbox" value="' + template.id + '"' + checked + '>' +
+                        '<label class="form-check-label">#' + template.id + ' ' + escapePromoteText(template.title) +
+                        ' <small class="text-muted">(' + escapePromoteText(template.category) + ')</small>' + inactive + '</label></div>';
+                });
+
+                html += '<div class="col-md-6 mb-3"><div class="card h-100">' +
+                    '<div class="card-body">' +
+                    '<div class="d-flex justify-content-between align-items-start mb-2">' +
+                    '<h6 class="card-title mb-0">' + escapePromoteText(names[group.group_jid] || group.group_jid) + '</h6>' + statusBadge + '</div>' +
+                    '<p class="card-text mb-2"><small class="text-muted">' + escapePromoteText(group.group_jid) + '</small><br>' +
+                    '<strong>Jadwal:</strong> ' + escapePromoteText(item.schedule) + '<br>' +
+                    '<strong>Rotasi:</strong> ' + escapePromoteText(item.rotation) + '</p>' +
+                    '<strong>Kategori:</strong><div class="mb-2">' + (categoryHtml || '<small class="text-muted">Belum ada kategori</small>') + '</div>' +
+                    '<strong>Template:</strong><div style="max-height:200px;overflow-y:auto;">' + (templateHtml || '<small class="text-muted">Belum ada template</small>') + '</div>' +
+                    '</div>' +
+                    '<div class="card-footer"><button class="btn btn-primary btn-sm w-100" onclick="savePromoteGroupTargets(' + index + ')">' +
+                    '<i class="fas fa-save"></i> Simpan Target</button></div>' +
+                    '</div></div>';
+            });
+
+            container.innerHTML = html;
+        }
+
+        function displayPromoteTemplateMedia() {
+            const container = document.getElementById('promote-templates-list');
+            const data = currentPromoteData || {};
+            const templates = data.templates || [];
+            if (!data.success || templates.length === 0) {
+                container.innerHTML = data.success ? '<div class="alert alert-info"><i class="fas fa-info-circle"></i> Belum ada template promosi.</div>' : '';
+                return;
+            }
+
+            const mediaIcons = {'image': '🖼️', 'video': '🎬', 'document': '📄'};
+            let html = '<div class="table-responsive"><table class="table table-sm align-middle"><thead><tr>' +
+                '<th>ID</th><th>Judul</th><th>Kategori</th><th>Media</th><th>Upload</th></tr></thead><tbody>';
+            templates.forEach(template => {
+                const media = template.media_path ?
+                    (mediaIcons[template.media_type] || '📎') + ' ' + escapePromoteText(template.media_type) +
+                    '<br><small class="text-muted">' + escapePromoteText(template.media_path) + '</small>' :
+                    '<span class="text-muted">Teks saja</span>';
+                const removeButton = template.media_path ?
+                    '<button class="btn btn-outline-danger btn-sm" onclick="savePromoteTemplateMedia(' + template.id + ', \'\')"><i class="fas fa-times"></i></button>' : '';
+
+                html += '<tr><td>#' + template.id + '</td>' +
+                    '<td>' + escapePromoteText(template.title) + '</td>' +
+                    '<td>' + escapePromoteText(template.category) + '</td>' +
+                    '<td>' + media + '</td>' +
+                    '<td><div class="input-group input-group-sm">' +
+                    '<input type="file" class="form-control" id="promote-media-' + template.id + '" accept="image/*,video/*,.pdf,.doc,.docx,.xls,.xlsx,.zip">' +
+                    '<button class="btn btn-primary" onclick="uploadPromoteTemplateMedia(' + template.id + ')"><i class="fas fa-upload"></i></button>' +
+                    removeButton + '</div></td></tr>';
+            });
+            html += '</tbody></table></div>';
+            container.innerHTML = html;
+        }
+
+        function uploadPromoteTemplateMedia(templateId) {
+            uploadFile(document.getElementById('promote-media-' + templateId), 'promote', filepath => {
+                savePromoteTemplateMedia(templateId, filepath);
+            });
+        }
+
+        function savePromoteTemplateMedia(templateId, mediaPath) {
+            fetch('/api/promote/templates/media', {
+                method: 'PUT',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({id: templateId, media_path: mediaPath})
+            })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    showAlert('success', escapePromoteText(data.message));
+                    refreshPromoteGroups();
+                } else {
+                    showAlert('danger', 'Gagal menyimpan media: ' + escapePromoteText(data.message));
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                showAlert('danger', 'Gagal menyimpan media template');
+            });
+        }
+
+        function savePromoteGroupTargets(index) {
+            const item = currentPromoteData.groups[index];
+            const categories = Array.from(document.querySelectorAll('.promote-category-' + index + ':checked')).map(input => input.value);
+            const templateIds = Array.from(document.querySelectorAll('.promote-template-' + index + ':checked')).map(input => parseInt(input.value, 10));
+
+            fetch('/api/promote/groups', {
+                method: 'PUT',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({group_jid: item.group.group_jid, categories: categories, template_ids: templateIds})
+            })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    showAlert('success', escapePromoteText(data.message));
+                    refreshPromoteGroups();
+                } else {
+                    showAlert('danger', 'Gagal menyimpan target: ' + escapePromoteText(data.message));
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                showAlert('danger', 'Gagal menyimpan target template');
+            });
+        }
+
+        // === XRAY CONVERTER FUNCTIONS ===
+
+        // xrayChangedBy nama pengubah converter yang dicatat di riwayat versi (disimpan di browser)
+        function xrayChangedBy() {
+            return document.getElementById('xrayChangedBy').value.trim();
+        }
+
+        function saveXRayChangedBy() {
+            localStorage.setItem('xrayChangedBy', xrayChangedBy());
+        }
+
+        function exportXRayConverters() {
+            window.location = '/api/xray_converters/export?changed_by=' + encodeURIComponent(xrayChangedBy());
+        }
+
+        function refreshXRayConverters() {
+            const changedByInput = document.getElementById('xrayChangedBy');
+            if (!changedByInput.value) changedByInput.value = localStorage.getItem('xrayChangedBy') || '';
+
+            fetch('/api/xray_converters')
+                .then(response => response.json())
+                .then(data => {
+                    currentXRayConverters = data.converters || [];
+                    displayXRayConverters();
+                })
+                .catch(error => console.error('Error:', error));
+        }
+
+        function displayXRayConverters() {
+            const container = document.getElementById('xray-converters-list');
+            if (currentXRayConverters.length === 0) {
+                container.innerHTML = '<div class="col-12"><div class="alert alert-info"><i class="fas fa-info-circle"></i> Belum ada XRay converter. Klik "Tambah Converter" untuk membuat yang pertama.</div></div>';
+                return;
+            }
+
+            let html = '';
+            currentXRayConverters.forEach(converter => {
+                const statusBadge = converter.is_active ? 
+                    '<span class="badge bg-success">Aktif</span>' : 
+                    '<span class="badge bg-secondary">Nonaktif</span>';
+                
+                const typeIcon = {
+                    'wildcard': '🌐',
+                    'sni': '🔐',
+                    'ws': '📡',
+                    'grpc': '⚡'
+                }[converter.modify_type] || '🔧';
+
+                html += ` + "`" + `
+                    <div class="col-md-6 col-lg-4 mb-3">
+                        <div class="card h-100">
+                            <div class="card-body">
+                                <div class="d-flex justify-content-between align-items-start mb-2">
+                                    <h6 class="card-title mb-0">${converter.display_name}</h6>
+                                    ${statusBadge}
+                                </div>
+                                <p class="card-text mb-1">
+                                    <strong>Command:</strong> .${converter.command_name}<br>
+                                    <strong>Kategori:</strong> ${converter.category || 'Lainnya'}<br>
+                                    <strong>Type:</strong> ${typeIcon} ${converter.modify_type}<br>
+                                    <strong>Bug Host:</strong> ${converter.bug_host}<br>
+                                    <strong>Usage:</strong> ${converter.usage_count || 0}x
+                                </p>
+                                ${converter.path_template ? ` + "`" + `<small class="text-muted">Path: ${converter.path_template}</small><br>` + "`" + ` : ''}
+                                ${converter.grpc_service_name ? ` + "`" + `<small class="text-muted">gRPC: ${converter.grpc_service_name}</small><br>` + "`" + ` : ''}
+                                ${converter.output_formats ? ` + "`" + `<small class="text-muted">Formats: ${converter.output_formats}</small><br>` + "`" + ` : ''}
+                                ${converter.profile_template ? ` + "`" + `<small class="text-muted">Profile: ${converter.profile_template}</small><br>` + "`" + ` : ''}
+                                ${converter.rules && converter.rules.length ? ` + "`" + `<small class="text-muted">Rules: ${converter.rules.length}</small><br>` + "`" + ` : ''}
+                                ${converter.probe_enabled ? ` + "`" + `<small class="text-muted">🩺 Probe aktif</small><br>` + "`" + ` : ''}
+                                ${converter.delivery_mode ? ` + "`" + `<small class="text-muted">Pengiriman: ${converter.delivery_mode}${converter.yaml_as_document ? ' + file .yaml' : ''}</small><br>` + "`" + ` : ''}
+                                ${converter.daily_user_quota || converter.daily_group_quota || converter.daily_global_quota ? ` + "`" + `<small class="text-muted">Kuota/hari: user ${converter.daily_user_quota || '∞'}, grup ${converter.daily_group_quota || '∞'}, global ${converter.daily_global_quota || '∞'}</small><br>` + "`" + ` : ''}
+                                <small class="text-muted">Created by: ${converter.created_by}</small>
+                                ${converter.updated_by ? ` + "`" + `<br><small class="text-muted">Updated by: ${converter.updated_by}</small>` + "`" + ` : ''}
+                                ${converter.version_id ? ` + "`" + `<br><small class="text-muted">Versi: #${converter.version_id}</small>` + "`" + ` : ''}
+                            </div>
+                            <div class="card-footer">
+                                <div class="btn-group w-100" role="group">
+                                    <button class="btn btn-outline-primary btn-sm" onclick="editXRayConverter('${converter.command_name}')">
+                                        <i class="fas fa-edit"></i>
+                                    </button>
+                                    <button class="btn btn-outline-success btn-sm" onclick="showXRayConverterTest('${converter.command_name}')">
+                                        <i class="fas fa-vial"></i>
+                                    </button>
+                                    <button class="btn btn-outline-secondary btn-sm" onclick="showXRayConverterHistory('${converter.command_name}')">
+                                        <i class="fas fa-history"></i>
                                     </button>
                                     <button class="btn btn-outline-danger btn-sm" onclick="deleteXRayConverter('${converter.command_name}')">
                                         <i class="fas fa-trash"></i>
@@ -1749,10 +2385,30 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
             }
         }
 
+        // parseConverterRules membaca textarea rules JSON (kosong = tanpa rule), null jika tidak valid
+        function parseConverterRules(elementId) {
+            const raw = document.getElementById(elementId).value.trim();
+            if (!raw) return [];
+            try {
+                const rules = JSON.parse(raw);
+                if (!Array.isArray(rules)) throw new Error('rules harus berupa array');
+                return rules;
+            } catch (error) {
+                alert('❌ Rules JSON tidak valid: ' + error.message);
+                return null;
+            }
+        }
+
         function saveNewXRayConverter() {
+            const rules = parseConverterRules('newConverterRules');
+            if (rules === null) return;
+
             const converterData = {
                 command_name: document.getElementById('newConverterCommand').value,
                 display_name: document.getElementById('newConverterDisplayName').value,
+                category: document.getElementById('newConverterCategory').value.trim(),
+                description: document.getElementById('newConverterDescription').value,
+                example: document.getElementById('newConverterExample').value,
                 bug_host: document.getElementById('newConverterBugHost').value,
                 modify_type: document.getElementById('newConverterModifyType').value,
                 server_template: document.getElementById('newConverterServerTemplate').value,
@@ -1761,7 +2417,17 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
                 path_template: document.getElementById('newConverterPathTemplate').value,
                 grpc_service_name: document.getElementById('newConverterGrpcService').value,
                 port_override: document.getElementById('newConverterPortOverride').value ? 
-                    parseInt(document.getElementById('newConverterPortOverride').value) : null
+                    parseInt(document.getElementById('newConverterPortOverride').value) : null,
+                output_formats: document.getElementById('newConverterOutputFormats').value,
+                profile_template: document.getElementById('newConverterProfileTemplate').value,
+                rules: rules,
+                probe_enabled: document.getElementById('newConverterProbeEnabled').value === 'true',
+                daily_user_quota: parseInt(document.getElementById('newConverterUserQuota').value) || 0,
+                daily_group_quota: parseInt(document.getElementById('newConverterGroupQuota').value) || 0,
+                daily_global_quota: parseInt(document.getElementById('newConverterGlobalQuota').value) || 0,
+                delivery_mode: document.getElementById('newConverterDeliveryMode').value,
+                yaml_as_document: document.getElementById('newConverterYAMLAsDocument').value === 'true',
+                changed_by: xrayChangedBy()
             };
 
             // Validation
@@ -1795,7 +2461,7 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
         function deleteXRayConverter(commandName) {
             if (!confirm(` + "`" + `Yakin ingin menghapus converter "${commandName}"?` + "`" + `)) return;
 
-            fetch(` + "`" + `/api/xray_converters?command=${commandName}` + "`" + `, {
+            fetch('/api/xray_converters?command=' + encodeURIComponent(commandName) + '&changed_by=' + encodeURIComponent(xrayChangedBy()), {
                 method: 'DELETE'
             })
             .then(response => response.json())
@@ -1813,6 +2479,231 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
             });
         }
 
+        // escapeHistoryValue menampilkan nilai field diff (string/JSON) dengan aman sebagai HTML
+        function escapeHistoryValue(value) {
+            if (value === undefined || value === null) return '<em class="text-muted">kosong</em>';
+            const text = typeof value === 'string' ? value : JSON.stringify(value);
+            const div = document.createElement('div');
+            div.textContent = text;
+            return '<code>' + div.innerHTML + '</code>';
+        }
+
+        function promptXRayConverterHistory() {
+            const commandName = prompt('Command converter (termasuk yang sudah dihapus):');
+            if (commandName) showXRayConverterHistory(commandName.trim().replace(/^\./, ''));
+        }
+
+        function showXRayConverterHistory(commandName) {
+            document.getElementById('xrayConverterHistoryTitle').textContent = 'Riwayat Converter .' + commandName;
+            const container = document.getElementById('xrayConverterHistoryList');
+            container.innerHTML = '<div class="text-muted">Memuat...</div>';
+            new bootstrap.Modal(document.getElementById('xrayConverterHistoryModal')).show();
+
+            fetch('/api/xray_converters/history?command=' + encodeURIComponent(commandName))
+                .then(response => response.json())
+                .then(data => {
+                    const versions = data.versions || [];
+                    if (versions.length === 0) {
+                        container.innerHTML = '<div class="alert alert-info">Belum ada riwayat untuk converter ini.</div>';
+                        return;
+                    }
+
+                    const current = currentXRayConverters.find(c => c.command_name === commandName);
+                    const actionBadge = {
+                        'create': 'bg-success',
+                        'update': 'bg-primary',
+                        'rollback': 'bg-warning text-dark',
+                        'delete': 'bg-danger'
+                    };
+
+                    let html = '';
+                    versions.forEach(version => {
+                        const isCurrent = current && current.version_id === version.id;
+                        let diffHtml = '';
+                        if (version.diff && version.diff.length) {
+                            diffHtml = '<table class="table table-sm mb-0"><thead><tr><th>Field</th><th>Sebelum</th><th>Sesudah</th></tr></thead><tbody>';
+                            version.diff.forEach(change => {
+                                diffHtml += '<tr><td>' + change.field + '</td><td>' + escapeHistoryValue(change.old) + '</td><td>' + escapeHistoryValue(change.new) + '</td></tr>';
+                            });
+                            diffHtml += '</tbody></table>';
+                        } else if (version.action === 'delete') {
+                            diffHtml = '<small class="text-muted">Converter dihapus. Rollback ke versi ini akan membuat ulang converter.</small>';
+                        } else {
+                            diffHtml = '<small class="text-muted">Tidak ada perubahan field.</small>';
+                        }
+
+                        html += '<div class="card mb-2"><div class="card-body">' +
+                            '<div class="d-flex justify-content-between align-items-start mb-2"><div>' +
+                            '<strong>v' + version.version + '</strong> ' +
+                            '<span class="badge ' + (actionBadge[version.action] || 'bg-secondary') + '">' + version.action + '</span> ' +
+                            (isCurrent ? '<span class="badge bg-info text-dark">aktif</span> ' : '') +
+                            (version.rollback_of ? '<small class="text-muted">dari versi #' + version.rollback_of + '</small> ' : '') +
+                            '<br><small class="text-muted">#' + version.id + ' • ' + new Date(version.created_at).toLocaleString() + ' • oleh ' + (version.changed_by || '-') + '</small>' +
+                            '</div>' +
+                            (isCurrent ? '' : '<button class="btn btn-sm btn-outline-warning" onclick="rollbackXRayConverter(' + version.id + ', \'' + commandName + '\', ' + version.version + ')"><i class="fas fa-undo"></i> Rollback</button>') +
+                            '</div>' + diffHtml + '</div></div>';
+                    });
+                    container.innerHTML = html;
+                })
+                .catch(error => {
+                    console.error('Error:', error);
+                    container.innerHTML = '<div class="alert alert-danger">❌ Gagal memuat riwayat: ' + error.message + '</div>';
+                });
+        }
+
+        function rollbackXRayConverter(versionId, commandName, versionNumber) {
+            if (!confirm('Kembalikan converter "' + commandName + '" ke v' + versionNumber + '?')) return;
+
+            fetch('/api/xray_converters/rollback', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ version_id: versionId, changed_by: xrayChangedBy() })
+            })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    alert('✅ Converter berhasil dikembalikan ke v' + versionNumber + '!');
+                    bootstrap.Modal.getInstance(document.getElementById('xrayConverterHistoryModal')).hide();
+                    refreshXRayConverters();
+                } else {
+                    alert('❌ Gagal rollback converter: ' + (data.message || 'Unknown error'));
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                alert('❌ Error: ' + error.message);
+            });
+        }
+
+        let testingXRayConverter = '';
+
+        function showXRayConverterTest(commandName) {
+            testingXRayConverter = commandName;
+            document.getElementById('xrayConverterTestTitle').textContent = 'Tes Converter .' + commandName;
+            document.getElementById('xrayTestResult').innerHTML = '';
+            new bootstrap.Modal(document.getElementById('xrayConverterTestModal')).show();
+        }
+
+        function testXRayConverter() {
+            const link = document.getElementById('xrayTestLink').value.trim();
+            const container = document.getElementById('xrayTestResult');
+            if (!link) {
+                alert('Masukkan link XRay');
+                return;
+            }
+            container.innerHTML = '<div class="text-muted">Memproses...</div>';
+
+            fetch('/api/xray_converters/test', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ converter_name: testingXRayConverter, xray_link: link })
+            })
+            .then(response => response.json())
+            .then(data => {
+                if (!data.success) {
+                    container.innerHTML = '<div class="alert alert-danger">❌ ' + escapeHistoryValue(data.message || 'Unknown error') + '</div>';
+                    return;
+                }
+
+                const dryRun = data.dry_run;
+                let html = '<h6>Diff (' + data.changed_fields + ' field berubah)</h6>' +
+                    '<table class="table table-sm"><thead><tr><th>Field</th><th>Asli</th><th>Hasil</th></tr></thead><tbody>';
+                dryRun.diff.forEach(diff => {
+                    html += '<tr class="' + (diff.changed ? 'table-warning' : '') + '"><td>' + diff.field + '</td><td>' +
+                        escapeHistoryValue(diff.original) + '</td><td>' + escapeHistoryValue(diff.modified) + '</td></tr>';
+                });
+                html += '</tbody></table>';
+
+                if (dryRun.warnings && dryRun.warnings.length) {
+                    html += '<div class="alert alert-warning"><strong>Peringatan:</strong><ul class="mb-0">';
+                    dryRun.warnings.forEach(warning => {
+                        html += '<li>' + escapeHistoryValue(warning) + '</li>';
+                    });
+                    html += '</ul></div>';
+                } else {
+                    html += '<div class="alert alert-success">Tidak ada peringatan</div>';
+                }
+
+                html += '<h6>Preview pesan user (' + dryRun.messages.length + ' pesan)</h6>';
+                dryRun.messages.forEach((message, i) => {
+                    const body = document.createElement('div');
+                    body.textContent = message.file_name ? message.content : message.text;
+                    html += '<div class="card mb-2"><div class="card-header py-1"><small>#' + (i + 1) +
+                        (message.file_name ? ' 📎 ' + escapeHistoryValue(message.file_name) + ' — ' + escapeHistoryValue(message.caption) : '') +
+                        '</small></div><div class="card-body py-2"><pre class="mb-0" style="white-space: pre-wrap;">' + body.innerHTML + '</pre></div></div>';
+                });
+
+                container.innerHTML = html;
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                container.innerHTML = '<div class="alert alert-danger">❌ Error: ' + error.message + '</div>';
+            });
+        }
+
+        function showXRayImportModal() {
+            document.getElementById('xrayImportFile').value = '';
+            document.getElementById('xrayImportBundle').value = '';
+            document.getElementById('xrayImportResult').innerHTML = '';
+            new bootstrap.Modal(document.getElementById('xrayImportModal')).show();
+        }
+
+        function loadXRayImportFile() {
+            const file = document.getElementById('xrayImportFile').files[0];
+            if (!file) return;
+            file.text().then(text => {
+                document.getElementById('xrayImportBundle').value = text;
+            });
+        }
+
+        function importXRayBundle(dryRun) {
+            const bundle = document.getElementById('xrayImportBundle').value.trim();
+            if (!bundle) {
+                alert('Pilih file atau paste isi bundle terlebih dahulu');
+                return;
+            }
+            const mode = document.getElementById('xrayImportMode').value;
+            const container = document.getElementById('xrayImportResult');
+
+            fetch('/api/xray_converters/import?mode=' + mode + '&dry_run=' + dryRun + '&changed_by=' + encodeURIComponent(xrayChangedBy()), {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: bundle
+            })
+            .then(response => response.json())
+            .then(data => {
+                if (!data.success) {
+                    container.innerHTML = '<div class="alert alert-danger">❌ ' + (data.message || 'Unknown error') + '</div>';
+                    return;
+                }
+
+                const result = data.result;
+                let html = '<div class="alert ' + (result.dry_run ? 'alert-info' : 'alert-success') + '">' +
+                    (result.dry_run ? '🔍 Preview (belum disimpan)' : '✅ Import selesai') +
+                    ' • Signature: ' + (result.signed ? 'valid' : 'tidak diverifikasi') +
+                    ' • Baru: ' + result.created + ', Overwrite: ' + result.overwritten + ', Rename: ' + result.renamed +
+                    ', Skip: ' + result.skipped + ', Invalid: ' + result.invalid + '</div>';
+                html += '<table class="table table-sm"><thead><tr><th>Converter</th><th>Aksi</th><th>Detail</th></tr></thead><tbody>';
+                (result.items || []).forEach(item => {
+                    let detail = '';
+                    if (item.imported_as && item.imported_as !== item.command_name) detail += '→ ' + escapeHistoryValue('.' + item.imported_as) + ' ';
+                    if (item.diff && item.diff.length) {
+                        detail += item.diff.map(change => change.field + ': ' + escapeHistoryValue(change.old) + ' → ' + escapeHistoryValue(change.new)).join('<br>');
+                    }
+                    if (item.error) detail += '<span class="text-danger">' + escapeHistoryValue(item.error) + '</span>';
+                    html += '<tr><td>' + escapeHistoryValue('.' + item.command_name) + '</td><td>' + item.action + '</td><td>' + detail + '</td></tr>';
+                });
+                html += '</tbody></table>';
+                container.innerHTML = html;
+
+                if (!result.dry_run) refreshXRayConverters();
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                container.innerHTML = '<div class="alert alert-danger">❌ Error: ' + error.message + '</div>';
+            });
+        }
+
         function toggleEditAdvancedSettings() {
             const modifyType = document.getElementById('editConverterModifyType').value;
             const advancedSettings = document.getElementById('editAdvancedSettings');
@@ -1840,6 +2731,9 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
             document.getElementById('editConverterOriginalCommand').value = converter.command_name;
             document.getElementById('editConverterCommand').value = converter.command_name;
             document.getElementById('editConverterDisplayName').value = converter.display_name;
+            document.getElementById('editConverterCategory').value = converter.category || '';
+            document.getElementById('editConverterDescription').value = converter.description || '';
+            document.getElementById('editConverterExample').value = converter.example || '';
             document.getElementById('editConverterBugHost').value = converter.bug_host;
             document.getElementById('editConverterModifyType').value = converter.modify_type;
             document.getElementById('editConverterServerTemplate').value = converter.server_template || '';
@@ -1849,6 +2743,16 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
             document.getElementById('editConverterGrpcService').value = converter.grpc_service_name || '';
             document.getElementById('editConverterPortOverride').value = converter.port_override || '';
             document.getElementById('editConverterIsActive').value = converter.is_active ? 'true' : 'false';
+            document.getElementById('editConverterOutputFormats').value = converter.output_formats || '';
+            document.getElementById('editConverterProfileTemplate').value = converter.profile_template || '';
+            document.getElementById('editConverterRules').value = converter.rules && converter.rules.length ?
+                JSON.stringify(converter.rules, null, 2) : '';
+            document.getElementById('editConverterProbeEnabled').value = converter.probe_enabled ? 'true' : 'false';
+            document.getElementById('editConverterUserQuota').value = converter.daily_user_quota || '';
+            document.getElementById('editConverterGroupQuota').value = converter.daily_group_quota || '';
+            document.getElementById('editConverterGlobalQuota').value = converter.daily_global_quota || '';
+            document.getElementById('editConverterDeliveryMode').value = converter.delivery_mode || '';
+            document.getElementById('editConverterYAMLAsDocument').value = converter.yaml_as_document ? 'true' : 'false';
 
             // Toggle advanced settings if needed
             toggleEditAdvancedSettings();
@@ -1858,9 +2762,15 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
         }
 
         function saveEditXRayConverter() {
+            const rules = parseConverterRules('editConverterRules');
+            if (rules === null) return;
+
             const converterData = {
                 command_name: document.getElementById('editConverterOriginalCommand').value,
                 display_name: document.getElementById('editConverterDisplayName').value,
+                category: document.getElementById('editConverterCategory').value.trim(),
+                description: document.getElementById('editConverterDescription').value,
+                example: document.getElementById('editConverterExample').value,
                 bug_host: document.getElementById('editConverterBugHost').value,
                 modify_type: document.getElementById('editConverterModifyType').value,
                 server_template: document.getElementById('editConverterServerTemplate').value,
@@ -1870,7 +2780,17 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
                 grpc_service_name: document.getElementById('editConverterGrpcService').value,
                 port_override: document.getElementById('editConverterPortOverride').value ? 
                     parseInt(document.getElementById('editConverterPortOverride').value) : null,
-                is_active: document.getElementById('editConverterIsActive').value === 'true'
+                output_formats: document.getElementById('editConverterOutputFormats').value,
+                profile_template: document.getElementById('editConverterProfileTemplate').value,
+                rules: rules,
+                probe_enabled: document.getElementById('editConverterProbeEnabled').value === 'true',
+                daily_user_quota: parseInt(document.getElementById('editConverterUserQuota').value) || 0,
+                daily_group_quota: parseInt(document.getElementById('editConverterGroupQuota').value) || 0,
+                daily_global_quota: parseInt(document.getElementById('editConverterGlobalQuota').value) || 0,
+                delivery_mode: document.getElementById('editConverterDeliveryMode').value,
+                yaml_as_document: document.getElementById('editConverterYAMLAsDocument').value === 'true',
+                is_active: document.getElementById('editConverterIsActive').value === 'true',
+                changed_by: xrayChangedBy()
             };
 
             // Validation
@@ -1900,6 +2820,132 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
             });
         }
 
+        function refreshRuleTemplates() {
+            fetch('/api/xray_rule_templates')
+                .then(response => response.json())
+                .then(data => {
+                    currentRuleTemplates = data.templates || [];
+                    displayRuleTemplates();
+                    populateProfileTemplateSelects();
+                })
+                .catch(error => console.error('Error:', error));
+        }
+
+        function displayRuleTemplates() {
+            const container = document.getElementById('xray-rule-templates-list');
+            if (currentRuleTemplates.length === 0) {
+                container.innerHTML = '<div class="alert alert-info"><i class="fas fa-info-circle"></i> Belum ada rule template.</div>';
+                return;
+            }
+
+            let html = '<table class="table table-striped"><thead><tr><th>Nama</th><th>Deskripsi</th><th>DNS</th><th>Rules</th><th>Status</th><th>Aksi</th></tr></thead><tbody>';
+            currentRuleTemplates.forEach(template => {
+                const ruleCount = (template.rules || '').split('\n').filter(r => r.trim() !== '').length;
+                html += '<tr>' +
+                    '<td><code>' + template.name + '</code></td>' +
+                    '<td>' + (template.description || '-') + '</td>' +
+                    '<td>' + template.dns_mode + '</td>' +
+                    '<td>' + ruleCount + '</td>' +
+                    '<td>' + (template.is_active ? '<span class="badge bg-success">Aktif</span>' : '<span class="badge bg-secondary">Nonaktif</span>') + '</td>' +
+                    '<td>' +
+                        '<button class="btn btn-sm btn-outline-primary me-1" onclick="showRuleTemplateModal(\'' + template.name + '\')"><i class="fas fa-edit"></i></button>' +
+                        '<button class="btn btn-sm btn-outline-danger" onclick="deleteRuleTemplate(\'' + template.name + '\')"><i class="fas fa-trash"></i></button>' +
+                    '</td></tr>';
+            });
+            html += '</tbody></table>';
+            container.innerHTML = html;
+        }
+
+        function populateProfileTemplateSelects() {
+            document.querySelectorAll('.profile-template-select').forEach(select => {
+                const selected = select.value;
+                let options = '<option value="">— Hanya proxies (tanpa profile) —</option>';
+                currentRuleTemplates.forEach(template => {
+                    options += '<option value="' + template.name + '">' + template.name + (template.is_active ? '' : ' (nonaktif)') + '</option>';
+                });
+                select.innerHTML = options;
+                select.value = selected;
+            });
+        }
+
+        function showRuleTemplateModal(name) {
+            const template = name ? currentRuleTemplates.find(t => t.name === name) : null;
+
+            document.getElementById('ruleTemplateForm').reset();
+            document.getElementById('ruleTemplateMode').value = template ? 'update' : 'create';
+            document.getElementById('ruleTemplateModalTitle').textContent = template ? 'Edit Rule Template' : 'Tambah Rule Template';
+            document.getElementById('ruleTemplateName').disabled = !!template;
+
+            if (template) {
+                document.getElementById('ruleTemplateName').value = template.name;
+                document.getElementById('ruleTemplateDescription').value = template.description || '';
+                document.getElementById('ruleTemplateDNSMode').value = template.dns_mode || 'fake-ip';
+                document.getElementById('ruleTemplateProviders').value = template.rule_providers || '';
+                document.getElementById('ruleTemplateRules').value = template.rules || '';
+                document.getElementById('ruleTemplateIsActive').value = template.is_active ? 'true' : 'false';
+            }
+
+            new bootstrap.Modal(document.getElementById('ruleTemplateModal')).show();
+        }
+
+        function saveRuleTemplate() {
+            const isUpdate = document.getElementById('ruleTemplateMode').value === 'update';
+            const templateData = {
+                name: document.getElementById('ruleTemplateName').value.trim(),
+                description: document.getElementById('ruleTemplateDescription').value,
+                dns_mode: document.getElementById('ruleTemplateDNSMode').value,
+                rule_providers: document.getElementById('ruleTemplateProviders').value,
+                rules: document.getElementById('ruleTemplateRules').value,
+                is_active: document.getElementById('ruleTemplateIsActive').value === 'true'
+            };
+
+            if (!templateData.name) {
+                alert('Mohon isi nama template');
+                return;
+            }
+
+            fetch('/api/xray_rule_templates', {
+                method: isUpdate ? 'PUT' : 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify(templateData)
+            })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    alert('✅ Rule template berhasil disimpan!');
+                    bootstrap.Modal.getInstance(document.getElementById('ruleTemplateModal')).hide();
+                    refreshRuleTemplates();
+                } else {
+                    alert('❌ Gagal menyimpan template: ' + (data.message || 'Unknown error'));
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                alert('❌ Error: ' + error.message);
+            });
+        }
+
+        function deleteRuleTemplate(name) {
+            if (!confirm('Yakin ingin menghapus rule template "' + name + '"?')) return;
+
+            fetch('/api/xray_rule_templates?name=' + encodeURIComponent(name), {
+                method: 'DELETE'
+            })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    alert('✅ Rule template berhasil dihapus!');
+                    refreshRuleTemplates();
+                } else {
+                    alert('❌ Gagal menghapus template: ' + (data.message || 'Unknown error'));
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                alert('❌ Error: ' + error.message);
+            });
+        }
+
     </script>
 </body>
 </html>`
@@ -1965,6 +3011,11 @@ func (s *DashboardServer) updateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	if err := services.ValidateDeliveryMode(group.XRayDeliveryMode); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
 	if err := s.repository.UpdateLearningGroup(&group); err != nil {
 		s.logger.Errorf("Failed to update group: %v", err)
 		http.Error(w, "Failed to update group", http.StatusInternalServerError)
@@ -2256,13 +3307,34 @@ func (s *DashboardServer) handleGetXRayConverters(w http.ResponseWriter, r *http
 	json.NewEncoder(w).Encode(response)
 }
 
+// xrayConverterRequest body create/update converter beserta nama pengubah untuk riwayat versi
+type xrayConverterRequest struct {
+	database.XRayConverter
+	ChangedBy string `json:"changed_by"`
+}
+
+// dashboardActor returns the actor recorded in converter versions: the changed_by body field,
+// then the changed_by query parameter, else "dashboard" (the dashboard has no login)
+func dashboardActor(r *http.Request, changedBy string) string {
+	for _, actor := range []string{changedBy, r.URL.Query().Get("changed_by")} {
+		if actor = strings.TrimSpace(actor); actor != "" {
+			if len([]rune(actor)) > 64 {
+				actor = string([]rune(actor)[:64])
+			}
+			return actor
+		}
+	}
+	return "dashboard"
+}
+
 // handleCreateXRayConverter creates a new XRay converter
 func (s *DashboardServer) handleCreateXRayConverter(w http.ResponseWriter, r *http.Request) {
-	var converter database.XRayConverter
-	if err := json.NewDecoder(r.Body).Decode(&converter); err != nil {
+	var request xrayConverterRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
+	converter := request.XRayConverter
 
 	// Validate required fields
 	if converter.CommandName == "" || converter.DisplayName == "" || converter.BugHost == "" || converter.ModifyType == "" {
@@ -2270,9 +3342,25 @@ func (s *DashboardServer) handleCreateXRayConverter(w http.ResponseWriter, r *ht
 		return
 	}
 
+	if err := services.ValidateConverterRules(converter.Rules); err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err := services.ValidateDeliveryMode(converter.DeliveryMode); err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
 	// Set default values
 	converter.IsActive = true
-	converter.CreatedBy = "admin" // TODO: Get from session/auth
+	converter.CreatedBy = dashboardActor(r, request.ChangedBy)
+	converter.UpdatedBy = converter.CreatedBy
 
 	// Create converter
 	err := s.repository.CreateXRayConverter(&converter)
@@ -2293,18 +3381,34 @@ func (s *DashboardServer) handleCreateXRayConverter(w http.ResponseWriter, r *ht
 
 // handleUpdateXRayConverter updates an existing XRay converter
 func (s *DashboardServer) handleUpdateXRayConverter(w http.ResponseWriter, r *http.Request) {
-	var converter database.XRayConverter
-	if err := json.NewDecoder(r.Body).Decode(&converter); err != nil {
+	var request xrayConverterRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
+	converter := request.XRayConverter
 
 	// Validate required fields
 	if converter.CommandName == "" {
 		http.Error(w, "Command name is required", http.StatusBadRequest)
 		return
 	}
+	if err := services.ValidateConverterRules(converter.Rules); err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err := services.ValidateDeliveryMode(converter.DeliveryMode); err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
 
+	converter.UpdatedBy = dashboardActor(r, request.ChangedBy)
 	err := s.repository.UpdateXRayConverter(&converter)
 	if err != nil {
 		s.logger.Errorf("Failed to update XRay converter: %v", err)
@@ -2313,8 +3417,9 @@ func (s *DashboardServer) handleUpdateXRayConverter(w http.ResponseWriter, r *ht
 	}
 
 	response := map[string]interface{}{
-		"success": true,
-		"message": "Converter updated successfully",
+		"success":    true,
+		"message":    "Converter updated successfully",
+		"version_id": converter.VersionID,
 	}
 
 	json.NewEncoder(w).Encode(response)
@@ -2328,7 +3433,7 @@ func (s *DashboardServer) handleDeleteXRayConverter(w http.ResponseWriter, r *ht
 		return
 	}
 
-	err := s.repository.DeleteXRayConverter(commandName)
+	err := s.repository.DeleteXRayConverter(commandName, dashboardActor(r, ""))
 	if err != nil {
 		s.logger.Errorf("Failed to delete XRay converter: %v", err)
 		http.Error(w, "Failed to delete converter", http.StatusInternalServerError)
@@ -2343,7 +3448,251 @@ func (s *DashboardServer) handleDeleteXRayConverter(w http.ResponseWriter, r *ht
 	json.NewEncoder(w).Encode(response)
 }
 
-// handleXRayConverterTest tests an XRay converter with sample input
+// handleXRayConverterHistory returns the version history of an XRay converter (including deleted ones)
+func (s *DashboardServer) handleXRayConverterHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	commandName := r.URL.Query().Get("command")
+	if commandName == "" {
+		http.Error(w, "Command name is required", http.StatusBadRequest)
+		return
+	}
+
+	versions, err := s.repository.GetXRayConverterVersions(commandName)
+	if err != nil {
+		s.logger.Errorf("Failed to get XRay converter history: %v", err)
+		http.Error(w, "Failed to get converter history", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":  true,
+		"versions": versions,
+		"count":    len(versions),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleXRayConverterRollback restores an XRay converter to the snapshot of a previous version
+func (s *DashboardServer) handleXRayConverterRollback(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var rollbackRequest struct {
+		VersionID int    `json:"version_id"`
+		ChangedBy string `json:"changed_by"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&rollbackRequest); err != nil || rollbackRequest.VersionID == 0 {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	converter, err := s.repository.RollbackXRayConverter(rollbackRequest.VersionID, dashboardActor(r, rollbackRequest.ChangedBy))
+	if err != nil {
+		s.logger.Errorf("Failed to rollback XRay converter: %v", err)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	s.logger.Infof("XRay converter %s rolled back to version %d", converter.CommandName, rollbackRequest.VersionID)
+
+	response := map[string]interface{}{
+		"success":   true,
+		"message":   "Converter rolled back successfully",
+		"converter": converter,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleXRayConverterExport downloads converters as a (signed) JSON bundle: ?command=a,b (empty = all)
+func (s *DashboardServer) handleXRayConverterExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.xrayService == nil {
+		http.Error(w, "XRay converter service not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var names []string
+	if commands := r.URL.Query().Get("command"); commands != "" {
+		names = strings.Split(commands, ",")
+	}
+
+	bundle, err := s.xrayService.ExportConverters(names, dashboardActor(r, ""))
+	if err != nil {
+		s.logger.Errorf("Failed to export XRay converters: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := services.EncodeConverterBundle(bundle)
+	if err != nil {
+		s.logger.Errorf("Failed to encode XRay converter bundle: %v", err)
+		http.Error(w, "Failed to export converters", http.StatusInternalServerError)
+		return
+	}
+
+	fileName := fmt.Sprintf("xray-converters-%s.json", time.Now().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.Write(data)
+}
+
+// handleXRayConverterImport imports a converter bundle: ?mode=skip|overwrite|rename&dry_run=true
+func (s *DashboardServer) handleXRayConverterImport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.xrayService == nil {
+		http.Error(w, "XRay converter service not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	if err := services.ValidateConflictMode(mode); err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "Failed to read bundle", http.StatusBadRequest)
+		return
+	}
+
+	bundle, err := s.xrayService.ParseConverterBundle(data)
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	result, err := s.xrayService.ImportConverters(bundle, mode, dryRun, dashboardActor(r, ""))
+	if err != nil {
+		s.logger.Errorf("Failed to import XRay converters: %v", err)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"result":  result,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleXRayRuleTemplates handles CRUD operations for Mihomo profile rule templates
+func (s *DashboardServer) handleXRayRuleTemplates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	switch r.Method {
+	case "OPTIONS":
+		return
+	case "GET":
+		templates, err := s.repository.GetAllXRayRuleTemplates()
+		if err != nil {
+			s.logger.Errorf("Failed to get rule templates: %v", err)
+			http.Error(w, "Failed to get rule templates", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":   true,
+			"templates": templates,
+			"count":     len(templates),
+		})
+	case "POST", "PUT":
+		var template database.XRayRuleTemplate
+		if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if template.DNSMode == "" {
+			template.DNSMode = "fake-ip"
+		}
+		if err := services.ValidateRuleTemplate(&template); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		var err error
+		if r.Method == "POST" {
+			err = s.repository.CreateXRayRuleTemplate(&template)
+		} else {
+			err = s.repository.UpdateXRayRuleTemplate(&template)
+		}
+		if err != nil {
+			s.logger.Errorf("Failed to save rule template: %v", err)
+			http.Error(w, "Failed to save rule template", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":  true,
+			"message":  "Rule template saved successfully",
+			"template": template,
+		})
+	case "DELETE":
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "Template name is required", http.StatusBadRequest)
+			return
+		}
+		if err := s.repository.DeleteXRayRuleTemplate(name); err != nil {
+			s.logger.Errorf("Failed to delete rule template: %v", err)
+			http.Error(w, "Failed to delete rule template", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Rule template deleted successfully",
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleXRayConverterTest dry-runs an XRay converter against a sample link: field diff, warnings
+// and a preview of the chat messages users receive (no logs, quota or usage recorded)
 func (s *DashboardServer) handleXRayConverterTest(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -2353,24 +3702,71 @@ func (s *DashboardServer) handleXRayConverterTest(w http.ResponseWriter, r *http
 		return
 	}
 
+	if s.xrayService == nil {
+		http.Error(w, "XRay converter service not available", http.StatusServiceUnavailable)
+		return
+	}
+
 	var testRequest struct {
 		ConverterName string `json:"converter_name"`
 		XRayLink      string `json:"xray_link"`
+		GroupJID      string `json:"group_jid"` // opsional, untuk mode pengiriman grup
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&testRequest); err != nil {
+	if err := json.NewDecoder(r.Body).Decode(&testRequest); err != nil || testRequest.ConverterName == "" || testRequest.XRayLink == "" {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	// TODO: Implement XRay converter test logic
-	// This would use the XRayConverterService to test conversion
-	
+	dryRun, err := s.xrayService.DryRunConversion(strings.TrimPrefix(testRequest.ConverterName, "."), testRequest.XRayLink, testRequest.GroupJID)
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
 	response := map[string]interface{}{
-		"success": true,
-		"message": "Test functionality will be implemented with XRayConverterService integration",
-		"input": testRequest,
+		"success":        true,
+		"changed_fields": dryRun.ChangedFields(),
+		"dry_run":        dryRun,
 	}
 
 	json.NewEncoder(w).Encode(response)
-}
\ No newline at end of file
+}
+
+// handleSubscription melayani subscription URL: /sub/{token}?format=v2ray|clash|singbox
+func (s *DashboardServer) handleSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.xrayService == nil {
+		http.Error(w, "Subscription service not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	token := strings.Trim(strings.TrimPrefix(r.URL.Path, "/sub/"), "/")
+	if token == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	content, contentType, err := s.xrayService.RenderSubscription(token, r.URL.Query().Get("format"))
+	if err != nil {
+		if errors.Is(err, services.ErrSubscriptionNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		s.logger.Errorf("Failed to render subscription: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Profile-Update-Interval", "24")
+	w.Write([]byte(content))
+}
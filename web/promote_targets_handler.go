@@ -0,0 +1,166 @@
+// Package web - auto promote handlers (group template targeting, template media)
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go.mau.fi/whatsmeow"
+
+	"github.com/nabilulilalbab/promote/services"
+)
+
+// SetAutoPromoteService sets the auto promote service for group targeting endpoints
+func (s *DashboardServer) SetAutoPromoteService(svc *services.AutoPromoteService) {
+	s.promoteService = svc
+}
+
+// SetPromoteTemplateService sets the template service for promote template media endpoints.
+// Template media is limited to the dashboard upload directory.
+func (s *DashboardServer) SetPromoteTemplateService(svc *services.TemplateService) {
+	svc.SetMediaDir(s.mediaPath)
+	s.promoteTemplateService = svc
+}
+
+// handlePromoteTemplateMedia attaches a media file uploaded via /api/upload to a promote
+// template (PUT {id, media_path}); an empty media_path removes the attachment
+func (s *DashboardServer) handlePromoteTemplateMedia(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "PUT, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != "PUT" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.promoteTemplateService == nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Auto promote tidak aktif (ENABLE_AUTO_PROMOTE)",
+		})
+		return
+	}
+
+	var request struct {
+		ID        int    `json:"id"`
+		MediaPath string `json:"media_path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.ID <= 0 {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	template, err := s.promoteTemplateService.SetTemplateMedia(request.ID, request.MediaPath)
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"message":  "Media template #" + strconv.Itoa(template.ID) + ": " + services.DescribeTemplateMedia(template),
+		"template": template,
+	})
+}
+
+// handlePromoteGroups lists auto promote groups with their template targets (GET)
+// and replaces the targets of one group (PUT {group_jid, categories, template_ids})
+func (s *DashboardServer) handlePromoteGroups(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if s.promoteService == nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Auto promote tidak aktif (ENABLE_AUTO_PROMOTE)",
+		})
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		groups, err := s.promoteService.ListGroupTargeting()
+		if err != nil {
+			s.logger.Errorf("Failed to get auto promote groups: %v", err)
+			http.Error(w, "Failed to get auto promote groups", http.StatusInternalServerError)
+			return
+		}
+		templates, err := s.promoteService.GetAllTemplates()
+		if err != nil {
+			s.logger.Errorf("Failed to get promote templates: %v", err)
+			http.Error(w, "Failed to get promote templates", http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":     true,
+			"groups":      groups,
+			"group_names": s.whatsAppGroupNames(),
+			"templates":   templates,
+			"categories":  services.TemplateCategories(templates),
+		})
+	case "PUT":
+		var request struct {
+			GroupJID    string   `json:"group_jid"`
+			Categories  []string `json:"categories"`
+			TemplateIDs []int    `json:"template_ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.GroupJID == "" {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		targets, err := s.promoteService.SetGroupTemplates(request.GroupJID, request.Categories, request.TemplateIDs)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Target template disimpan: " + services.DescribeTemplateTargets(targets),
+			"targets": targets,
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// whatsAppGroupNames maps group JID to WhatsApp group name (empty when the client is not connected)
+func (s *DashboardServer) whatsAppGroupNames() map[string]string {
+	names := make(map[string]string)
+
+	client, ok := s.whatsappClient.(*whatsmeow.Client)
+	if !ok || client == nil || !client.IsConnected() {
+		return names
+	}
+
+	groups, err := client.GetJoinedGroups()
+	if err != nil {
+		s.logger.Warningf("Failed to get WhatsApp group names: %v", err)
+		return names
+	}
+	for _, group := range groups {
+		names[group.JID.String()] = group.Name
+	}
+	return names
+}